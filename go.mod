@@ -2,4 +2,4 @@ module github.com/idesyatov/http-runner
 
 go 1.23.6
 
-require gopkg.in/yaml.v2 v2.4.0 // indirect
+require gopkg.in/yaml.v2 v2.4.0
@@ -0,0 +1,108 @@
+// Package capacity searches for the highest request rate a service sustains
+// within an SLO ("find the knee"). It drives a caller-supplied probe at
+// different rates and judges each run with threshold conditions, so it knows
+// nothing about HTTP itself.
+//
+// Two strategies are supported: "step" raises the rate by a fixed increment
+// until the SLO is breached, and "binary" bisects between the lowest and
+// highest rate until the bracket is no wider than the step.
+package capacity
+
+import (
+	"context"
+
+	"github.com/idesyatov/http-runner/internal/threshold"
+)
+
+// Search modes.
+const (
+	ModeStep   = "step"
+	ModeBinary = "binary"
+)
+
+// Config describes a capacity search.
+type Config struct {
+	Mode string                // ModeStep or ModeBinary
+	Min  int                   // Lowest rate to try (req/s)
+	Max  int                   // Highest rate to try (req/s)
+	Step int                   // Step increment, or the resolution of a binary search
+	SLO  []threshold.Condition // Failure conditions; a step passes when none holds
+}
+
+// Probe runs load at rate and returns the run's metrics by threshold metric
+// name (durations in seconds).
+type Probe func(ctx context.Context, rate int) map[string]float64
+
+// Step is the outcome of one probe.
+type Step struct {
+	Rate     int                // Target rate of the probe
+	Metrics  map[string]float64 // Metrics returned by the probe
+	Failures []string           // SLO conditions that held; empty when the step passed
+}
+
+// Passed reports whether the step stayed within the SLO.
+func (s Step) Passed() bool { return len(s.Failures) == 0 }
+
+// Result is the outcome of a search.
+type Result struct {
+	MaxRate     int    // Highest passing rate (0 if no rate passed)
+	Steps       []Step // Every probe, in the order it ran
+	Interrupted bool   // The search stopped early because ctx was cancelled
+}
+
+// Search probes increasing rates until the SLO is breached and returns the
+// highest rate that passed. A probe interrupted by ctx is discarded, since a
+// partial run says nothing about the rate, and ends the search.
+func Search(ctx context.Context, cfg Config, probe Probe) Result {
+	var res Result
+	// try runs one probe and reports whether it passed; ok is false when the
+	// search must stop because ctx was cancelled.
+	try := func(rate int) (passed, ok bool) {
+		metrics := probe(ctx, rate)
+		if ctx.Err() != nil {
+			res.Interrupted = true
+			return false, false
+		}
+		step := Step{Rate: rate, Metrics: metrics, Failures: threshold.Evaluate(cfg.SLO, metrics)}
+		res.Steps = append(res.Steps, step)
+		if step.Passed() && rate > res.MaxRate {
+			res.MaxRate = rate
+		}
+		return step.Passed(), true
+	}
+
+	if cfg.Mode == ModeStep {
+		// The last step is clamped to Max, so Max is probed even when the
+		// range is not a multiple of the step.
+		for rate := cfg.Min; ; rate = min(rate+cfg.Step, cfg.Max) {
+			if passed, ok := try(rate); !passed || !ok || rate >= cfg.Max {
+				break
+			}
+		}
+		return res
+	}
+
+	// Binary: establish a passing lower bound and a failing upper bound, then
+	// halve the gap until it is within one step.
+	passed, ok := try(cfg.Min)
+	if !passed || !ok {
+		return res
+	}
+	if passed, ok = try(cfg.Max); passed || !ok {
+		return res
+	}
+	lo, hi := cfg.Min, cfg.Max
+	for hi-lo > cfg.Step {
+		mid := lo + (hi-lo)/2
+		passed, ok := try(mid)
+		if !ok {
+			break
+		}
+		if passed {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return res
+}
@@ -0,0 +1,100 @@
+package capacity
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/idesyatov/http-runner/internal/threshold"
+)
+
+// kneeAt returns a probe whose p99 jumps past 300ms above the given rate.
+func kneeAt(knee int, calls *[]int) Probe {
+	return func(_ context.Context, rate int) map[string]float64 {
+		*calls = append(*calls, rate)
+		p99 := 0.1
+		if rate > knee {
+			p99 = 0.5
+		}
+		return map[string]float64{"p99": p99, "success": 100}
+	}
+}
+
+func slo(t *testing.T) []threshold.Condition {
+	t.Helper()
+	conds, err := threshold.Parse("p99>300ms,success<99.9")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	return conds
+}
+
+func TestSearch_Step(t *testing.T) {
+	var calls []int
+	res := Search(context.Background(), Config{Mode: ModeStep, Min: 100, Max: 1000, Step: 100, SLO: slo(t)}, kneeAt(450, &calls))
+
+	if res.MaxRate != 400 {
+		t.Errorf("expected max passing rate 400, got %d", res.MaxRate)
+	}
+	// 100..400 pass, 500 fails and stops the search.
+	if len(res.Steps) != 5 || res.Steps[4].Passed() {
+		t.Errorf("expected 5 steps ending in a failure, got %+v", res.Steps)
+	}
+}
+
+func TestSearch_StepClampedToMax(t *testing.T) {
+	var calls []int
+	res := Search(context.Background(), Config{Mode: ModeStep, Min: 100, Max: 350, Step: 100, SLO: slo(t)}, kneeAt(1000, &calls))
+
+	if res.MaxRate != 350 {
+		t.Errorf("expected max passing rate 350, got %d", res.MaxRate)
+	}
+	if want := []int{100, 200, 300, 350}; !slices.Equal(calls, want) {
+		t.Errorf("expected probes at %v, got %v", want, calls)
+	}
+}
+
+func TestSearch_Binary(t *testing.T) {
+	var calls []int
+	res := Search(context.Background(), Config{Mode: ModeBinary, Min: 10, Max: 1000, Step: 10, SLO: slo(t)}, kneeAt(450, &calls))
+
+	if res.MaxRate < 440 || res.MaxRate > 450 {
+		t.Errorf("expected max passing rate within one step below 450, got %d", res.MaxRate)
+	}
+	if len(calls) > 10 {
+		t.Errorf("expected a binary search to need few probes, got %d: %v", len(calls), calls)
+	}
+}
+
+func TestSearch_BinaryMaxPasses(t *testing.T) {
+	var calls []int
+	res := Search(context.Background(), Config{Mode: ModeBinary, Min: 10, Max: 100, Step: 10, SLO: slo(t)}, kneeAt(1000, &calls))
+
+	if res.MaxRate != 100 || len(calls) != 2 {
+		t.Errorf("expected max rate 100 after 2 probes, got %d after %v", res.MaxRate, calls)
+	}
+}
+
+func TestSearch_NothingPasses(t *testing.T) {
+	var calls []int
+	res := Search(context.Background(), Config{Mode: ModeBinary, Min: 10, Max: 100, Step: 10, SLO: slo(t)}, kneeAt(5, &calls))
+
+	if res.MaxRate != 0 || len(res.Steps) != 1 {
+		t.Errorf("expected no passing rate after 1 step, got %d after %d steps", res.MaxRate, len(res.Steps))
+	}
+}
+
+func TestSearch_Interrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	probe := func(_ context.Context, rate int) map[string]float64 {
+		if rate >= 30 {
+			cancel()
+		}
+		return map[string]float64{"p99": 0.1}
+	}
+	res := Search(ctx, Config{Mode: ModeStep, Min: 10, Max: 100, Step: 10, SLO: slo(t)}, probe)
+
+	if !res.Interrupted || res.MaxRate != 20 || len(res.Steps) != 2 {
+		t.Errorf("expected an interrupted search at 20 req/s after 2 steps, got %+v", res)
+	}
+}
@@ -0,0 +1,174 @@
+// Package check asserts properties of HTTP responses beyond a 2xx status:
+// the status code, headers, the body's content and size, and values in a JSON
+// body. A response that fails any check is not counted as a success.
+package check
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/idesyatov/http-runner/pkg/jsonpath"
+)
+
+// Spec describes a check as configured. Exactly one of Status, Header,
+// BodyContains, BodyMatches, JSONPath and MaxBodySize is set; Equals or
+// Matches qualify Header and JSONPath, which otherwise only need to be
+// present.
+type Spec struct {
+	Name         string // Label in the report; derived from the check if empty
+	Status       []int  // The status code must be one of these
+	Header       string // A response header
+	JSONPath     string // A JSONPath into the JSON body
+	Equals       string // The header or JSONPath value must equal this
+	Matches      string // The header or JSONPath value must match this regular expression
+	BodyContains string // The body must contain this text
+	BodyMatches  string // The body must match this regular expression
+	MaxBodySize  int64  // The body must be at most this many bytes
+}
+
+// Check is a compiled check. It is safe for concurrent use.
+type Check struct {
+	Name string // Label in the report
+
+	status   map[int]bool
+	header   string
+	path     *jsonpath.Path
+	equals   string
+	re       *regexp.Regexp // Matches or BodyMatches
+	contains string
+	maxSize  int64
+}
+
+// New compiles a check, or returns an error if it does not set exactly one
+// kind or an expression is invalid.
+func New(s Spec) (*Check, error) {
+	kinds := 0
+	for _, set := range []bool{len(s.Status) > 0, s.Header != "", s.JSONPath != "", s.BodyContains != "", s.BodyMatches != "", s.MaxBodySize != 0} {
+		if set {
+			kinds++
+		}
+	}
+	if kinds != 1 {
+		return nil, fmt.Errorf("set exactly one of status, header, jsonpath, bodyContains, bodyMatches or maxBodySize")
+	}
+	if (s.Equals != "" || s.Matches != "") && s.Header == "" && s.JSONPath == "" {
+		return nil, fmt.Errorf("equals and matches only apply to header and jsonpath")
+	}
+	if s.Equals != "" && s.Matches != "" {
+		return nil, fmt.Errorf("set equals or matches, not both")
+	}
+
+	c := &Check{header: s.Header, equals: s.Equals, contains: s.BodyContains, maxSize: s.MaxBodySize}
+	var err error
+	switch {
+	case s.Matches != "":
+		c.re, err = regexp.Compile(s.Matches)
+	case s.BodyMatches != "":
+		c.re, err = regexp.Compile(s.BodyMatches)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if s.JSONPath != "" {
+		p, err := jsonpath.Parse(s.JSONPath)
+		if err != nil {
+			return nil, err
+		}
+		c.path = &p
+	}
+	if len(s.Status) > 0 {
+		c.status = make(map[int]bool, len(s.Status))
+		for _, code := range s.Status {
+			c.status[code] = true
+		}
+	}
+	if s.MaxBodySize < 0 {
+		return nil, fmt.Errorf("maxBodySize must be > 0, got %d", s.MaxBodySize)
+	}
+
+	c.Name = s.Name
+	if c.Name == "" {
+		c.Name = describe(s)
+	}
+	return c, nil
+}
+
+// describe returns a default name for a check, e.g. "status 200|201" or
+// "$.state == active".
+func describe(s Spec) string {
+	var subject string
+	switch {
+	case len(s.Status) > 0:
+		codes := make([]string, len(s.Status))
+		for i, code := range s.Status {
+			codes[i] = strconv.Itoa(code)
+		}
+		return "status " + strings.Join(codes, "|")
+	case s.BodyContains != "":
+		return fmt.Sprintf("body contains %q", s.BodyContains)
+	case s.BodyMatches != "":
+		return "body matches " + s.BodyMatches
+	case s.MaxBodySize != 0:
+		return fmt.Sprintf("body size <= %d", s.MaxBodySize)
+	case s.Header != "":
+		subject = "header " + s.Header
+	default:
+		subject = s.JSONPath
+	}
+	switch {
+	case s.Equals != "":
+		return subject + " == " + s.Equals
+	case s.Matches != "":
+		return subject + " matches " + s.Matches
+	default:
+		return subject + " exists"
+	}
+}
+
+// ChecksStatus reports whether the check is on the status code, which then
+// replaces the default 2xx rule for success.
+func (c *Check) ChecksStatus() bool { return c.status != nil }
+
+// NeedsBody reports whether the check reads the response body.
+func (c *Check) NeedsBody() bool {
+	return c.path != nil || c.contains != "" || (c.re != nil && c.header == "")
+}
+
+// Pass reports whether resp passes the check. body is the response body if
+// NeedsBody, and size the number of body bytes read.
+func (c *Check) Pass(resp *http.Response, body []byte, size int64) bool {
+	switch {
+	case c.status != nil:
+		return c.status[resp.StatusCode]
+	case c.maxSize != 0:
+		return size <= c.maxSize
+	case c.contains != "":
+		return strings.Contains(string(body), c.contains)
+	case c.header != "":
+		v := resp.Header.Values(c.header)
+		if len(v) == 0 {
+			return false
+		}
+		return c.value(v[0])
+	case c.path != nil:
+		v, ok := c.path.Lookup(body)
+		return ok && c.value(v)
+	default: // body matches
+		return c.re.Match(body)
+	}
+}
+
+// value checks a header or JSONPath value against Equals or Matches, if set.
+func (c *Check) value(v string) bool {
+	switch {
+	case c.equals != "":
+		return v == c.equals
+	case c.re != nil:
+		return c.re.MatchString(v)
+	default:
+		return true
+	}
+}
@@ -0,0 +1,103 @@
+package check
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestPass(t *testing.T) {
+	resp := &http.Response{StatusCode: 201, Header: http.Header{}}
+	resp.Header.Set("Content-Type", "application/json; charset=utf-8")
+	body := []byte(`{"state":"active","count":3}`)
+
+	tests := []struct {
+		spec Spec
+		want bool
+	}{
+		{Spec{Status: []int{200, 201}}, true},
+		{Spec{Status: []int{200}}, false},
+		{Spec{Header: "content-type"}, true},
+		{Spec{Header: "Content-Type", Matches: "^application/json"}, true},
+		{Spec{Header: "Content-Type", Equals: "text/html"}, false},
+		{Spec{Header: "ETag"}, false},
+		{Spec{BodyContains: `"state":"active"`}, true},
+		{Spec{BodyContains: "error"}, false},
+		{Spec{BodyMatches: `"count":\d+`}, true},
+		{Spec{JSONPath: "$.state", Equals: "active"}, true},
+		{Spec{JSONPath: "$.count", Equals: "3"}, true},
+		{Spec{JSONPath: "$.count", Matches: "^[4-9]$"}, false},
+		{Spec{JSONPath: "$.missing"}, false},
+		{Spec{MaxBodySize: int64(len(body))}, true},
+		{Spec{MaxBodySize: 10}, false},
+	}
+	for _, tt := range tests {
+		c, err := New(tt.spec)
+		if err != nil {
+			t.Errorf("New(%+v): %v", tt.spec, err)
+			continue
+		}
+		if got := c.Pass(resp, body, int64(len(body))); got != tt.want {
+			t.Errorf("%s: got %v, want %v", c.Name, got, tt.want)
+		}
+	}
+}
+
+func TestNew_Names(t *testing.T) {
+	tests := []struct {
+		spec Spec
+		want string
+	}{
+		{Spec{Status: []int{200, 204}}, "status 200|204"},
+		{Spec{Header: "Content-Type", Matches: "json"}, "header Content-Type matches json"},
+		{Spec{JSONPath: "$.state", Equals: "active"}, "$.state == active"},
+		{Spec{JSONPath: "$.id"}, "$.id exists"},
+		{Spec{BodyContains: "ok"}, `body contains "ok"`},
+		{Spec{MaxBodySize: 1024}, "body size <= 1024"},
+		{Spec{Name: "is json", Header: "Content-Type"}, "is json"},
+	}
+	for _, tt := range tests {
+		c, err := New(tt.spec)
+		if err != nil {
+			t.Errorf("New(%+v): %v", tt.spec, err)
+			continue
+		}
+		if c.Name != tt.want {
+			t.Errorf("name = %q, want %q", c.Name, tt.want)
+		}
+	}
+}
+
+func TestNew_Errors(t *testing.T) {
+	for _, spec := range []Spec{
+		{},
+		{Status: []int{200}, BodyContains: "ok"},
+		{BodyContains: "ok", Equals: "x"},
+		{Header: "X", Equals: "a", Matches: "b"},
+		{Header: "X", Matches: "(unclosed"},
+		{BodyMatches: "(unclosed"},
+		{JSONPath: "$.items["},
+		{MaxBodySize: -1},
+	} {
+		if _, err := New(spec); err == nil {
+			t.Errorf("New(%+v): expected an error", spec)
+		}
+	}
+}
+
+func TestNeedsBody(t *testing.T) {
+	for _, tt := range []struct {
+		spec Spec
+		want bool
+	}{
+		{Spec{Status: []int{200}}, false},
+		{Spec{Header: "X", Matches: "y"}, false},
+		{Spec{MaxBodySize: 10}, false},
+		{Spec{BodyMatches: "y"}, true},
+		{Spec{JSONPath: "$.a"}, true},
+	} {
+		c, _ := New(tt.spec)
+		if c.NeedsBody() != tt.want {
+			t.Errorf("%s: NeedsBody() = %v, want %v", c.Name, c.NeedsBody(), tt.want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Package extract pulls values out of HTTP responses so that later requests
+// of a scenario can use them, e.g. a token from a login response in the
+// Authorization header of the next request.
+package extract
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/idesyatov/http-runner/pkg/jsonpath"
+)
+
+// Sources a value can be extracted from.
+const (
+	SourceJSONPath = "jsonpath" // A JSONPath into the JSON body, e.g. $.token
+	SourceHeader   = "header"   // A response header, e.g. Location
+	SourceRegex    = "regex"    // A regular expression over the body; the first group if it has one
+	SourceCookie   = "cookie"   // A cookie set by the response, by name
+)
+
+// Extractor extracts one value from a response into a variable.
+type Extractor struct {
+	Name   string // Variable the value is stored in
+	Source string // Where the value comes from: one of the Source constants
+	Expr   string // JSONPath, header name, regular expression or cookie name
+
+	path jsonpath.Path
+	re   *regexp.Regexp
+}
+
+// New returns an extractor of the value at expr in source into the variable
+// name, or an error if the expression is invalid.
+func New(name, source, expr string) (Extractor, error) {
+	x := Extractor{Name: name, Source: source, Expr: expr}
+	if expr == "" {
+		return x, fmt.Errorf("%s: empty %s", name, source)
+	}
+	var err error
+	switch source {
+	case SourceJSONPath:
+		x.path, err = jsonpath.Parse(expr)
+	case SourceRegex:
+		x.re, err = regexp.Compile(expr)
+	case SourceHeader, SourceCookie:
+	default:
+		return x, fmt.Errorf("%s: unknown source %q (expected jsonpath, header, regex or cookie)", name, source)
+	}
+	if err != nil {
+		return x, fmt.Errorf("%s: %w", name, err)
+	}
+	return x, nil
+}
+
+// NeedsBody reports whether the extractor reads the response body.
+func (x Extractor) NeedsBody() bool {
+	return x.Source == SourceJSONPath || x.Source == SourceRegex
+}
+
+// Extract returns the value in resp, whose body has been read into body, and
+// whether it was found.
+func (x Extractor) Extract(resp *http.Response, body []byte) (string, bool) {
+	switch x.Source {
+	case SourceJSONPath:
+		return x.path.Lookup(body)
+	case SourceHeader:
+		v := resp.Header.Values(x.Expr)
+		if len(v) == 0 {
+			return "", false
+		}
+		return v[0], true
+	case SourceRegex:
+		m := x.re.FindSubmatch(body)
+		if m == nil {
+			return "", false
+		}
+		if len(m) > 1 {
+			return string(m[1]), true
+		}
+		return string(m[0]), true
+	case SourceCookie:
+		for _, c := range resp.Cookies() {
+			if c.Name == x.Expr {
+				return c.Value, true
+			}
+		}
+	}
+	return "", false
+}
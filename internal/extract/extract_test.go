@@ -0,0 +1,70 @@
+package extract
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	resp := &http.Response{Header: http.Header{}}
+	resp.Header.Set("Location", "/orders/42")
+	resp.Header.Add("Set-Cookie", "theme=dark")
+	resp.Header.Add("Set-Cookie", "SESSION=s3cr3t; Path=/; HttpOnly")
+	body := []byte(`{"token":"abc","user":{"id":7}} <input name="csrf" value="x-9">`)
+	jsonBody := []byte(`{"token":"abc","user":{"id":7}}`)
+
+	tests := []struct {
+		source, expr string
+		body         []byte
+		want         string
+	}{
+		{SourceJSONPath, "$.token", jsonBody, "abc"},
+		{SourceJSONPath, "$.user.id", jsonBody, "7"},
+		{SourceHeader, "location", nil, "/orders/42"},
+		{SourceRegex, `name="csrf" value="([^"]+)"`, body, "x-9"},
+		{SourceRegex, `\d+`, body, "7"},
+		{SourceCookie, "SESSION", nil, "s3cr3t"},
+	}
+	for _, tt := range tests {
+		x, err := New("v", tt.source, tt.expr)
+		if err != nil {
+			t.Errorf("New(%s, %q): %v", tt.source, tt.expr, err)
+			continue
+		}
+		if got, ok := x.Extract(resp, tt.body); !ok || got != tt.want {
+			t.Errorf("%s %q = %q (found %v), want %q", tt.source, tt.expr, got, ok, tt.want)
+		}
+	}
+}
+
+func TestExtract_NotFound(t *testing.T) {
+	resp := &http.Response{Header: http.Header{}}
+	body := []byte(`{"token":"abc"}`)
+	for _, src := range [][2]string{
+		{SourceJSONPath, "$.missing"},
+		{SourceHeader, "Location"},
+		{SourceRegex, `id=(\d+)`},
+		{SourceCookie, "SESSION"},
+	} {
+		x, err := New("v", src[0], src[1])
+		if err != nil {
+			t.Fatalf("New(%s, %q): %v", src[0], src[1], err)
+		}
+		if got, ok := x.Extract(resp, body); ok {
+			t.Errorf("%s %q: expected no value, got %q", src[0], src[1], got)
+		}
+	}
+}
+
+func TestNew_Errors(t *testing.T) {
+	for _, src := range [][2]string{
+		{"xpath", "//a"},
+		{SourceJSONPath, "$.items["},
+		{SourceRegex, "(unclosed"},
+		{SourceHeader, ""},
+	} {
+		if _, err := New("v", src[0], src[1]); err == nil {
+			t.Errorf("New(%s, %q): expected an error", src[0], src[1])
+		}
+	}
+}
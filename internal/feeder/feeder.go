@@ -0,0 +1,232 @@
+// Package feeder supplies rows of test data (IDs, credentials, search terms)
+// from a CSV or JSONL file, one row per request. A row's columns become
+// template variables, so {{.user_id}} in the URL takes each row's user_id in
+// turn.
+package feeder
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Strategies select the order in which rows are handed out.
+const (
+	StrategySequential = "sequential" // File order
+	StrategyRandom     = "random"     // A random row each time; never runs out
+	StrategyUnique     = "unique"     // Each row once, in random order
+)
+
+// What a feeder does once every row has been used.
+const (
+	ExhaustedWrap = "wrap" // Start over (reshuffled for StrategyUnique)
+	ExhaustedStop = "stop" // Report exhaustion; the run stops launching requests
+)
+
+// Row is one record: column name -> value.
+type Row map[string]string
+
+// Feeder hands out rows. It is safe for concurrent use.
+type Feeder struct {
+	mu          sync.Mutex
+	rows        []Row
+	columns     []string
+	strategy    string
+	onExhausted string
+	seed        uint64
+	rng         *rand.Rand
+	order       []int // StrategyUnique: the current permutation of rows
+	pos         int   // Next position in rows (or order)
+}
+
+// Open loads the rows of path and returns a feeder over them. The format is
+// chosen by extension: .csv (the first line names the columns) or .jsonl /
+// .ndjson (one JSON object per line). A seed of 0 picks a random seed.
+func Open(path, strategy, onExhausted string, seed int64) (*Feeder, error) {
+	rows, columns, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return New(rows, columns, strategy, onExhausted, seed), nil
+}
+
+// New returns a feeder over rows. An empty strategy means StrategySequential
+// and an empty onExhausted means ExhaustedWrap.
+func New(rows []Row, columns []string, strategy, onExhausted string, seed int64) *Feeder {
+	if strategy == "" {
+		strategy = StrategySequential
+	}
+	if onExhausted == "" {
+		onExhausted = ExhaustedWrap
+	}
+	s := uint64(seed)
+	if s == 0 {
+		s = rand.Uint64()
+	}
+	f := &Feeder{
+		rows:        rows,
+		columns:     columns,
+		strategy:    strategy,
+		onExhausted: onExhausted,
+		seed:        s,
+		rng:         rand.New(rand.NewPCG(s, 0)),
+	}
+	if strategy == StrategyUnique {
+		f.order = f.rng.Perm(len(rows))
+	}
+	return f
+}
+
+// Fork returns the feeder for requests sent alongside those drawing on f,
+// such as a warm-up ahead of them. Rows handed out in turn (sequential or
+// unique) are used up in f as well, so no row is sent twice, and the fork is
+// f itself. A random feeder forks into one with a random stream of its own.
+func (f *Feeder) Fork() *Feeder {
+	if f.strategy != StrategyRandom {
+		return f
+	}
+	return &Feeder{
+		rows:        f.rows,
+		columns:     f.columns,
+		strategy:    f.strategy,
+		onExhausted: f.onExhausted,
+		seed:        f.seed,
+		rng:         rand.New(rand.NewPCG(f.seed, 1)),
+	}
+}
+
+// Columns returns the column names: in header order for CSV, sorted for JSONL.
+func (f *Feeder) Columns() []string { return f.columns }
+
+// Next returns the next row, or false once the rows are used up and the
+// feeder is set to stop.
+func (f *Feeder) Next() (Row, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.rows) == 0 {
+		return nil, false
+	}
+	if f.strategy == StrategyRandom {
+		return f.rows[f.rng.IntN(len(f.rows))], true
+	}
+	if f.pos == len(f.rows) {
+		if f.onExhausted == ExhaustedStop {
+			return nil, false
+		}
+		f.pos = 0
+		if f.strategy == StrategyUnique {
+			f.order = f.rng.Perm(len(f.rows))
+		}
+	}
+	i := f.pos
+	if f.strategy == StrategyUnique {
+		i = f.order[i]
+	}
+	f.pos++
+	return f.rows[i], true
+}
+
+// Load reads the rows of a CSV or JSONL file and their column names.
+func Load(path string) ([]Row, []string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	var rows []Row
+	var columns []string
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".csv":
+		rows, columns, err = readCSV(file)
+	case ".jsonl", ".ndjson":
+		rows, columns, err = readJSONL(file)
+	default:
+		return nil, nil, fmt.Errorf("%s: unsupported feeder format %q (expected .csv, .jsonl or .ndjson)", path, ext)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(rows) == 0 {
+		return nil, nil, fmt.Errorf("%s: no rows", path)
+	}
+	return rows, columns, nil
+}
+
+func readCSV(r io.Reader) ([]Row, []string, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+	var rows []Row
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		row := make(Row, len(header))
+		for i, col := range header {
+			row[col] = rec[i]
+		}
+		rows = append(rows, row)
+	}
+	return rows, header, nil
+}
+
+// readJSONL reads one JSON object per line. Values that are not strings are
+// kept in their JSON form (e.g. 42, true, {"a":1}).
+func readJSONL(r io.Reader) ([]Row, []string, error) {
+	seen := make(map[string]bool)
+	var rows []Row
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" {
+			continue
+		}
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(text), &obj); err != nil {
+			return nil, nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		row := make(Row, len(obj))
+		for k, raw := range obj {
+			var s string
+			if err := json.Unmarshal(raw, &s); err == nil {
+				row[k] = s
+			} else {
+				row[k] = string(raw)
+			}
+			seen[k] = true
+		}
+		rows = append(rows, row)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, nil, err
+	}
+	columns := make([]string, 0, len(seen))
+	for k := range seen {
+		columns = append(columns, k)
+	}
+	sort.Strings(columns)
+	return rows, columns, nil
+}
@@ -0,0 +1,155 @@
+package feeder
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFile writes content to name in a temporary directory and returns its path.
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func rowsOf(ids ...string) []Row {
+	var rows []Row
+	for _, id := range ids {
+		rows = append(rows, Row{"id": id})
+	}
+	return rows
+}
+
+// take returns the ids of the next n rows, stopping early on exhaustion.
+func take(f *Feeder, n int) []string {
+	var ids []string
+	for i := 0; i < n; i++ {
+		row, ok := f.Next()
+		if !ok {
+			break
+		}
+		ids = append(ids, row["id"])
+	}
+	return ids
+}
+
+func TestLoad_CSV(t *testing.T) {
+	path := writeFile(t, "users.csv", "user_id, name\n1,alice\n2,\"bob, jr\"\n")
+	rows, columns, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if strings.Join(columns, ",") != "user_id,name" {
+		t.Errorf("unexpected columns %v", columns)
+	}
+	if len(rows) != 2 || rows[1]["user_id"] != "2" || rows[1]["name"] != "bob, jr" {
+		t.Errorf("unexpected rows %v", rows)
+	}
+}
+
+func TestLoad_JSONL(t *testing.T) {
+	path := writeFile(t, "terms.jsonl", `{"term":"shoes","page":2}`+"\n\n"+`{"term":"hats","filter":{"size":"m"}}`+"\n")
+	rows, columns, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if strings.Join(columns, ",") != "filter,page,term" {
+		t.Errorf("unexpected columns %v", columns)
+	}
+	if len(rows) != 2 || rows[0]["page"] != "2" || rows[1]["filter"] != `{"size":"m"}` || rows[1]["term"] != "hats" {
+		t.Errorf("unexpected rows %v", rows)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	cases := map[string]string{
+		"data.txt":   "a\n1\n",
+		"empty.csv":  "id\n",
+		"bad.jsonl":  "{not json}\n",
+		"ragged.csv": "a,b\n1\n",
+	}
+	for name, content := range cases {
+		if _, _, err := Load(writeFile(t, name, content)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if _, _, err := Load(filepath.Join(t.TempDir(), "missing.csv")); err == nil {
+		t.Errorf("expected an error for a missing file")
+	}
+}
+
+func TestNext_Sequential(t *testing.T) {
+	wrap := New(rowsOf("a", "b", "c"), []string{"id"}, StrategySequential, ExhaustedWrap, 1)
+	if got := strings.Join(take(wrap, 5), ""); got != "abcab" {
+		t.Errorf("expected abcab, got %s", got)
+	}
+	stop := New(rowsOf("a", "b", "c"), []string{"id"}, StrategySequential, ExhaustedStop, 1)
+	if got := strings.Join(take(stop, 5), ""); got != "abc" {
+		t.Errorf("expected abc then exhaustion, got %s", got)
+	}
+}
+
+func TestNext_Unique(t *testing.T) {
+	f := New(rowsOf("a", "b", "c", "d"), []string{"id"}, StrategyUnique, ExhaustedStop, 1)
+	ids := take(f, 10)
+	if len(ids) != 4 {
+		t.Fatalf("expected 4 rows before exhaustion, got %v", ids)
+	}
+	seen := map[string]bool{}
+	for _, id := range ids {
+		if seen[id] {
+			t.Errorf("row %s handed out twice", id)
+		}
+		seen[id] = true
+	}
+
+	wrap := New(rowsOf("a", "b", "c", "d"), []string{"id"}, StrategyUnique, ExhaustedWrap, 1)
+	if ids := take(wrap, 8); len(ids) != 8 {
+		t.Errorf("expected a wrapping feeder to keep going, got %v", ids)
+	}
+}
+
+func TestFork(t *testing.T) {
+	f := New(rowsOf("a", "b", "c"), []string{"id"}, StrategyUnique, ExhaustedStop, 1)
+	f.Next()
+	if ids := take(f.Fork(), 1); len(ids) != 1 {
+		t.Fatalf("expected the fork to hand out a row, got %v", ids)
+	}
+	if ids := take(f, 5); len(ids) != 1 {
+		t.Errorf("expected the rows taken by the fork to be used up, got %v", ids)
+	}
+
+	r := New(rowsOf("a", "b", "c", "d", "e"), []string{"id"}, StrategyRandom, ExhaustedStop, 1)
+	want := strings.Join(take(New(rowsOf("a", "b", "c", "d", "e"), []string{"id"}, StrategyRandom, ExhaustedStop, 1), 50), "")
+	if strings.Join(take(r.Fork(), 50), "") == want {
+		t.Errorf("expected a random fork to draw rows of its own")
+	}
+	if got := strings.Join(take(r, 50), ""); got != want {
+		t.Errorf("expected the fork to leave the rows drawn by the original alone")
+	}
+}
+
+func TestNext_Random(t *testing.T) {
+	f := New(rowsOf("a", "b", "c"), []string{"id"}, StrategyRandom, ExhaustedStop, 1)
+	ids := take(f, 300)
+	if len(ids) != 300 {
+		t.Fatalf("expected a random feeder never to run out, got %d rows", len(ids))
+	}
+	counts := map[string]int{}
+	for _, id := range ids {
+		counts[id]++
+	}
+	if len(counts) != 3 {
+		t.Errorf("expected every row to be drawn, got %v", counts)
+	}
+
+	again := New(rowsOf("a", "b", "c"), []string{"id"}, StrategyRandom, ExhaustedStop, 1)
+	if strings.Join(take(again, 300), "") != strings.Join(ids, "") {
+		t.Errorf("expected the same seed to draw the same rows")
+	}
+}
@@ -5,9 +5,13 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/idesyatov/http-runner/internal/check"
+	"github.com/idesyatov/http-runner/internal/extract"
+	"github.com/idesyatov/http-runner/internal/templating"
 	"github.com/idesyatov/http-runner/internal/threshold"
 	"gopkg.in/yaml.v2"
 )
@@ -16,12 +20,41 @@ const defaultTimeout = 5 * time.Second
 
 // Config holds the configuration options for the HTTP client application.
 type Config struct {
-	ShowVersion bool                  // Flag to indicate whether to display the application version.
-	Output      string                // Output format: "text" or "json".
-	Insecure    bool                  // Skip TLS certificate verification.
-	Redirects   bool                  // Follow HTTP redirects.
-	Thresholds  []threshold.Condition // Pass/fail conditions; a violation exits non-zero.
-	Endpoints   []Endpoint            // List of endpoints to process.
+	ShowVersion bool                   // Flag to indicate whether to display the application version.
+	Output      string                 // Output format: "text", "json", "ndjson", "html", "junit", "markdown" or "csv".
+	Insecure    bool                   // Skip TLS certificate verification.
+	Redirects   bool                   // Follow HTTP redirects.
+	Thresholds  []threshold.Condition  // Pass/fail conditions; a violation exits non-zero.
+	AbortOnFail []threshold.Condition  // Conditions that stop a run early when they hold over the abort window.
+	AbortWindow time.Duration          // Width of the rolling window the abort conditions are evaluated over.
+	Search      *Search                // Capacity search settings; nil runs each endpoint once.
+	Precision   int                    // Significant digits kept by latency histograms (1-5).
+	Interval    time.Duration          // Width of a timeseries window in the JSON report.
+	Progress    bool                   // Draw a live status line on stderr when it is a terminal.
+	ResultsFile string                 // JSONL file every request is logged to; empty logs none.
+	Baseline    string                 // JSON report of a previous run to compare each report with; empty compares none.
+	HTMLReport  string                 // HTML file the reports are also written to; empty writes none.
+	JUnitFile   string                 // JUnit XML file the threshold results are also written to; empty writes none.
+	StepSummary bool                   // Append the markdown report to $GITHUB_STEP_SUMMARY, when it is set.
+	CSVFile     string                 // CSV file the reports are also written to, a row per endpoint; empty writes none.
+	CSVAppend   bool                   // Append rows to CSVFile instead of replacing it.
+	Regressions []threshold.Regression // Conditions on the change from the baseline; one holding exits non-zero.
+	Seed        int64                  // Seed for template random values; 0 picks a random seed.
+	ConfigPath  string                 // Path of the configuration file; empty when configured by flags.
+	Endpoints   []Endpoint             // List of endpoints to process.
+	Mix         *Endpoint              // Load settings of a traffic mix; nil runs the endpoints one after another.
+	Parallel    bool                   // Start every endpoint, mix and scenario at once instead of one after another.
+}
+
+// Search configures a capacity search: each endpoint is rerun at increasing
+// rates until the SLO is breached, and the highest passing rate is reported.
+type Search struct {
+	Mode         string                // "step" or "binary"
+	Min          int                   // Lowest rate to try (req/s)
+	Max          int                   // Highest rate to try (req/s)
+	Step         int                   // Step increment, or binary search resolution (req/s)
+	StepDuration time.Duration         // How long each rate is held
+	SLO          []threshold.Condition // A step fails if any condition holds
 }
 
 // Duration wraps time.Duration so it can be unmarshalled from a YAML string
@@ -45,6 +78,61 @@ func (d *Duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	return nil
 }
 
+// Warmup is a warm-up phase, given either as a request count ("100") or a
+// duration ("10s"). Its requests are sent normally but left out of the
+// report.
+type Warmup struct {
+	Count    int           // Requests to send before measuring (0 = none)
+	Duration time.Duration // Time to send requests for before measuring (0 = none)
+}
+
+// UnmarshalYAML accepts a warm-up written as a number (a request count) or as
+// a string holding a count or a duration.
+func (w *Warmup) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var n int
+	if err := unmarshal(&n); err == nil {
+		*w = Warmup{Count: n}
+		return nil
+	}
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	v, err := parseWarmup(s)
+	if err != nil {
+		return err
+	}
+	*w = v
+	return nil
+}
+
+// Thresholds are pass/fail conditions set for one endpoint, in -fail-if
+// syntax.
+type Thresholds []threshold.Condition
+
+// UnmarshalYAML accepts conditions written as one comma-separated string
+// ("p99>300ms,success<99") or as a list of them.
+func (t *Thresholds) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var specs []string
+	if err := unmarshal(&specs); err != nil {
+		var s string
+		if err := unmarshal(&s); err != nil {
+			return err
+		}
+		specs = []string{s}
+	}
+	var conds []threshold.Condition
+	for _, spec := range specs {
+		c, err := threshold.Parse(spec)
+		if err != nil {
+			return fmt.Errorf("invalid thresholds: %w", err)
+		}
+		conds = append(conds, c...)
+	}
+	*t = conds
+	return nil
+}
+
 // Metadata contains information about the application version and its source repository.
 type Metadata struct {
 	Version string // The version of the application.
@@ -54,10 +142,17 @@ type Metadata struct {
 // ConfigFile holds the structure of the configuration file.
 type ConfigFile struct {
 	Endpoints []Endpoint `yaml:"endpoints"`
+	Scenarios []Endpoint `yaml:"scenarios"` // Multi-step user journeys; run after the endpoints.
+	Mix       *Endpoint  `yaml:"mix"`       // If set, the endpoints run at once as a weighted traffic mix under these load settings.
+	Parallel  bool       `yaml:"parallel"`  // If set, every run starts at once, each under its own load settings.
 }
 
-// Endpoint represents a single endpoint configuration.
+// Endpoint represents a single endpoint configuration. A scenario is an
+// endpoint whose iterations send its Steps in order instead of one request to
+// URL; every load setting (count, duration, rate, stages, ...) applies to its
+// iterations.
 type Endpoint struct {
+	Name        string            `yaml:"name"` // Label in the report (defaults to "scenario N" for scenarios).
 	URL         string            `yaml:"url"`
 	Verbose     bool              `yaml:"verbose"`
 	Method      string            `yaml:"method"`
@@ -65,9 +160,130 @@ type Endpoint struct {
 	Count       int               `yaml:"count"`
 	Concurrency int               `yaml:"concurrency"`
 	Data        interface{}       `yaml:"data"`
-	Timeout     Duration          `yaml:"timeout"`  // Per-request timeout (e.g. "10s").
-	Duration    Duration          `yaml:"duration"` // Run for this wall-clock time instead of Count.
-	Rate        int               `yaml:"rate"`     // Target requests per second (0 = unlimited).
+	Timeout     Duration          `yaml:"timeout"`     // Per-request timeout (e.g. "10s").
+	Duration    Duration          `yaml:"duration"`    // Run for this wall-clock time instead of Count.
+	Rate        int               `yaml:"rate"`        // Target requests per second (0 = unlimited).
+	Executor    string            `yaml:"executor"`    // "closed" (default) or "arrival-rate".
+	MaxInFlight int               `yaml:"maxInFlight"` // Arrival-rate only: cap on in-flight requests (0 = unlimited).
+	Stages      []Stage           `yaml:"stages"`      // Staged load profile; replaces count and duration.
+	Warmup      Warmup            `yaml:"warmup"`      // Warm-up phase excluded from the report (count or duration).
+	Feeder      Feeder            `yaml:"feeder"`      // Test data file whose rows become template variables.
+	Steps       []Step            `yaml:"steps"`       // Scenario only: the requests of one iteration, in order.
+	Checks      []Check           `yaml:"checks"`      // Assertions a response must pass to count as a success.
+	Weight      int               `yaml:"weight"`      // Share of a traffic mix's requests (default 1).
+	Thresholds  Thresholds        `yaml:"thresholds"`  // Pass/fail conditions; override -fail-if conditions on the same metrics.
+	AbortOnFail Thresholds        `yaml:"abortOnFail"` // Conditions that stop the run early; override -abort-on-fail conditions on the same metrics.
+	AbortWindow Duration          `yaml:"abortWindow"` // Rolling window abortOnFail is evaluated over (default: -abort-window).
+}
+
+// Check is an assertion on a response. Exactly one of status, header,
+// jsonpath, bodyContains, bodyMatches and maxBodySize is set; equals or
+// matches qualify header and jsonpath, which otherwise only need to be
+// present.
+type Check struct {
+	Name         string `yaml:"name"`         // Label in the report (derived from the check if empty).
+	Status       []int  `yaml:"status"`       // The status code must be one of these (replaces the 2xx rule).
+	Header       string `yaml:"header"`       // A response header.
+	JSONPath     string `yaml:"jsonpath"`     // A JSONPath into the JSON body, e.g. $.state
+	Equals       string `yaml:"equals"`       // The header or JSONPath value must equal this.
+	Matches      string `yaml:"matches"`      // The header or JSONPath value must match this regular expression.
+	BodyContains string `yaml:"bodyContains"` // The body must contain this text.
+	BodyMatches  string `yaml:"bodyMatches"`  // The body must match this regular expression.
+	MaxBodySize  int64  `yaml:"maxBodySize"`  // The body must be at most this many bytes.
+}
+
+// Spec returns the check in the form the check package compiles.
+func (c Check) Spec() check.Spec {
+	return check.Spec{
+		Name:         c.Name,
+		Status:       c.Status,
+		Header:       c.Header,
+		JSONPath:     c.JSONPath,
+		Equals:       c.Equals,
+		Matches:      c.Matches,
+		BodyContains: c.BodyContains,
+		BodyMatches:  c.BodyMatches,
+		MaxBodySize:  c.MaxBodySize,
+	}
+}
+
+// Step is one request of a scenario. Its templates can use the feeder's
+// columns and every variable extracted by the steps before it.
+type Step struct {
+	Name    string             `yaml:"name"` // Label in the report (defaults to "METHOD URL").
+	Method  string             `yaml:"method"`
+	URL     string             `yaml:"url"`
+	Headers map[string]string  `yaml:"headers"`
+	Data    interface{}        `yaml:"data"`
+	Extract map[string]Extract `yaml:"extract"` // Variable name -> where to take its value from the response.
+	Checks  []Check            `yaml:"checks"`  // Assertions the response must pass for the iteration to go on.
+}
+
+// Extract names where a value is taken from in a response. Exactly one
+// field is set.
+type Extract struct {
+	JSONPath string `yaml:"jsonpath"` // A JSONPath into the JSON body, e.g. $.token
+	Header   string `yaml:"header"`   // A response header, e.g. Location
+	Regex    string `yaml:"regex"`    // A regular expression over the body; its first group if it has one
+	Cookie   string `yaml:"cookie"`   // A cookie set by the response, by name
+}
+
+// Source returns the extraction source and its expression, or an error
+// unless exactly one is set.
+func (x Extract) Source() (source, expr string, err error) {
+	n := 0
+	for _, f := range []struct{ source, expr string }{
+		{extract.SourceJSONPath, x.JSONPath},
+		{extract.SourceHeader, x.Header},
+		{extract.SourceRegex, x.Regex},
+		{extract.SourceCookie, x.Cookie},
+	} {
+		if f.expr != "" {
+			source, expr = f.source, f.expr
+			n++
+		}
+	}
+	if n != 1 {
+		return "", "", fmt.Errorf("set exactly one of jsonpath, header, regex or cookie")
+	}
+	return source, expr, nil
+}
+
+// Feeder points an endpoint at a CSV or JSONL file of test data. Each request
+// takes one row, and the row's columns are available to templates as
+// {{.column}}.
+type Feeder struct {
+	File        string `yaml:"file"`        // Path to a .csv, .jsonl or .ndjson file; empty disables the feeder.
+	Strategy    string `yaml:"strategy"`    // "sequential" (default), "random" or "unique".
+	OnExhausted string `yaml:"onExhausted"` // "wrap" (default) or "stop" once every row has been used.
+}
+
+// Stage is one step of a staged load profile: over Duration the rate ramps
+// linearly from the previous stage's target (the endpoint's rate for the first
+// stage) to Rate. Concurrency, if set, applies from the start of the stage.
+type Stage struct {
+	Duration    Duration `yaml:"duration"`
+	Rate        int      `yaml:"rate"`
+	Concurrency int      `yaml:"concurrency"`
+}
+
+// Label names the endpoint in messages: its name if it has one, otherwise its
+// URL.
+func (e Endpoint) Label() string {
+	if e.Name != "" {
+		return e.Name
+	}
+	return e.URL
+}
+
+// PeakConcurrency returns the highest concurrency the endpoint reaches across
+// its stages, used to size the connection pool.
+func (e Endpoint) PeakConcurrency() int {
+	peak := e.Concurrency
+	for _, st := range e.Stages {
+		peak = max(peak, st.Concurrency)
+	}
+	return peak
 }
 
 // DefineFlags defines the flags and returns them as a Config structure.
@@ -86,15 +302,58 @@ func DefineFlags() *Config {
 	timeout := flag.String("timeout", defaultTimeout.String(), "Per-request timeout (e.g. 10s, 500ms).")
 	loadDuration := flag.String("duration", "", "Run for this wall-clock duration instead of -count (e.g. 30s).")
 	rate := flag.Int("rate", 0, "Target requests per second (0 = unlimited).")
-	output := flag.String("output", "text", "Output format: text or json.")
+	warmup := flag.String("warmup", "", "Warm-up before measuring, as a request count (e.g. 100) or a duration (e.g. 10s); its requests are left out of the report.")
+	feederFile := flag.String("feeder", "", "CSV or JSONL file of test data; each request takes a row, whose columns are available to templates as {{.column}}.")
+	feederStrategy := flag.String("feeder-strategy", "sequential", "Order in which feeder rows are used: sequential, random or unique (each row once).")
+	feederExhausted := flag.String("feeder-exhausted", "wrap", "What to do once every feeder row has been used: wrap (start over) or stop (end the run).")
+	executor := flag.String("executor", "closed", "Scheduling model: closed (concurrency-bound) or arrival-rate (fixed -rate regardless of in-flight requests).")
+	maxInFlight := flag.Int("max-in-flight", 0, "With -executor arrival-rate: cap on in-flight requests; requests over the cap are dropped (0 = unlimited).")
+	output := flag.String("output", "text", "Output format: text, json (one document for the run), ndjson (a line per endpoint as it finishes), html (a self-contained page with charts), junit (JUnit XML of the threshold results), markdown (tables for PR comments) or csv (a row per endpoint).")
 	insecure := flag.Bool("insecure", false, "Skip TLS certificate verification.")
 	redirects := flag.Bool("redirects", true, "Follow HTTP redirects.")
 	failIf := flag.String("fail-if", "", "Comma-separated failure thresholds, e.g. 'p99>500ms,success<99'. Exit non-zero if any holds.")
+	abortOnFail := flag.String("abort-on-fail", "", "Conditions in -fail-if syntax that stop a run early when they hold over the -abort-window, e.g. 'errors>100,success<90'.")
+	abortWindow := flag.String("abort-window", "10s", "Width of the rolling window -abort-on-fail is evaluated over.")
+	search := flag.String("search", "", "Capacity search mode: step or binary. Reruns each endpoint at increasing rates until -slo is breached.")
+	searchMin := flag.Int("search-min", 10, "Capacity search: lowest rate to try (req/s).")
+	searchMax := flag.Int("search-max", 1000, "Capacity search: highest rate to try (req/s).")
+	searchStep := flag.Int("search-step", 10, "Capacity search: step increment, or binary search resolution (req/s).")
+	searchDuration := flag.String("search-duration", "10s", "Capacity search: how long each rate is held.")
+	sloSpec := flag.String("slo", "", "Capacity search: comma-separated failure conditions in -fail-if syntax, e.g. 'p99>300ms,success<99.9'.")
+	interval := flag.String("interval", "1s", "Width of each timeseries window in the JSON report (e.g. 1s, 500ms).")
+	seed := flag.Int64("seed", 0, "Seed for random template values ({{uuid}}, {{randInt}}, ...) and feeder order, for reproducible runs (0 = random).")
+	showProgress := flag.Bool("progress", true, "Show a live status line on stderr while a run is in flight (only when stderr is a terminal).")
+	resultsFile := flag.String("results-file", "", "JSONL file to log every request to (timestamps, latency, connection phases, status, error), for offline analysis.")
+	baseline := flag.String("baseline", "", "JSON report of a previous run (-output json) to compare each endpoint's metrics and figures with, field by field.")
+	regression := flag.String("regression", "", "Comma-separated regression conditions against -baseline, e.g. 'p99 +10%,rps -5%'. Exit non-zero if any holds.")
+	htmlReport := flag.String("html-report", "", "HTML file to also write the report to, with latency, status code and timeseries charts.")
+	junitFile := flag.String("junit-file", "", "JUnit XML file to also write the threshold and regression results to, one testsuite per endpoint.")
+	stepSummary := flag.Bool("step-summary", false, "Append the markdown report to the file $GITHUB_STEP_SUMMARY names, when it is set (GitHub Actions).")
+	csvFile := flag.String("csv-file", "", "CSV file to also write the report to, one row per endpoint with a fixed header.")
+	csvAppend := flag.Bool("csv-append", false, "With -csv-file: append rows to the file instead of replacing it; the header is written only to a new or empty file.")
+	precision := flag.Int("precision", 3, "Significant digits kept by latency histograms (1-5); higher is more exact but uses more memory.")
 
 	flag.Parse()
 
-	if *output != "text" && *output != "json" {
-		fmt.Fprintf(os.Stderr, "invalid -output %q (expected text or json)\n", *output)
+	switch *output {
+	case "text", "json", "ndjson", "html", "junit", "markdown", "csv":
+	default:
+		fmt.Fprintf(os.Stderr, "invalid -output %q (expected text, json, ndjson, html, junit, markdown or csv)\n", *output)
+		os.Exit(1)
+	}
+	if *csvAppend && *csvFile == "" {
+		fmt.Fprintln(os.Stderr, "invalid -csv-append: requires -csv-file")
+		os.Exit(1)
+	}
+
+	if *precision < 1 || *precision > 5 {
+		fmt.Fprintf(os.Stderr, "invalid -precision %d (expected 1-5)\n", *precision)
+		os.Exit(1)
+	}
+
+	intervalDur, err := parseDuration(*interval)
+	if err != nil || intervalDur <= 0 {
+		fmt.Fprintf(os.Stderr, "invalid -interval %q (expected a positive duration)\n", *interval)
 		os.Exit(1)
 	}
 
@@ -103,12 +362,53 @@ func DefineFlags() *Config {
 		fmt.Fprintf(os.Stderr, "invalid -fail-if: %s\n", err)
 		os.Exit(1)
 	}
+	abortConds, err := threshold.Parse(*abortOnFail)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -abort-on-fail: %s\n", err)
+		os.Exit(1)
+	}
+	regressions, err := threshold.ParseRegressions(*regression)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -regression: %s\n", err)
+		os.Exit(1)
+	}
+	if len(regressions) > 0 && *baseline == "" {
+		fmt.Fprintln(os.Stderr, "invalid -regression: requires -baseline")
+		os.Exit(1)
+	}
+	abortWindowDur, err := parseDuration(*abortWindow)
+	if err != nil || abortWindowDur <= 0 {
+		fmt.Fprintf(os.Stderr, "invalid -abort-window %q (expected a positive duration)\n", *abortWindow)
+		os.Exit(1)
+	}
+
+	var searchCfg *Search
+	if *search != "" {
+		searchCfg, err = parseSearch(*search, *searchMin, *searchMax, *searchStep, *searchDuration, *sloSpec)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid capacity search: %s\n", err)
+			os.Exit(1)
+		}
+		if (*output != "text" && *output != "json") || *htmlReport != "" || *junitFile != "" || *csvFile != "" || *stepSummary {
+			fmt.Fprintln(os.Stderr, "invalid capacity search: only -output text or json is supported")
+			os.Exit(1)
+		}
+	}
 
 	var endpoints []Endpoint
+	var mix *Endpoint
+	var parallel bool
 
 	if *configFile != "" {
 		config := loadConfigFromFile(*configFile)
 		endpoints = config.Endpoints
+		mix = config.Mix
+		parallel = config.Parallel
+	}
+	// Every probe of a capacity search has to have the server to itself.
+	if parallel && searchCfg != nil {
+		fmt.Fprintln(os.Stderr, "invalid capacity search: not supported with parallel: true")
+		os.Exit(1)
 	}
 
 	// If the configuration file is not specified, we use flags.
@@ -136,6 +436,11 @@ func DefineFlags() *Config {
 			fmt.Fprintf(os.Stderr, "invalid -duration: %s\n", err)
 			os.Exit(1)
 		}
+		warmupCfg, err := parseWarmup(*warmup)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -warmup: %s\n", err)
+			os.Exit(1)
+		}
 		endpoints = append(endpoints, Endpoint{
 			URL:         *url,
 			Verbose:     *verbose,
@@ -147,26 +452,93 @@ func DefineFlags() *Config {
 			Timeout:     Duration(timeoutDur),
 			Duration:    Duration(loadDur),
 			Rate:        *rate,
+			Executor:    *executor,
+			MaxInFlight: *maxInFlight,
+			Warmup:      warmupCfg,
+			Feeder:      Feeder{File: *feederFile, Strategy: *feederStrategy, OnExhausted: *feederExhausted},
 		})
 	}
 
 	// Validate every endpoint (defaults already applied) so bad values fail fast
 	// with a clear message instead of a silent no-op or a deadlock.
 	for i, ep := range endpoints {
+		// A capacity search sets the rate of every run itself.
+		if searchCfg != nil && ep.Rate == 0 {
+			ep.Rate = searchCfg.Min
+		}
 		if err := validateEndpoint(ep); err != nil {
-			fmt.Fprintf(os.Stderr, "invalid endpoint %d (%s): %s\n", i+1, ep.URL, err)
+			fmt.Fprintf(os.Stderr, "invalid endpoint %d (%s): %s\n", i+1, ep.Label(), err)
+			os.Exit(1)
+		}
+	}
+	if mix != nil {
+		m := *mix
+		if searchCfg != nil && m.Rate == 0 {
+			m.Rate = searchCfg.Min
+		}
+		if err := validateMix(m, endpoints); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid mix: %s\n", err)
 			os.Exit(1)
 		}
 	}
 
 	return &Config{
 		ShowVersion: *showVersion,
+		ConfigPath:  *configFile,
 		Output:      *output,
 		Insecure:    *insecure,
 		Redirects:   *redirects,
 		Thresholds:  thresholds,
+		AbortOnFail: abortConds,
+		AbortWindow: abortWindowDur,
+		Search:      searchCfg,
+		Precision:   *precision,
+		Interval:    intervalDur,
+		Progress:    *showProgress,
+		ResultsFile: *resultsFile,
+		Baseline:    *baseline,
+		HTMLReport:  *htmlReport,
+		JUnitFile:   *junitFile,
+		StepSummary: *stepSummary,
+		CSVFile:     *csvFile,
+		CSVAppend:   *csvAppend,
+		Regressions: regressions,
+		Seed:        *seed,
 		Endpoints:   endpoints,
+		Mix:         mix,
+		Parallel:    parallel,
+	}
+}
+
+// parseSearch builds and validates the capacity search settings from flags.
+func parseSearch(mode string, lo, hi, step int, stepDuration, sloSpec string) (*Search, error) {
+	if mode != "step" && mode != "binary" {
+		return nil, fmt.Errorf("-search must be step or binary, got %q", mode)
 	}
+	if lo < 1 {
+		return nil, fmt.Errorf("-search-min must be >= 1, got %d", lo)
+	}
+	if hi < lo {
+		return nil, fmt.Errorf("-search-max (%d) must be >= -search-min (%d)", hi, lo)
+	}
+	if step < 1 {
+		return nil, fmt.Errorf("-search-step must be >= 1, got %d", step)
+	}
+	dur, err := parseDuration(stepDuration)
+	if err != nil {
+		return nil, fmt.Errorf("-search-duration: %w", err)
+	}
+	if dur <= 0 {
+		return nil, fmt.Errorf("-search-duration must be > 0")
+	}
+	slo, err := threshold.Parse(sloSpec)
+	if err != nil {
+		return nil, fmt.Errorf("-slo: %w", err)
+	}
+	if len(slo) == 0 {
+		return nil, fmt.Errorf("-slo is required with -search")
+	}
+	return &Search{Mode: mode, Min: lo, Max: hi, Step: step, StepDuration: dur, SLO: slo}, nil
 }
 
 // validateEndpoint checks an endpoint's numeric fields after defaults have been
@@ -196,9 +568,151 @@ func validateEndpoint(e Endpoint) error {
 	if time.Duration(e.Timeout) < 0 {
 		return fmt.Errorf("timeout must be >= 0, got %s", time.Duration(e.Timeout))
 	}
+	switch e.Executor {
+	case "closed":
+	case "arrival-rate":
+		// The open model is defined by its schedule: without a rate there is
+		// nothing to keep to.
+		if e.Rate < 1 && !stagesHaveRate(e.Stages) {
+			return fmt.Errorf("executor arrival-rate requires rate >= 1, got %d", e.Rate)
+		}
+	default:
+		return fmt.Errorf("executor must be closed or arrival-rate, got %q", e.Executor)
+	}
+	if e.MaxInFlight < 0 {
+		return fmt.Errorf("maxInFlight must be >= 0, got %d", e.MaxInFlight)
+	}
+	if _, err := templating.New(1).Compile(e.URL, e.Headers, e.Data); err != nil {
+		return fmt.Errorf("template: %w", err)
+	}
+	if err := validateChecks(e.Checks); err != nil {
+		return err
+	}
+	for i, st := range e.Steps {
+		if err := validateStep(st); err != nil {
+			return fmt.Errorf("step %d (%s): %w", i+1, st.Name, err)
+		}
+	}
+	if e.Feeder.File != "" {
+		switch e.Feeder.Strategy {
+		case "sequential", "random", "unique":
+		default:
+			return fmt.Errorf("feeder strategy must be sequential, random or unique, got %q", e.Feeder.Strategy)
+		}
+		if e.Feeder.OnExhausted != "wrap" && e.Feeder.OnExhausted != "stop" {
+			return fmt.Errorf("feeder onExhausted must be wrap or stop, got %q", e.Feeder.OnExhausted)
+		}
+	}
+	if time.Duration(e.AbortWindow) < 0 {
+		return fmt.Errorf("abortWindow must be >= 0, got %s", time.Duration(e.AbortWindow))
+	}
+	if e.Weight < 0 {
+		return fmt.Errorf("weight must be >= 0, got %d", e.Weight)
+	}
+	if e.Warmup.Count < 0 {
+		return fmt.Errorf("warmup count must be >= 0, got %d", e.Warmup.Count)
+	}
+	if e.Warmup.Duration < 0 {
+		return fmt.Errorf("warmup duration must be >= 0, got %s", e.Warmup.Duration)
+	}
+	for i, st := range e.Stages {
+		if time.Duration(st.Duration) <= 0 {
+			return fmt.Errorf("stage %d: duration must be > 0, got %s", i+1, time.Duration(st.Duration))
+		}
+		if st.Rate < 0 {
+			return fmt.Errorf("stage %d: rate must be >= 0, got %d", i+1, st.Rate)
+		}
+		if st.Concurrency < 0 {
+			return fmt.Errorf("stage %d: concurrency must be >= 0, got %d", i+1, st.Concurrency)
+		}
+	}
 	return nil
 }
 
+// validateMix checks the load settings of a traffic mix, which may not set
+// anything about the requests themselves, and that it has endpoints to mix.
+func validateMix(m Endpoint, endpoints []Endpoint) error {
+	switch {
+	case m.URL != "" || len(m.Headers) > 0 || m.Data != nil:
+		return fmt.Errorf("url, headers and data belong to the endpoints")
+	case len(m.Steps) > 0:
+		return fmt.Errorf("steps belong to scenarios")
+	case m.Feeder.File != "" || len(m.Checks) > 0:
+		return fmt.Errorf("feeder and checks belong to the endpoints")
+	}
+	mixed := 0
+	for _, ep := range endpoints {
+		if len(ep.Steps) == 0 {
+			mixed++
+		}
+	}
+	if mixed == 0 {
+		return fmt.Errorf("no endpoints to mix")
+	}
+	return validateEndpoint(m)
+}
+
+// validateStep checks a scenario step after defaults have been applied.
+func validateStep(st Step) error {
+	if st.URL == "" {
+		return fmt.Errorf("url must not be empty")
+	}
+	if st.Method == "" {
+		return fmt.Errorf("method must not be empty")
+	}
+	if _, err := templating.New(1).Compile(st.URL, st.Headers, st.Data); err != nil {
+		return fmt.Errorf("template: %w", err)
+	}
+	for name, x := range st.Extract {
+		source, expr, err := x.Source()
+		if err != nil {
+			return fmt.Errorf("extract %s: %w", name, err)
+		}
+		if _, err := extract.New(name, source, expr); err != nil {
+			return fmt.Errorf("extract %w", err)
+		}
+	}
+	return validateChecks(st.Checks)
+}
+
+// validateChecks compiles each check to catch bad combinations and
+// expressions.
+func validateChecks(checks []Check) error {
+	for i, c := range checks {
+		if _, err := check.New(c.Spec()); err != nil {
+			return fmt.Errorf("check %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// stagesHaveRate reports whether any stage sets a target rate.
+func stagesHaveRate(stages []Stage) bool {
+	for _, st := range stages {
+		if st.Rate > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// parseWarmup parses a warm-up given as a request count ("100") or a
+// duration ("10s"); an empty string yields no warm-up.
+func parseWarmup(s string) (Warmup, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Warmup{}, nil
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		return Warmup{Count: n}, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return Warmup{}, fmt.Errorf("invalid warmup %q: expected a request count or a duration", s)
+	}
+	return Warmup{Duration: d}, nil
+}
+
 // parseDuration parses a duration string; an empty string yields 0 (disabled).
 func parseDuration(s string) (time.Duration, error) {
 	if s == "" {
@@ -221,32 +735,90 @@ func loadConfigFromFile(filePath string) *Config {
 		os.Exit(1)
 	}
 
-	if len(configFile.Endpoints) == 0 {
-		fmt.Println("No endpoints found in the configuration file.")
+	if len(configFile.Endpoints) == 0 && len(configFile.Scenarios) == 0 {
+		fmt.Println("No endpoints or scenarios found in the configuration file.")
 		os.Exit(1)
 	}
+	for i, ep := range configFile.Endpoints {
+		if len(ep.Steps) > 0 {
+			fmt.Printf("Endpoint %d (%s) has steps: multi-step journeys go under scenarios.\n", i+1, ep.URL)
+			os.Exit(1)
+		}
+	}
 
-	// Apply default values for omitted fields.
-	for i := range configFile.Endpoints {
-		if configFile.Endpoints[i].Method == "" {
-			configFile.Endpoints[i].Method = "GET"
+	// Scenarios run after the endpoints and share their defaults.
+	for i, sc := range configFile.Scenarios {
+		if len(sc.Steps) == 0 {
+			fmt.Printf("Scenario %d has no steps.\n", i+1)
+			os.Exit(1)
 		}
-		if configFile.Endpoints[i].Count == 0 {
-			configFile.Endpoints[i].Count = 1
+		if sc.URL != "" {
+			fmt.Printf("Scenario %d sets url: a scenario's requests are its steps.\n", i+1)
+			os.Exit(1)
 		}
-		if configFile.Endpoints[i].Concurrency == 0 {
-			configFile.Endpoints[i].Concurrency = 10
+		if sc.Name == "" {
+			sc.Name = fmt.Sprintf("scenario %d", i+1)
 		}
-		if configFile.Endpoints[i].Timeout == 0 {
-			configFile.Endpoints[i].Timeout = Duration(defaultTimeout)
+		for j := range sc.Steps {
+			st := &sc.Steps[j]
+			if st.Method == "" {
+				st.Method = "GET"
+			}
+			if st.Name == "" {
+				st.Name = st.Method + " " + st.URL
+			}
+			st.Data = normalizeYAML(st.Data)
+		}
+		configFile.Endpoints = append(configFile.Endpoints, sc)
+	}
+
+	// Apply default values for omitted fields.
+	for i := range configFile.Endpoints {
+		applyDefaults(&configFile.Endpoints[i])
+	}
+	if configFile.Mix != nil {
+		applyDefaults(configFile.Mix)
+		if configFile.Mix.Name == "" {
+			configFile.Mix.Name = "mix"
 		}
-		configFile.Endpoints[i].Data = normalizeYAML(configFile.Endpoints[i].Data)
 	}
 
 	return &Config{
 		ShowVersion: false, // No version flag in file
 		Endpoints:   configFile.Endpoints,
+		Mix:         configFile.Mix,
+		Parallel:    configFile.Parallel,
+	}
+}
+
+// applyDefaults fills in the omitted fields of an endpoint loaded from a
+// configuration file.
+func applyDefaults(e *Endpoint) {
+	if e.Method == "" {
+		e.Method = "GET"
+	}
+	if e.Count == 0 {
+		e.Count = 1
+	}
+	if e.Concurrency == 0 {
+		e.Concurrency = 10
+	}
+	if e.Timeout == 0 {
+		e.Timeout = Duration(defaultTimeout)
+	}
+	if e.Executor == "" {
+		e.Executor = "closed"
+	}
+	if e.Feeder.Strategy == "" {
+		e.Feeder.Strategy = "sequential"
+	}
+	if e.Feeder.OnExhausted == "" {
+		e.Feeder.OnExhausted = "wrap"
+	}
+	if e.Weight == 0 {
+		e.Weight = 1
 	}
+	e.Data = normalizeYAML(e.Data)
 }
 
 // parseHeadersFromCLI parses headers from a string and returns them as a map.
@@ -321,7 +893,7 @@ func ParseFlags(metadata Metadata) *Config {
 		os.Exit(0)
 	}
 
-	if len(config.Endpoints) == 0 || config.Endpoints[0].URL == "" {
+	if len(config.Endpoints) == 0 || (config.Endpoints[0].URL == "" && len(config.Endpoints[0].Steps) == 0) {
 		flag.Usage()
 		os.Exit(1)
 	}
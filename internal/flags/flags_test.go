@@ -3,8 +3,11 @@ package flags
 import (
 	"encoding/json"
 	"os"
+	"reflect"
 	"testing"
 	"time"
+
+	"gopkg.in/yaml.v2"
 )
 
 // Test for loading configuration from file
@@ -80,6 +83,12 @@ endpoints:
 	if time.Duration(ep.Timeout) != 5*time.Second {
 		t.Errorf("Expected default Timeout 5s, got %s", time.Duration(ep.Timeout))
 	}
+	if ep.Executor != "closed" {
+		t.Errorf("Expected default Executor 'closed', got '%s'", ep.Executor)
+	}
+	if ep.Feeder.Strategy != "sequential" || ep.Feeder.OnExhausted != "wrap" {
+		t.Errorf("Expected default feeder sequential/wrap, got %s/%s", ep.Feeder.Strategy, ep.Feeder.OnExhausted)
+	}
 }
 
 // Test that timeout/duration are parsed from YAML duration strings
@@ -116,10 +125,380 @@ endpoints:
 	}
 }
 
+// Test that warmup is parsed from YAML as a count or a duration
+func TestLoadConfigFromFile_ParsesWarmup(t *testing.T) {
+	yamlWithWarmup := `
+endpoints:
+  - url: "http://example.com/a"
+    warmup: 100
+  - url: "http://example.com/b"
+    warmup: "10s"
+  - url: "http://example.com/c"
+`
+	tmpFile, err := os.CreateTemp("", "config.yaml")
+	if err != nil {
+		t.Fatalf("Error creating temporary file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err = tmpFile.Write([]byte(yamlWithWarmup)); err != nil {
+		t.Fatalf("Error writing to temporary file: %v", err)
+	}
+	tmpFile.Close()
+
+	config := loadConfigFromFile(tmpFile.Name())
+
+	if w := config.Endpoints[0].Warmup; w != (Warmup{Count: 100}) {
+		t.Errorf("Expected a warmup of 100 requests, got %+v", w)
+	}
+	if w := config.Endpoints[1].Warmup; w != (Warmup{Duration: 10 * time.Second}) {
+		t.Errorf("Expected a warmup of 10s, got %+v", w)
+	}
+	if w := config.Endpoints[2].Warmup; w != (Warmup{}) {
+		t.Errorf("Expected no warmup, got %+v", w)
+	}
+}
+
+func TestParseWarmup(t *testing.T) {
+	cases := []struct {
+		in      string
+		want    Warmup
+		wantErr bool
+	}{
+		{"", Warmup{}, false},
+		{"50", Warmup{Count: 50}, false},
+		{"1m30s", Warmup{Duration: 90 * time.Second}, false},
+		{"soon", Warmup{}, true},
+	}
+	for _, tc := range cases {
+		got, err := parseWarmup(tc.in)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("parseWarmup(%q): unexpected error state: %v", tc.in, err)
+		}
+		if got != tc.want {
+			t.Errorf("parseWarmup(%q) = %+v, want %+v", tc.in, got, tc.want)
+		}
+	}
+}
+
+// Test that a stages list is parsed from YAML
+func TestLoadConfigFromFile_ParsesStages(t *testing.T) {
+	yamlWithStages := `
+endpoints:
+  - url: "http://example.com"
+    stages:
+      - duration: "1m"
+        rate: 200
+      - duration: "5m"
+        rate: 200
+        concurrency: 50
+      - duration: "30s"
+        rate: 0
+`
+	tmpFile, err := os.CreateTemp("", "config.yaml")
+	if err != nil {
+		t.Fatalf("Error creating temporary file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err = tmpFile.Write([]byte(yamlWithStages)); err != nil {
+		t.Fatalf("Error writing to temporary file: %v", err)
+	}
+	tmpFile.Close()
+
+	ep := loadConfigFromFile(tmpFile.Name()).Endpoints[0]
+	if len(ep.Stages) != 3 {
+		t.Fatalf("Expected 3 stages, got %d", len(ep.Stages))
+	}
+	if time.Duration(ep.Stages[0].Duration) != time.Minute || ep.Stages[0].Rate != 200 {
+		t.Errorf("Expected first stage 1m to 200 rps, got %+v", ep.Stages[0])
+	}
+	if ep.Stages[1].Concurrency != 50 {
+		t.Errorf("Expected second stage concurrency 50, got %d", ep.Stages[1].Concurrency)
+	}
+	if ep.PeakConcurrency() != 50 {
+		t.Errorf("Expected peak concurrency 50, got %d", ep.PeakConcurrency())
+	}
+}
+
+// Test that scenarios are loaded after the endpoints, with defaults applied
+func TestLoadConfigFromFile_ParsesScenarios(t *testing.T) {
+	yamlWithScenarios := `
+endpoints:
+  - url: "http://example.com/health"
+scenarios:
+  - name: "checkout"
+    concurrency: 5
+    steps:
+      - name: "log in"
+        method: "POST"
+        url: "http://example.com/login"
+        data:
+          user: "alice"
+        extract:
+          token:
+            jsonpath: "$.token"
+      - url: "http://example.com/orders"
+        headers:
+          Authorization: "Bearer {{.token}}"
+  - steps:
+      - url: "http://example.com/"
+`
+	tmpFile, err := os.CreateTemp("", "config.yaml")
+	if err != nil {
+		t.Fatalf("Error creating temporary file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err = tmpFile.Write([]byte(yamlWithScenarios)); err != nil {
+		t.Fatalf("Error writing to temporary file: %v", err)
+	}
+	tmpFile.Close()
+
+	eps := loadConfigFromFile(tmpFile.Name()).Endpoints
+	if len(eps) != 3 || eps[0].URL != "http://example.com/health" {
+		t.Fatalf("Expected the endpoint followed by 2 scenarios, got %+v", eps)
+	}
+	sc := eps[1]
+	if sc.Name != "checkout" || sc.Concurrency != 5 || sc.Count != 1 || len(sc.Steps) != 2 {
+		t.Errorf("Expected scenario checkout with defaults applied, got %+v", sc)
+	}
+	if x := sc.Steps[0].Extract["token"]; x.JSONPath != "$.token" {
+		t.Errorf("Expected token extracted with $.token, got %+v", x)
+	}
+	if _, err := json.Marshal(sc.Steps[0].Data); err != nil {
+		t.Errorf("Expected step data to be JSON-encodable, got %v", err)
+	}
+	if st := sc.Steps[1]; st.Method != "GET" || st.Name != "GET http://example.com/orders" {
+		t.Errorf("Expected the step method and name to default, got %q and %q", st.Method, st.Name)
+	}
+	if eps[2].Name != "scenario 2" {
+		t.Errorf("Expected the unnamed scenario to be called scenario 2, got %q", eps[2].Name)
+	}
+}
+
+// Test that checks are parsed from YAML, with numeric values kept as text
+func TestLoadConfigFromFile_ParsesChecks(t *testing.T) {
+	yamlWithChecks := `
+endpoints:
+  - url: "http://example.com"
+    checks:
+      - status: [200, 201]
+      - name: "three items"
+        jsonpath: "$.count"
+        equals: 3
+      - header: "Content-Type"
+        matches: "^application/json"
+      - maxBodySize: 1048576
+`
+	tmpFile, err := os.CreateTemp("", "config.yaml")
+	if err != nil {
+		t.Fatalf("Error creating temporary file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err = tmpFile.Write([]byte(yamlWithChecks)); err != nil {
+		t.Fatalf("Error writing to temporary file: %v", err)
+	}
+	tmpFile.Close()
+
+	checks := loadConfigFromFile(tmpFile.Name()).Endpoints[0].Checks
+	if len(checks) != 4 {
+		t.Fatalf("Expected 4 checks, got %d", len(checks))
+	}
+	if len(checks[0].Status) != 2 || checks[0].Status[1] != 201 {
+		t.Errorf("Expected status [200 201], got %v", checks[0].Status)
+	}
+	if checks[1].Name != "three items" || checks[1].Equals != "3" {
+		t.Errorf("Expected check %q to equal \"3\", got %+v", "three items", checks[1])
+	}
+	if checks[3].MaxBodySize != 1048576 {
+		t.Errorf("Expected maxBodySize 1048576, got %d", checks[3].MaxBodySize)
+	}
+}
+
+func TestLoadConfigFromFile_ParsesMix(t *testing.T) {
+	yamlWithMix := `
+mix:
+  rate: 200
+  duration: 1m
+  executor: arrival-rate
+endpoints:
+  - url: "http://example.com/items"
+    weight: 7
+  - url: "http://example.com/orders"
+    method: POST
+`
+	tmpFile, err := os.CreateTemp("", "config.yaml")
+	if err != nil {
+		t.Fatalf("Error creating temporary file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err = tmpFile.Write([]byte(yamlWithMix)); err != nil {
+		t.Fatalf("Error writing to temporary file: %v", err)
+	}
+	tmpFile.Close()
+
+	config := loadConfigFromFile(tmpFile.Name())
+	mix := config.Mix
+	if mix == nil {
+		t.Fatalf("Expected a mix")
+	}
+	if mix.Name != "mix" || mix.Rate != 200 || time.Duration(mix.Duration) != time.Minute || mix.Concurrency != 10 {
+		t.Errorf("Expected mix {mix, 200 req/s, 1m, concurrency 10}, got %+v", *mix)
+	}
+	if config.Endpoints[0].Weight != 7 || config.Endpoints[1].Weight != 1 {
+		t.Errorf("Expected weights 7 and 1 (default), got %d and %d", config.Endpoints[0].Weight, config.Endpoints[1].Weight)
+	}
+	if err := validateMix(*mix, config.Endpoints); err != nil {
+		t.Errorf("Expected a valid mix, got %v", err)
+	}
+}
+
+func TestLoadConfigFromFile_ParsesParallel(t *testing.T) {
+	yamlWithParallel := `
+parallel: true
+endpoints:
+  - url: "http://example.com/items"
+    rate: 50
+    duration: 10m
+  - url: "http://example.com/orders"
+    method: POST
+    count: 1000
+    concurrency: 100
+`
+	tmpFile, err := os.CreateTemp("", "config.yaml")
+	if err != nil {
+		t.Fatalf("Error creating temporary file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err = tmpFile.Write([]byte(yamlWithParallel)); err != nil {
+		t.Fatalf("Error writing to temporary file: %v", err)
+	}
+	tmpFile.Close()
+
+	config := loadConfigFromFile(tmpFile.Name())
+	if !config.Parallel {
+		t.Errorf("Expected parallel to be set")
+	}
+	if config.Endpoints[0].Rate != 50 || config.Endpoints[1].Concurrency != 100 {
+		t.Errorf("Expected each endpoint to keep its own load settings, got %+v", config.Endpoints)
+	}
+}
+
+func TestLoadConfigFromFile_ParsesThresholds(t *testing.T) {
+	yamlWithThresholds := `
+endpoints:
+  - url: "http://example.com/health"
+    thresholds: "p99>50ms,errors>0"
+  - url: "http://example.com/search"
+    thresholds:
+      - "p99>2s"
+      - "success<99.5"
+`
+	tmpFile, err := os.CreateTemp("", "config.yaml")
+	if err != nil {
+		t.Fatalf("Error creating temporary file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err = tmpFile.Write([]byte(yamlWithThresholds)); err != nil {
+		t.Fatalf("Error writing to temporary file: %v", err)
+	}
+	tmpFile.Close()
+
+	config := loadConfigFromFile(tmpFile.Name())
+	health, search := config.Endpoints[0].Thresholds, config.Endpoints[1].Thresholds
+	if len(health) != 2 || health[0].Metric != "p99" || health[0].Value != 0.05 || health[1].Raw != "errors>0" {
+		t.Errorf("Expected thresholds [p99>50ms errors>0], got %+v", health)
+	}
+	if len(search) != 2 || search[0].Value != 2 || search[1].Metric != "success" {
+		t.Errorf("Expected thresholds [p99>2s success<99.5], got %+v", search)
+	}
+}
+
+func TestLoadConfigFromFile_ParsesAbortOnFail(t *testing.T) {
+	yamlWithAbort := `
+endpoints:
+  - url: "http://example.com/soak"
+    duration: 30m
+    abortOnFail: "errors>100,success<90"
+    abortWindow: 30s
+`
+	tmpFile, err := os.CreateTemp("", "config.yaml")
+	if err != nil {
+		t.Fatalf("Error creating temporary file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err = tmpFile.Write([]byte(yamlWithAbort)); err != nil {
+		t.Fatalf("Error writing to temporary file: %v", err)
+	}
+	tmpFile.Close()
+
+	ep := loadConfigFromFile(tmpFile.Name()).Endpoints[0]
+	if len(ep.AbortOnFail) != 2 || ep.AbortOnFail[0].Raw != "errors>100" || ep.AbortOnFail[1].Metric != "success" {
+		t.Errorf("Expected abortOnFail [errors>100 success<90], got %+v", ep.AbortOnFail)
+	}
+	if time.Duration(ep.AbortWindow) != 30*time.Second {
+		t.Errorf("Expected abortWindow 30s, got %s", time.Duration(ep.AbortWindow))
+	}
+}
+
+func TestThresholds_UnmarshalYAMLInvalid(t *testing.T) {
+	var ep Endpoint
+	for _, doc := range []string{`thresholds: "p99>>1"`, `thresholds: ["latency>1s"]`} {
+		if err := yaml.Unmarshal([]byte(doc), &ep); err == nil {
+			t.Errorf("expected an error for %s", doc)
+		}
+	}
+}
+
+// Test that validateMix keeps request settings out of the mix and needs an
+// endpoint to send requests to.
+func TestValidateMix(t *testing.T) {
+	mix := Endpoint{Name: "mix", Method: "GET", Count: 100, Concurrency: 10, Executor: "closed"}
+	endpoints := []Endpoint{{URL: "http://x", Method: "GET", Weight: 1}}
+	scenario := Endpoint{Method: "GET", Steps: []Step{{URL: "http://x", Method: "GET"}}}
+
+	cases := []struct {
+		name      string
+		mutate    func(m *Endpoint)
+		endpoints []Endpoint
+		wantErr   bool
+	}{
+		{"valid", func(*Endpoint) {}, endpoints, false},
+		{"scenarios run separately", func(*Endpoint) {}, append([]Endpoint{scenario}, endpoints...), false},
+		{"only scenarios", func(*Endpoint) {}, []Endpoint{scenario}, true},
+		{"url", func(m *Endpoint) { m.URL = "http://x" }, endpoints, true},
+		{"headers", func(m *Endpoint) { m.Headers = map[string]string{"X": "y"} }, endpoints, true},
+		{"steps", func(m *Endpoint) { m.Steps = scenario.Steps }, endpoints, true},
+		{"feeder", func(m *Endpoint) { m.Feeder = Feeder{File: "u.csv"} }, endpoints, true},
+		{"checks", func(m *Endpoint) { m.Checks = []Check{{Status: []int{200}}} }, endpoints, true},
+		{"bad load settings", func(m *Endpoint) { m.Concurrency = 0 }, endpoints, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := mix
+			tc.mutate(&m)
+			err := validateMix(m, tc.endpoints)
+			if tc.wantErr && err == nil {
+				t.Errorf("expected error, got nil")
+			}
+			if !tc.wantErr && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
 // Test that validateEndpoint rejects values that would fail silently or hang,
 // and accepts valid configurations.
 func TestValidateEndpoint(t *testing.T) {
-	valid := Endpoint{URL: "http://x", Method: "GET", Count: 1, Concurrency: 10}
+	valid := Endpoint{URL: "http://x", Method: "GET", Count: 1, Concurrency: 10, Executor: "closed"}
 
 	cases := []struct {
 		name    string
@@ -136,6 +515,56 @@ func TestValidateEndpoint(t *testing.T) {
 		{"negative rate", func(e *Endpoint) { e.Rate = -1 }, true},
 		{"negative duration", func(e *Endpoint) { e.Duration = Duration(-time.Second) }, true},
 		{"negative timeout", func(e *Endpoint) { e.Timeout = Duration(-time.Second) }, true},
+		{"unknown executor", func(e *Endpoint) { e.Executor = "open" }, true},
+		{"arrival-rate without rate", func(e *Endpoint) { e.Executor = "arrival-rate" }, true},
+		{"arrival-rate with rate", func(e *Endpoint) { e.Executor = "arrival-rate"; e.Rate = 100 }, false},
+		{"negative max in flight", func(e *Endpoint) { e.MaxInFlight = -1 }, true},
+		{"arrival-rate with staged rate", func(e *Endpoint) {
+			e.Executor = "arrival-rate"
+			e.Stages = []Stage{{Duration: Duration(time.Second), Rate: 10}}
+		}, false},
+		{"zero stage duration", func(e *Endpoint) { e.Stages = []Stage{{Rate: 10}} }, true},
+		{"negative stage rate", func(e *Endpoint) { e.Stages = []Stage{{Duration: Duration(time.Second), Rate: -1}} }, true},
+		{"bad url template", func(e *Endpoint) { e.URL = "http://x/{{nope}}" }, true},
+		{"bad data template", func(e *Endpoint) { e.Data = map[string]interface{}{"id": "{{randInt 1}}"} }, true},
+		{"valid templates", func(e *Endpoint) {
+			e.URL = "http://x/{{seq}}"
+			e.Headers = map[string]string{"X-Request-Id": "{{uuid}}"}
+		}, false},
+		{"feeder", func(e *Endpoint) { e.Feeder = Feeder{File: "u.csv", Strategy: "unique", OnExhausted: "stop"} }, false},
+		{"unknown feeder strategy", func(e *Endpoint) { e.Feeder = Feeder{File: "u.csv", Strategy: "shuffle", OnExhausted: "wrap"} }, true},
+		{"unknown feeder exhaustion", func(e *Endpoint) { e.Feeder = Feeder{File: "u.csv", Strategy: "random", OnExhausted: "loop"} }, true},
+		{"negative weight", func(e *Endpoint) { e.Weight = -1 }, true},
+		{"negative abort window", func(e *Endpoint) { e.AbortWindow = Duration(-time.Second) }, true},
+		{"negative warmup count", func(e *Endpoint) { e.Warmup.Count = -1 }, true},
+		{"negative warmup duration", func(e *Endpoint) { e.Warmup.Duration = -time.Second }, true},
+		{"scenario", func(e *Endpoint) {
+			e.URL = ""
+			e.Steps = []Step{
+				{URL: "http://x/login", Method: "POST", Extract: map[string]Extract{"token": {JSONPath: "$.token"}}},
+				{URL: "http://x/orders", Method: "GET", Headers: map[string]string{"Authorization": "Bearer {{.token}}"}},
+			}
+		}, false},
+		{"step without url", func(e *Endpoint) { e.Steps = []Step{{Method: "GET"}} }, true},
+		{"bad step template", func(e *Endpoint) { e.Steps = []Step{{URL: "http://x/{{nope}}", Method: "GET"}} }, true},
+		{"extract without source", func(e *Endpoint) {
+			e.Steps = []Step{{URL: "http://x", Method: "GET", Extract: map[string]Extract{"token": {}}}}
+		}, true},
+		{"extract with two sources", func(e *Endpoint) {
+			e.Steps = []Step{{URL: "http://x", Method: "GET", Extract: map[string]Extract{"token": {Header: "X-Token", Cookie: "token"}}}}
+		}, true},
+		{"checks", func(e *Endpoint) {
+			e.Checks = []Check{{Status: []int{200, 201}}, {JSONPath: "$.state", Equals: "active"}, {MaxBodySize: 1024}}
+		}, false},
+		{"check without kind", func(e *Endpoint) { e.Checks = []Check{{Name: "empty"}} }, true},
+		{"check with two kinds", func(e *Endpoint) { e.Checks = []Check{{Header: "X", BodyContains: "ok"}} }, true},
+		{"bad check regex", func(e *Endpoint) { e.Checks = []Check{{BodyMatches: "(unclosed"}} }, true},
+		{"bad step check", func(e *Endpoint) {
+			e.Steps = []Step{{URL: "http://x", Method: "GET", Checks: []Check{{Equals: "x"}}}}
+		}, true},
+		{"bad extract regex", func(e *Endpoint) {
+			e.Steps = []Step{{URL: "http://x", Method: "GET", Extract: map[string]Extract{"id": {Regex: "id=(\\d+"}}}}
+		}, true},
 	}
 
 	for _, tc := range cases {
@@ -302,3 +731,65 @@ func TestNormalizeYAML(t *testing.T) {
 		t.Errorf("Expected nested roles[1].scope 'all', got %v", roles[1])
 	}
 }
+
+// Test that capacity search flags are validated and the SLO is required.
+func TestParseSearch(t *testing.T) {
+	s, err := parseSearch("binary", 10, 1000, 10, "5s", "p99>300ms,success<99.9")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if s.Mode != "binary" || s.StepDuration != 5*time.Second || len(s.SLO) != 2 {
+		t.Errorf("Unexpected search settings: %+v", s)
+	}
+
+	bad := []struct {
+		name                 string
+		mode                 string
+		lo, hi, step         int
+		stepDuration, sloStr string
+	}{
+		{"unknown mode", "linear", 10, 100, 10, "5s", "p99>300ms"},
+		{"zero min", "step", 0, 100, 10, "5s", "p99>300ms"},
+		{"max below min", "step", 100, 10, 10, "5s", "p99>300ms"},
+		{"zero step", "step", 10, 100, 0, "5s", "p99>300ms"},
+		{"zero step duration", "step", 10, 100, 10, "0s", "p99>300ms"},
+		{"missing slo", "step", 10, 100, 10, "5s", ""},
+		{"invalid slo", "step", 10, 100, 10, "5s", "p99>fast"},
+	}
+	for _, tc := range bad {
+		if _, err := parseSearch(tc.mode, tc.lo, tc.hi, tc.step, tc.stepDuration, tc.sloStr); err == nil {
+			t.Errorf("%s: expected an error, got nil", tc.name)
+		}
+	}
+}
+
+// Test that the report subcommand's flags are parsed into a filter, and that
+// invalid values are rejected.
+func TestParseReportFlags(t *testing.T) {
+	rc, err := ParseReportFlags([]string{"-output", "json", "-from", "30s", "-endpoint", "login, search", "-status", "200,5xx", "-fail-if", "p99>1s", "out.jsonl"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if rc.File != "out.jsonl" || rc.Output != "json" || len(rc.Thresholds) != 1 || rc.Interval != time.Second {
+		t.Errorf("Unexpected report settings: %+v", rc)
+	}
+	if rc.Filter.From.Offset != 30*time.Second || !rc.Filter.To.IsZero() {
+		t.Errorf("Unexpected time range: from %+v to %+v", rc.Filter.From, rc.Filter.To)
+	}
+	if !reflect.DeepEqual(rc.Filter.Endpoints, []string{"login", "search"}) || !reflect.DeepEqual(rc.Filter.Statuses, []string{"200", "5xx"}) {
+		t.Errorf("Unexpected filter: %+v", rc.Filter)
+	}
+
+	for _, args := range [][]string{
+		{"-output", "xml", "out.jsonl"},
+		{"-from", "soon", "out.jsonl"},
+		{"-status", "6xx", "out.jsonl"},
+		{"-fail-if", "p99>fast", "out.jsonl"},
+		{"-precision", "0", "out.jsonl"},
+		{"a.jsonl", "b.jsonl"},
+	} {
+		if _, err := ParseReportFlags(args); err == nil {
+			t.Errorf("%v: expected an error, got nil", args)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package flags
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/idesyatov/http-runner/internal/results"
+	"github.com/idesyatov/http-runner/internal/threshold"
+)
+
+// ReportConfig holds the options of the report subcommand, which rebuilds
+// reports from a -results-file instead of sending requests.
+type ReportConfig struct {
+	File       string                // Results log to read
+	Output     string                // Output format: "text", "json", "ndjson", "html", "junit", "markdown" or "csv".
+	Filter     results.Filter        // Records to keep
+	Thresholds []threshold.Condition // Pass/fail conditions; a violation exits non-zero.
+	Precision  int                   // Significant digits kept by latency histograms (1-5).
+	Interval   time.Duration         // Width of a timeseries window in the JSON report.
+}
+
+// ParseReportFlags parses the arguments of the report subcommand, those after
+// "report": its flags, then the results log. It exits on a malformed flag, like
+// DefineFlags, and returns an error for an invalid value.
+func ParseReportFlags(args []string) (*ReportConfig, error) {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: http-runner report [flags] results.jsonl")
+		fs.PrintDefaults()
+	}
+	output := fs.String("output", "text", "Output format: text, json (one document), ndjson (a line per endpoint), html (a self-contained page with charts), junit (JUnit XML of the -fail-if results), markdown (tables for PR comments) or csv (a row per endpoint).")
+	from := fs.String("from", "", "Keep requests started at or after this RFC 3339 time, or offset from the start of the log (e.g. 30s).")
+	to := fs.String("to", "", "Keep requests started before this RFC 3339 time, or offset from the start of the log (e.g. 5m).")
+	endpoint := fs.String("endpoint", "", "Comma-separated endpoints to keep, by name (or URL if unnamed).")
+	status := fs.String("status", "", "Comma-separated status codes or classes to keep, e.g. '200,5xx'; 0 keeps transport errors.")
+	failIf := fs.String("fail-if", "", "Comma-separated failure thresholds, e.g. 'p99>500ms,success<99'. Exit non-zero if any holds.")
+	precision := fs.Int("precision", 3, "Significant digits kept by latency histograms (1-5).")
+	interval := fs.String("interval", "1s", "Width of each timeseries window in the JSON report (e.g. 1s, 500ms).")
+	_ = fs.Parse(args) // ExitOnError: exits on a bad flag
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return nil, fmt.Errorf("expected one results file, got %d arguments", fs.NArg())
+	}
+	switch *output {
+	case "text", "json", "ndjson", "html", "junit", "markdown", "csv":
+	default:
+		return nil, fmt.Errorf("invalid -output %q (expected text, json, ndjson, html, junit, markdown or csv)", *output)
+	}
+	if *precision < 1 || *precision > 5 {
+		return nil, fmt.Errorf("invalid -precision %d (expected 1-5)", *precision)
+	}
+	intervalDur, err := parseDuration(*interval)
+	if err != nil || intervalDur <= 0 {
+		return nil, fmt.Errorf("invalid -interval %q (expected a positive duration)", *interval)
+	}
+	thresholds, err := threshold.Parse(*failIf)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -fail-if: %s", err)
+	}
+
+	var filter results.Filter
+	if filter.From, err = results.ParseBound(*from); err != nil {
+		return nil, fmt.Errorf("invalid -from: %s", err)
+	}
+	if filter.To, err = results.ParseBound(*to); err != nil {
+		return nil, fmt.Errorf("invalid -to: %s", err)
+	}
+	filter.Endpoints = splitList(*endpoint)
+	filter.Statuses = splitList(*status)
+	for _, s := range filter.Statuses {
+		if !results.ValidStatus(s) {
+			return nil, fmt.Errorf("invalid -status %q (expected a status code or a class such as 5xx)", s)
+		}
+	}
+
+	return &ReportConfig{
+		File:       fs.Arg(0),
+		Output:     *output,
+		Filter:     filter,
+		Thresholds: thresholds,
+		Precision:  *precision,
+		Interval:   intervalDur,
+	}, nil
+}
+
+// splitList splits a comma-separated list, dropping empty items.
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,49 @@
+package generator
+
+import "time"
+
+// DefaultAbortWindow is the width of the window handed to RequestConfig.Abort
+// when RequestConfig.AbortWindow is unset.
+const DefaultAbortWindow = 10 * time.Second
+
+// abortSlices is how many slices a rolling window is kept in. The window moves
+// on, and Abort is called, once per slice.
+const abortSlices = 10
+
+// rolling keeps the results of the last window of a run as a ring of equal
+// slices, so the window can be evaluated while the run is in flight without
+// keeping every result. It is not safe for concurrent use.
+type rolling struct {
+	slices []*stats
+	cur    int    // Slice results are recorded in
+	sum    *stats // Scratch space for the whole window
+}
+
+// newRolling returns an empty rolling window whose histograms keep precision
+// significant digits.
+func newRolling(precision int) *rolling {
+	r := &rolling{slices: make([]*stats, abortSlices), sum: newStats(precision)}
+	for i := range r.slices {
+		r.slices[i] = newStats(precision)
+	}
+	return r
+}
+
+// current returns the slice results are recorded in.
+func (r *rolling) current() *stats { return r.slices[r.cur] }
+
+// window returns the results of the whole window. They are only valid until
+// the next call.
+func (r *rolling) window() *stats {
+	r.sum.reset()
+	for _, s := range r.slices {
+		r.sum.merge(s)
+	}
+	return r.sum
+}
+
+// advance drops the oldest slice and starts recording in a fresh one.
+func (r *rolling) advance() {
+	r.cur = (r.cur + 1) % len(r.slices)
+	r.slices[r.cur].reset()
+}
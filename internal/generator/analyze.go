@@ -0,0 +1,116 @@
+package generator
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	"github.com/idesyatov/http-runner/internal/results"
+	"github.com/idesyatov/http-runner/pkg/httpclient"
+)
+
+// Analyzer rebuilds the report of a run from its results log (see
+// RequestConfig.Results), e.g. to look at part of a run after the fact.
+// Percentiles, the histogram and the timeseries are recomputed from the
+// records added. Records are expected in the order they were written, which
+// is roughly that in which requests finished; the log does not say how many
+// requests were in flight or dropped, nor the load settings of the run.
+type Analyzer struct {
+	name     string
+	interval time.Duration
+	total    *stats
+	window   *stats
+	series   []Interval
+
+	method, url string
+	mixedURLs   bool      // Records differ in URL, e.g. a template's
+	start, end  time.Time // Earliest start and latest end over the records
+	windowStart time.Time
+}
+
+// NewAnalyzer returns an analyzer for the report of name, whose histograms
+// keep precision significant digits (0 = DefaultPrecision) and whose
+// timeseries windows are interval wide (0 = DefaultInterval).
+func NewAnalyzer(name string, precision int, interval time.Duration) *Analyzer {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Analyzer{name: name, interval: interval, total: newStats(precision), window: newStats(precision)}
+}
+
+// Add adds a record of the log.
+func (a *Analyzer) Add(rec results.Record) {
+	start := rec.Timestamp
+	latency := fromSeconds(rec.LatencySec)
+	end := start.Add(latency)
+	if a.total.sent == 0 {
+		a.method, a.url = rec.Method, rec.URL
+		a.start, a.end, a.windowStart = start, end, start
+	}
+	a.mixedURLs = a.mixedURLs || rec.URL != a.url
+	if start.Before(a.start) {
+		a.start = start
+	}
+	if end.After(a.end) {
+		a.end = end
+	}
+
+	// A request belongs to the window it finished in; one that finished in a
+	// window already closed is counted in the current one.
+	for !end.Before(a.windowStart.Add(a.interval)) {
+		a.series = append(a.series, a.window.interval(a.windowStart.Sub(a.start), a.interval, 0))
+		a.window.reset()
+		a.windowStart = a.windowStart.Add(a.interval)
+	}
+
+	res := result{stage: -1, latency: latency, corrected: latency, status: rec.Status, bytes: rec.Bytes, checkFailures: rec.CheckFailures}
+	if intended := rec.Intended; !intended.IsZero() && intended.Before(start) {
+		res.corrected = end.Sub(intended)
+	}
+	if rec.Error != "" {
+		res.err = &recordedError{category: rec.Error, message: rec.Message}
+	} else {
+		res.trace = &httpclient.Trace{
+			DNS:     fromSeconds(rec.DNSSec),
+			Connect: fromSeconds(rec.ConnectSec),
+			TLS:     fromSeconds(rec.TLSSec),
+			TTFB:    fromSeconds(rec.TTFBSec),
+			Reused:  rec.Reused,
+		}
+		// The log keeps whether the request succeeded; the result derives it
+		// from the status, the checks and the extractions.
+		is2xx := rec.Status >= 200 && rec.Status < 300
+		res.statusExpected = rec.Success && !is2xx
+		res.extractFailed = !rec.Success && is2xx && len(rec.CheckFailures) == 0
+	}
+	for _, s := range []*stats{a.total, a.window} {
+		s.sent++
+		s.add(res)
+	}
+}
+
+// Report returns the report of the records added so far. Its duration spans
+// the earliest start to the latest end, its URL is empty if the records
+// differ in URL, and its concurrency is unknown (0).
+func (a *Analyzer) Report() GeneratorReport {
+	report := GeneratorReport{Name: a.name, Method: a.method, URL: a.url}
+	if a.mixedURLs {
+		report.URL = ""
+	}
+	if report.Name == report.URL {
+		report.Name = "" // the log names an unnamed endpoint by its URL
+	}
+	series := slices.Clip(a.series)
+	if a.window.sent > 0 && a.end.After(a.windowStart) {
+		series = append(series, a.window.interval(a.windowStart.Sub(a.start), a.end.Sub(a.windowStart), 0))
+	}
+	report.Timeseries = series
+	a.total.fill(&report, a.end.Sub(a.start))
+	return report
+}
+
+// fromSeconds converts seconds, as written to a results log, back to a
+// duration, rounding to the nanosecond it was written from.
+func fromSeconds(s float64) time.Duration {
+	return time.Duration(math.Round(s * float64(time.Second)))
+}
@@ -0,0 +1,114 @@
+package generator
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"syscall"
+)
+
+// maxErrorSamples is how many distinct messages the report keeps per error
+// category.
+const maxErrorSamples = 3
+
+// bodyError is a failure to read a response body after its status arrived.
+type bodyError struct{ err error }
+
+func (e *bodyError) Error() string { return "reading body: " + e.err.Error() }
+func (e *bodyError) Unwrap() error { return e.err }
+
+// recordedError is a transport error read back from a results log, of which
+// only the category and message are known.
+type recordedError struct{ category, message string }
+
+func (e *recordedError) Error() string { return e.message }
+
+// ErrorCategories are the categories classifyError groups transport errors
+// into, in the order it tries them, with "other" last.
+var ErrorCategories = []string{
+	"timeout", "body read", "tls certificate", "tls handshake", "dns", "connection refused", "connection reset",
+	"broken pipe", "http2 goaway", "http2 stream", "unexpected eof", "too many redirects", "other",
+}
+
+// classifyError groups a transport error into a short, human-readable category
+// for the report, one of ErrorCategories. The first that applies wins, so a
+// body read that timed out is a timeout.
+func classifyError(err error) string {
+	var recErr *recordedError
+	if errors.As(err, &recErr) {
+		return recErr.category
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return "timeout"
+	}
+	s := err.Error()
+	if strings.Contains(s, "context deadline exceeded") {
+		return "timeout"
+	}
+
+	var bodyErr *bodyError
+	var certErr *tls.CertificateVerificationError
+	var unknownAuth x509.UnknownAuthorityError
+	var hostErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	var recordErr tls.RecordHeaderError
+	var alertErr tls.AlertError
+	var dnsErr *net.DNSError
+	switch {
+	case errors.As(err, &bodyErr):
+		return "body read"
+	case errors.As(err, &certErr), errors.As(err, &unknownAuth), errors.As(err, &hostErr),
+		errors.As(err, &invalidErr), strings.Contains(s, "x509:"):
+		return "tls certificate"
+	case errors.As(err, &recordErr), errors.As(err, &alertErr), strings.Contains(s, "tls:"):
+		return "tls handshake"
+	case errors.As(err, &dnsErr), strings.Contains(s, "no such host"):
+		return "dns"
+	case errors.Is(err, syscall.ECONNREFUSED), strings.Contains(s, "connection refused"):
+		return "connection refused"
+	case errors.Is(err, syscall.ECONNRESET), strings.Contains(s, "connection reset"):
+		return "connection reset"
+	case errors.Is(err, syscall.EPIPE), strings.Contains(s, "broken pipe"):
+		return "broken pipe"
+	case strings.Contains(s, "GOAWAY"):
+		return "http2 goaway"
+	case strings.Contains(s, "stream error"):
+		return "http2 stream"
+	case errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, io.EOF):
+		return "unexpected eof"
+	case strings.Contains(s, "stopped after") && strings.Contains(s, "redirects"):
+		return "too many redirects"
+	default:
+		return "other"
+	}
+}
+
+// errorMessage returns the message of err kept as a sample in the report. The
+// URL of a failed request is left out, so that the same failure on templated
+// URLs is one sample rather than many.
+func errorMessage(err error) string {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Op + ": " + urlErr.Err.Error()
+	}
+	return err.Error()
+}
+
+// addSample appends msg to samples unless it is already there or samples is
+// full.
+func addSample(samples []string, msg string) []string {
+	if len(samples) >= maxErrorSamples {
+		return samples
+	}
+	for _, s := range samples {
+		if s == msg {
+			return samples
+		}
+	}
+	return append(samples, msg)
+}
@@ -0,0 +1,86 @@
+package generator
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"slices"
+	"syscall"
+	"testing"
+)
+
+// TestClassifyError checks the category of each kind of transport error,
+// whether wrapped the way net/http returns it or known only by its message.
+func TestClassifyError(t *testing.T) {
+	wrap := func(err error) error { return &url.Error{Op: "Get", URL: "http://example.com", Err: err} }
+	dial := func(errno syscall.Errno) error {
+		return wrap(&net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", errno)})
+	}
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{wrap(context.DeadlineExceeded), "timeout"},
+		{wrap(&net.DNSError{Err: "no such host", Name: "nowhere.invalid", IsNotFound: true}), "dns"},
+		{dial(syscall.ECONNREFUSED), "connection refused"},
+		{dial(syscall.ECONNRESET), "connection reset"},
+		{wrap(errors.New("read tcp 127.0.0.1:1->127.0.0.1:2: read: connection reset by peer")), "connection reset"},
+		{dial(syscall.EPIPE), "broken pipe"},
+		{wrap(io.EOF), "unexpected eof"},
+		{wrap(io.ErrUnexpectedEOF), "unexpected eof"},
+		{&bodyError{io.ErrUnexpectedEOF}, "body read"},
+		{&bodyError{errors.New("http2: response body closed")}, "body read"},
+		{wrap(x509.UnknownAuthorityError{}), "tls certificate"},
+		{wrap(errors.New("tls: failed to verify certificate: x509: certificate has expired or is not yet valid")), "tls certificate"},
+		{wrap(errors.New("remote error: tls: handshake failure")), "tls handshake"},
+		{wrap(errors.New("http2: server sent GOAWAY and closed the connection; LastStreamID=1, ErrCode=NO_ERROR")), "http2 goaway"},
+		{wrap(errors.New("stream error: stream ID 3; INTERNAL_ERROR")), "http2 stream"},
+		{wrap(errors.New("stopped after 10 redirects")), "too many redirects"},
+		{errors.New("network error"), "other"},
+	}
+	seen := map[string]bool{}
+	for _, tt := range tests {
+		if got := classifyError(tt.err); got != tt.want {
+			t.Errorf("classifyError(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+		seen[tt.want] = true
+	}
+	for cat := range seen {
+		if !slices.Contains(ErrorCategories, cat) {
+			t.Errorf("category %q is missing from ErrorCategories", cat)
+		}
+	}
+	if len(seen) != len(ErrorCategories) {
+		t.Errorf("expected a case for each of %v, got %d", ErrorCategories, len(seen))
+	}
+}
+
+// TestStatsErrorSamples checks that a category keeps its first distinct
+// messages, without the request URL, and that merging respects the limit.
+func TestStatsErrorSamples(t *testing.T) {
+	s := newStats(0)
+	for i := 0; i < 10; i++ {
+		err := &url.Error{Op: "Get", URL: fmt.Sprintf("http://example.com/%d", i), Err: fmt.Errorf("failure %d", i%4)}
+		s.add(result{err: err})
+	}
+	want := []string{"Get: failure 0", "Get: failure 1", "Get: failure 2"}
+	if got := s.errorSample["other"]; !slices.Equal(got, want) {
+		t.Errorf("samples = %q, want %q", got, want)
+	}
+
+	o := newStats(0)
+	o.add(result{err: errors.New("failure 3")})
+	o.add(result{err: io.EOF})
+	s.merge(o)
+	if got := s.errorSample["other"]; !slices.Equal(got, want) {
+		t.Errorf("samples after merge = %q, want %q", got, want)
+	}
+	if got := s.errorSample["unexpected eof"]; !slices.Equal(got, []string{"EOF"}) {
+		t.Errorf("unexpected eof samples = %q, want [EOF]", got)
+	}
+}
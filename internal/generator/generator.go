@@ -2,64 +2,214 @@ package generator
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"github.com/idesyatov/http-runner/internal/check"
+	"github.com/idesyatov/http-runner/internal/feeder"
+	"github.com/idesyatov/http-runner/internal/results"
+	"github.com/idesyatov/http-runner/internal/templating"
 	"github.com/idesyatov/http-runner/pkg/httpclient"
 	"io"
-	"math"
-	"net"
-	"sort"
-	"strings"
+	"maps"
+	"net/http"
 	"sync"
 	"time"
 )
 
+// Executors select how requests are scheduled.
+const (
+	// ExecutorClosed is the closed model: at most Concurrency requests are in
+	// flight and a new one starts only when a slot frees up, so a slow server
+	// lowers the achieved rate.
+	ExecutorClosed = "closed"
+	// ExecutorArrivalRate is the open model: requests start on a fixed schedule
+	// of Rate per second regardless of how many are still in flight. A request
+	// whose slot would exceed MaxInFlight is dropped rather than delayed.
+	ExecutorArrivalRate = "arrival-rate"
+)
+
+// DefaultInterval is the width of a timeseries window when
+// RequestConfig.Interval is unset.
+const DefaultInterval = time.Second
+
+// ProgressInterval is how often RequestConfig.Progress is called during a run.
+const ProgressInterval = time.Second
+
 type Generator struct {
 	Client *httpclient.Client // The HTTP client used for sending requests
 }
 
 // RequestConfig holds the configuration for generating requests.
 type RequestConfig struct {
-	Method        string            // The HTTP method to use
-	URL           string            // The URL to send requests to
-	Count         int               // The number of requests to generate
-	Verbose       bool              // Flag to enable verbose output
-	Concurrency   int               // The level of concurrency for requests
-	ParsedHeaders map[string]string // Headers to include in the requests
-	Data          interface{}       // Data to include in the request body (arbitrary JSON)
-	Duration      time.Duration     // If >0, run for this wall-clock time instead of Count
-	Rate          int               // Target requests per second (0 = unlimited)
+	Name           string                       // Label of the endpoint or scenario in the report (optional)
+	Method         string                       // The HTTP method to use
+	URL            string                       // The URL to send requests to
+	Count          int                          // The number of requests to generate
+	Verbose        bool                         // Flag to enable verbose output
+	Concurrency    int                          // The level of concurrency for requests
+	ParsedHeaders  map[string]string            // Headers to include in the requests
+	Data           interface{}                  // Data to include in the request body (arbitrary JSON)
+	Duration       time.Duration                // If >0, run for this wall-clock time instead of Count
+	Rate           int                          // Target requests per second (0 = unlimited)
+	Executor       string                       // ExecutorClosed (default) or ExecutorArrivalRate
+	MaxInFlight    int                          // Arrival-rate only: cap on in-flight requests (0 = unlimited)
+	Stages         []Stage                      // If set, a staged load profile that replaces Count and Duration
+	Precision      int                          // Significant digits kept by latency histograms (0 = DefaultPrecision)
+	Interval       time.Duration                // Width of a timeseries window (0 = DefaultInterval)
+	Progress       func(Progress)               // If set, called every ProgressInterval while the run is in flight
+	WarmupCount    int                          // Requests to send before measuring, left out of the report
+	WarmupDuration time.Duration                // Time to send requests for before measuring (takes precedence over WarmupCount)
+	Template       *templating.Request          // If set, renders the URL, headers and data of each request (replacing the fields above)
+	Feeder         *feeder.Feeder               // If set, each request takes a row whose columns are the template's variables
+	Steps          []Step                       // If set, a scenario: each iteration sends these requests in order instead of one request
+	Checks         []*check.Check               // Assertions a response must pass to count as a success
+	Weight         int                          // Share of a traffic mix's requests (values below 1 count as 1)
+	Mix            []RequestConfig              // If set, a traffic mix: each request goes to one of these, picked by weight
+	Abort          func(GeneratorReport) string // If set, called with the metrics of the last AbortWindow while the run is in flight; a non-empty reason stops the run
+	AbortWindow    time.Duration                // Width of the window handed to Abort (0 = DefaultAbortWindow)
+	Results        *results.Writer              // If set, receives a record of every request (or scenario iteration) measured
 }
 
+// forkData returns a copy of cfg whose feeders and templates, including
+// those of its scenario steps and mix endpoints, are forks of those of cfg:
+// they draw random values of their own but share its rows and sequence
+// numbers, so that the two never send the same data.
+func (cfg RequestConfig) forkData() RequestConfig {
+	if cfg.Feeder != nil {
+		cfg.Feeder = cfg.Feeder.Fork()
+	}
+	if cfg.Template != nil {
+		cfg.Template = cfg.Template.Fork()
+	}
+	if cfg.Steps != nil {
+		steps := make([]Step, len(cfg.Steps))
+		for i, st := range cfg.Steps {
+			if st.Template != nil {
+				st.Template = st.Template.Fork()
+			}
+			steps[i] = st
+		}
+		cfg.Steps = steps
+	}
+	if cfg.Mix != nil {
+		mix := make([]RequestConfig, len(cfg.Mix))
+		for i, m := range cfg.Mix {
+			mix[i] = m.forkData()
+		}
+		cfg.Mix = mix
+	}
+	return cfg
+}
+
+// Progress is a live view of a run in flight, handed to RequestConfig.Progress.
+type Progress struct {
+	Elapsed           time.Duration // Time since the run started
+	Remaining         time.Duration // Time left in a run bounded in time (0 otherwise)
+	Sent              int           // Requests launched so far
+	Target            int           // Requests to send in a run bounded by count (0 otherwise)
+	DroppedIterations int           // Arrival-rate only: scheduled requests skipped so far
+	InFlight          int           // Requests launched but not finished
+	RequestsPerSec    float64       // Finished requests per second over the last timeseries window
+	P99Response       float64       // 99th percentile response time over the last timeseries window
+	ErrorCount        int           // Transport errors so far
+	StatusCodes       map[int]int   // Status code -> count so far
+	Warmup            bool          // Whether the requests are those of the warm-up
+}
+
+// For a scenario (RequestConfig.Steps set) the metrics describe whole
+// iterations: Count is the iterations launched, latency spans every step, an
+// iteration succeeds only if all its steps do, and its status code is that of
+// the step it ended on. Steps has the per-request metrics of each step.
+//
+// For a traffic mix (RequestConfig.Mix set) the metrics cover every request
+// sent, and Endpoints has the metrics of the requests sent to each endpoint.
 type GeneratorReport struct {
-	URL             string            // The URL of the request
-	Method          string            // The HTTP method used
-	Count           int               // The number of requests made
-	Concurrency     int               // The level of concurrency
-	TotalDuration   time.Duration     // The total duration of the request execution
-	RequestsPerSec  float64           // Throughput: requests per second over the whole run
-	TotalBytes      int64             // Total response body bytes read across completed requests
-	BytesPerSec     float64           // Throughput: response body bytes per second over the whole run
-	ParsedHeaders   map[string]string // Headers passed to the request
-	ParsedData      interface{}       // Data passed to the request (arbitrary JSON)
-	AverageResponse float64           // The average response time
-	P50Response     float64           // The 50th percentile (median) response time
-	P90Response     float64           // The 90th percentile response time
-	P95Response     float64           // The 95th percentile response time
-	P99Response     float64           // The 99th percentile response time
-	MinResponse     float64           // The minimum response time
-	MaxResponse     float64           // The maximum response time
-	AvgDNS          float64           // Average DNS resolution time over new connections
-	AvgConnect      float64           // Average TCP connect time over new connections
-	AvgTLS          float64           // Average TLS handshake time over new connections
-	AvgTTFB         float64           // Average time to first response byte over completed requests
-	ConnReuseRate   float64           // Percentage of completed requests served over a reused connection
-	SuccessCount    int               // The count of successful (2xx) responses
-	SuccessRate     float64           // The success rate as a percentage
-	StatusCodes     map[int]int       // A map to store status codes and their counts
-	ErrorCount      int               // The number of requests that failed with a transport error
-	Errors          map[string]int    // Transport errors grouped by category
-	Histogram       []Bucket          // Latency distribution over completed requests
+	Name              string              // Label of the endpoint, scenario or mix
+	URL               string              // The URL of the request
+	Method            string              // The HTTP method used
+	Count             int                 // The number of requests made
+	Concurrency       int                 // The level of concurrency
+	Executor          string              // Scheduling model used for the run
+	Rate              int                 // Target requests per second (0 = unlimited)
+	DroppedIterations int                 // Arrival-rate only: scheduled requests skipped because MaxInFlight was reached
+	LateIterations    int                 // Arrival-rate only: requests that started more than one interval after their scheduled time
+	MissedTicks       int                 // Closed executor only: ticks of the schedule skipped while waiting for a free slot
+	WarmupCount       int                 // Requests sent during the warm-up and left out of every other field
+	FeederExhausted   bool                // The run stopped early because the feeder ran out of rows
+	AbortReason       string              // Why Abort stopped the run early, if it did
+	TotalDuration     time.Duration       // The total duration of the request execution
+	RequestsPerSec    float64             // Throughput: requests per second over the whole run
+	TotalBytes        int64               // Total response body bytes read across completed requests
+	BytesPerSec       float64             // Throughput: response body bytes per second over the whole run
+	ParsedHeaders     map[string]string   // Headers passed to the request
+	ParsedData        interface{}         // Data passed to the request (arbitrary JSON)
+	AverageResponse   float64             // The average response time
+	P50Response       float64             // The 50th percentile (median) response time
+	P90Response       float64             // The 90th percentile response time
+	P95Response       float64             // The 95th percentile response time
+	P99Response       float64             // The 99th percentile response time
+	CorrectedP50      float64             // p50 measured from the scheduled start (equals P50Response without a rate)
+	CorrectedP90      float64             // p90 measured from the scheduled start
+	CorrectedP95      float64             // p95 measured from the scheduled start
+	CorrectedP99      float64             // p99 measured from the scheduled start
+	MinResponse       float64             // The minimum response time
+	MaxResponse       float64             // The maximum response time
+	AvgDNS            float64             // Average DNS resolution time over new connections
+	AvgConnect        float64             // Average TCP connect time over new connections
+	AvgTLS            float64             // Average TLS handshake time over new connections
+	AvgTTFB           float64             // Average time to first response byte over completed requests
+	ConnReuseRate     float64             // Percentage of completed requests served over a reused connection
+	SuccessCount      int                 // The count of successful (2xx) responses
+	SuccessRate       float64             // The success rate as a percentage
+	StatusCodes       map[int]int         // A map to store status codes and their counts
+	ErrorCount        int                 // The number of requests that failed with a transport error
+	Errors            map[string]int      // Transport errors grouped by category
+	ErrorSamples      map[string][]string // Category -> the first distinct error messages (up to 3)
+	CheckFailures     map[string]int      // Check name -> responses that failed it
+	Histogram         []Bucket            // Latency distribution over completed requests
+	Stages            []StageReport       // Per-stage breakdown of a staged run
+	Timeseries        []Interval          // Per-window snapshots over the run, in order
+	Steps             []StepReport        // Per-step breakdown of a scenario
+	Weight            int                 // Share of a traffic mix's requests (mix endpoints only)
+	Endpoints         []GeneratorReport   // Per-endpoint breakdown of a traffic mix
+}
+
+// StageReport holds the metrics of one stage of a staged run. Requests belong
+// to the stage they were scheduled (or, without a rate, launched) in.
+type StageReport struct {
+	Start             time.Duration // Offset of the stage from the start of the run
+	Duration          time.Duration // Time the stage actually ran (shorter if the run was cut short)
+	Rate              int           // Target rate at the end of the stage (0 = unlimited)
+	Concurrency       int           // Concurrency in effect during the stage
+	Count             int           // Requests launched in the stage
+	DroppedIterations int           // Arrival-rate only: scheduled requests skipped in the stage
+	LateIterations    int           // Arrival-rate only: requests of the stage that started over an interval late
+	MissedTicks       int           // Closed executor only: ticks of the stage skipped while waiting for a free slot
+	RequestsPerSec    float64       // Launched requests per second over the stage
+	SuccessRate       float64       // Percentage of launched requests that got a 2xx
+	ErrorCount        int           // Transport errors
+	AverageResponse   float64       // Average response time
+	P50Response       float64       // 50th percentile response time
+	P95Response       float64       // 95th percentile response time
+	P99Response       float64       // 99th percentile response time
+	MaxResponse       float64       // Maximum response time
+}
+
+// Interval is a snapshot of one timeseries window. Requests belong to the
+// window they finished in, so a window shows what the server delivered then.
+type Interval struct {
+	Start             time.Duration // Offset of the window from the start of the run
+	Duration          time.Duration // Width of the window (the last one may be shorter)
+	Count             int           // Requests that finished in the window
+	DroppedIterations int           // Arrival-rate only: scheduled requests skipped in the window
+	LateIterations    int           // Arrival-rate only: requests that started over an interval late in the window
+	MissedTicks       int           // Closed executor only: ticks skipped in the window while waiting for a free slot
+	RequestsPerSec    float64       // Finished requests per second
+	SuccessRate       float64       // Percentage of finished requests that got a 2xx
+	ErrorCount        int           // Transport errors
+	P50Response       float64       // 50th percentile response time
+	P95Response       float64       // 95th percentile response time
+	P99Response       float64       // 99th percentile response time
+	InFlight          int           // Requests in flight at the end of the window
 }
 
 // Bucket is one bar of the latency histogram: [Start, End] seconds and how many
@@ -82,289 +232,590 @@ func (g *Generator) GenerateRequests(ctx context.Context, cfg RequestConfig) Gen
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 
-	var totalResponseTime time.Duration // Total response time across completed requests
-	var minResponseTime time.Duration   // Minimum response time recorded
-	var maxResponseTime time.Duration   // Maximum response time recorded
-	var completedCount int              // Requests that got an HTTP response (no transport error)
-	var successCount int                // Responses with a 2xx status code
-	var errorCount int                  // Requests that failed with a transport error
-	var statusCodes = make(map[int]int) // Map for storing status codes
-	var errorTypes = make(map[string]int)
-	var responseTimes []time.Duration // Per-request response times (completed only) for percentiles
-	var sentCount int                 // Requests actually launched
-	var totalBytes int64              // Response body bytes read across completed requests
-
-	// Connection phase timings (httptrace). DNS/connect/TLS only accrue on new
-	// connections, so they carry their own counters; TTFB and reuse span all
-	// completed requests.
-	var sumDNS, sumConnect, sumTLS, sumTTFB time.Duration
-	var cntDNS, cntConnect, cntTLS, reusedCount int
+	executor := cfg.Executor
+	if executor == "" {
+		executor = ExecutorClosed
+	}
 
-	startTime := time.Now() // Start of total execution time
+	// The warm-up is a run of its own ahead of the measured one, at the same
+	// rate and concurrency, of which only the request count is kept. It pays
+	// for DNS, handshakes and server-side warming that would otherwise skew
+	// the first requests measured. It draws on forks of the feeders and
+	// templates: its random values are its own, and the rows and sequence
+	// numbers it takes are used up, so the measured run sends none of its
+	// data again and finds no cache warmed on exactly its keys.
+	warmupCount := 0
+	if cfg.WarmupCount > 0 || cfg.WarmupDuration > 0 {
+		w := cfg.forkData()
+		w.Count, w.Duration, w.Stages = cfg.WarmupCount, cfg.WarmupDuration, nil
+		w.WarmupCount, w.WarmupDuration = 0, 0
+		w.Abort, w.Results = nil, nil
+		if cfg.Progress != nil {
+			w.Progress = func(p Progress) {
+				p.Warmup = true
+				cfg.Progress(p)
+			}
+		}
+		if w.Rate <= 0 {
+			// Only stages set the rate: without one an arrival-rate warm-up
+			// would have no schedule to keep to.
+			w.Executor = ExecutorClosed
+		}
+		warmupCount = g.GenerateRequests(ctx, w).Count
+	}
 
-	// Create a channel for the semaphore to limit concurrency
-	semaphore := make(chan struct{}, cfg.Concurrency)
+	// With a rate, every request has an intended start time on a fixed
+	// schedule. Measuring latency from it as well as from the actual start
+	// exposes time spent queued inside the generator, which the plain
+	// percentiles hide (coordinated omission).
+	sched := newSchedule(cfg)
 
-	// Optional rate limiter: at most cfg.Rate requests started per second.
-	var rateCh <-chan time.Time
-	if cfg.Rate > 0 {
-		interval := time.Second / time.Duration(cfg.Rate)
-		if interval <= 0 {
-			interval = time.Nanosecond
-		}
-		ticker := time.NewTicker(interval)
-		defer ticker.Stop()
-		rateCh = ticker.C
+	total := newStats(cfg.Precision)
+	stageStats := make([]*stats, len(cfg.Stages))
+	for i := range stageStats {
+		stageStats[i] = newStats(cfg.Precision)
+	}
+	stepStats := make([]*stats, len(cfg.Steps))
+	for i := range stepStats {
+		stepStats[i] = newStats(cfg.Precision)
 	}
+	mixStats := make([]*stats, len(cfg.Mix))
+	for i := range mixStats {
+		mixStats[i] = newStats(cfg.Precision)
+	}
+	picker := newMixPicker(cfg.Mix)
 
-	worker := func() {
-		defer wg.Done()
-		defer func() { <-semaphore }() // Release semaphore
+	// The current timeseries window, closed every interval by the monitor
+	// below. inFlight counts launched requests that have not finished yet.
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	window := newStats(cfg.Precision)
+	inFlight := 0
+	var series []Interval
 
-		start := time.Now()
-		// Send the request using the HTTP client
-		resp, trace, err := g.Client.SendRequest(cfg.Method, cfg.URL, cfg.ParsedHeaders, cfg.Data)
-		responseTime := time.Since(start)
+	startTime := time.Now() // Start of total execution time
+	windowStart := startTime
 
-		// Drain and close the body so the connection can be reused (keep-alive).
-		// io.Copy already reports how many bytes were read, so byte throughput
-		// costs nothing extra.
-		var bodyBytes int64
-		if err == nil {
-			bodyBytes, _ = io.Copy(io.Discard, resp.Body)
-			_ = resp.Body.Close()
+	// closeWindow appends the current window, ending at now, to the series
+	// and starts the next one. Callers hold mu.
+	closeWindow := func(now time.Time) {
+		series = append(series, window.interval(windowStart.Sub(startTime), now.Sub(windowStart), inFlight))
+		window.reset()
+		windowStart = now
+	}
+
+	// snapshot returns the live view of the run at now. Callers hold mu.
+	snapshot := func(now time.Time) Progress {
+		p := Progress{
+			Elapsed:           now.Sub(startTime),
+			Sent:              total.sent,
+			DroppedIterations: total.dropped,
+			InFlight:          inFlight,
+			ErrorCount:        total.errors,
+			StatusCodes:       maps.Clone(total.statusCodes),
+		}
+		if sched.end > 0 {
+			p.Remaining = max(sched.end-p.Elapsed, 0)
+		} else if len(cfg.Stages) == 0 {
+			p.Target = cfg.Count
 		}
+		// Rate and p99 come from the last full window, or from the current
+		// one until the first window closes.
+		if n := len(series); n > 0 {
+			p.RequestsPerSec = series[n-1].RequestsPerSec
+			p.P99Response = series[n-1].P99Response
+		} else if el := now.Sub(windowStart).Seconds(); el > 0 {
+			p.RequestsPerSec = float64(window.sent) / el
+			p.P99Response = percentile(window.latencies, 99)
+		}
+		return p
+	}
 
-		mu.Lock()
-		// Latency metrics cover every completed request (one that returned an
-		// HTTP response); transport errors carry no meaningful response time.
-		// "Success" is narrower: only 2xx responses count toward SuccessCount.
-		if err == nil {
-			completedCount++
-			totalResponseTime += responseTime
-			totalBytes += bodyBytes
-			responseTimes = append(responseTimes, responseTime)
-			statusCodes[resp.StatusCode]++ // Increment the counter for the status code
-			if minResponseTime == 0 || responseTime < minResponseTime {
-				minResponseTime = responseTime
+	// Monitors do periodic work alongside the run until it ends.
+	stopMonitors := make(chan struct{})
+	var monitors sync.WaitGroup
+	every := func(d time.Duration, fn func(now time.Time)) {
+		monitors.Add(1)
+		go func() {
+			defer monitors.Done()
+			ticker := time.NewTicker(d)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-stopMonitors:
+					return
+				case now := <-ticker.C:
+					fn(now)
+				}
 			}
-			if responseTime > maxResponseTime {
-				maxResponseTime = responseTime
+		}()
+	}
+	every(interval, func(now time.Time) {
+		mu.Lock()
+		closeWindow(now)
+		mu.Unlock()
+	})
+	if cfg.Progress != nil {
+		every(ProgressInterval, func(now time.Time) {
+			mu.Lock()
+			p := snapshot(now)
+			mu.Unlock()
+			cfg.Progress(p)
+		})
+	}
+
+	// A run bounded in time stops launching at its end, even while waiting for
+	// a slot or for the next scheduled start. Abort stops it the same way.
+	runCtx, stopRun := context.WithCancel(ctx)
+	defer stopRun()
+	if sched.end > 0 {
+		end := sched.end
+		if sched.rated && executor != ExecutorArrivalRate {
+			// The closed executor's last tick falls on the end itself, and
+			// may wait up to its own interval for a slot.
+			last, _ := sched.at(sched.next(end) - 1)
+			end += end - last
+		}
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithDeadline(runCtx, startTime.Add(end))
+		defer cancel()
+	}
+
+	// Abort is handed the last window of the run once per slice of it, from
+	// the time a full window has passed, so a short burst at the start does
+	// not stop a run on its own.
+	var recent *rolling
+	abortReason := ""
+	if cfg.Abort != nil {
+		recent = newRolling(cfg.Precision)
+		width := cfg.AbortWindow
+		if width <= 0 {
+			width = DefaultAbortWindow
+		}
+		every(width/abortSlices, func(now time.Time) {
+			var wr GeneratorReport
+			mu.Lock()
+			full := now.Sub(startTime) >= width
+			if full {
+				recent.window().fill(&wr, width)
 			}
-			if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-				successCount++
+			recent.advance()
+			mu.Unlock()
+			if !full {
+				return
 			}
-			// Aggregate connection phase timings. DNS/connect/TLS are counted
-			// only when they actually happened (a new connection); TTFB and
-			// reuse apply to every completed request.
-			if trace != nil {
-				sumTTFB += trace.TTFB
-				if trace.Reused {
-					reusedCount++
-				}
-				if trace.DNS > 0 {
-					sumDNS += trace.DNS
-					cntDNS++
+			if reason := cfg.Abort(wr); reason != "" {
+				mu.Lock()
+				if abortReason == "" {
+					abortReason = reason
 				}
-				if trace.Connect > 0 {
-					sumConnect += trace.Connect
-					cntConnect++
-				}
-				if trace.TLS > 0 {
-					sumTLS += trace.TLS
-					cntTLS++
+				mu.Unlock()
+				stopRun()
+			}
+		})
+	}
+
+	// The limiter caps requests in flight: the closed executor waits on it at
+	// Concurrency, the arrival-rate executor never waits and drops what would
+	// exceed MaxInFlight (0 = unlimited).
+	var slots *limiter
+	if executor == ExecutorArrivalRate {
+		slots = newLimiter(cfg.MaxInFlight)
+	} else {
+		slots = newLimiter(cfg.Concurrency)
+	}
+
+	// Stage concurrency takes effect at the stage boundary, even while the
+	// scheduler is blocked waiting for a slot.
+	stageConcurrency := make([]int, len(cfg.Stages))
+	concurrency := cfg.Concurrency
+	for i, st := range cfg.Stages {
+		if st.Concurrency > 0 {
+			concurrency = st.Concurrency
+			if executor == ExecutorClosed {
+				limit := concurrency
+				if at := sched.segments[i].start; at == 0 {
+					slots.setLimit(limit)
+				} else {
+					t := time.AfterFunc(at, func() { slots.setLimit(limit) })
+					defer t.Stop()
 				}
 			}
+		}
+		stageConcurrency[i] = concurrency
+	}
+
+	// recordStep records the result of one step of a scenario iteration.
+	recordStep := func(step int, res result) {
+		mu.Lock()
+		defer mu.Unlock()
+		stepStats[step].sent++
+		stepStats[step].add(res)
+	}
+
+	// worker sends one request, or runs one iteration of a scenario. intended
+	// is its scheduled start time, or zero when the run has no schedule (no
+	// rate), in which case the corrected latency equals the measured one.
+	// target is the index of the request's endpoint in a traffic mix, or -1.
+	worker := func(stage, target int, intended time.Time, row feeder.Row) {
+		defer wg.Done()
+		defer slots.release()
+
+		ep := cfg
+		if target >= 0 {
+			ep = cfg.Mix[target]
+		}
+		var res result
+		url := ep.URL
+		start := time.Now()
+		if len(ep.Steps) > 0 {
+			res = g.iterate(ep, row, recordStep)
+			res.latency = time.Since(start)
 		} else {
-			errorCount++
-			errorTypes[classifyError(err)]++
+			headers, data := ep.ParsedHeaders, ep.Data
+			if ep.Template != nil {
+				url, headers, data = ep.Template.Render(row)
+			}
+			start = time.Now()
+			res, _, _ = g.exchange(ep.Method, url, headers, data, ep.Checks, false, ep.Verbose)
+		}
+		res.stage = stage
+		end := start.Add(res.latency)
+		if intended.IsZero() || intended.After(start) {
+			intended = start
+		}
+		res.corrected = end.Sub(intended)
+		if cfg.Results != nil {
+			cfg.Results.Write(res.record(ep, url, start, intended))
+		}
+
+		mu.Lock()
+		total.add(res)
+		if stage >= 0 {
+			stageStats[stage].add(res)
+		}
+		if target >= 0 {
+			mixStats[target].add(res)
+		}
+		window.sent++ // the window counts requests as they finish
+		window.add(res)
+		if recent != nil {
+			recent.current().sent++
+			recent.current().add(res)
 		}
+		inFlight--
 		mu.Unlock()
+	}
 
-		// Output response status only when verbose is enabled
-		if cfg.Verbose {
-			if err != nil {
-				fmt.Println("Error:", err)
+	// count records a launched (or, for arrival-rate, dropped) request against
+	// the run, its stage and its endpoint in a mix (target, or -1). late marks
+	// an arrival-rate request launched behind schedule.
+	count := func(stage, target int, dropped, late bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		bump := func(s *stats) {
+			if dropped {
+				s.dropped++
 			} else {
-				fmt.Println("Response Status:", resp.Status)
+				s.sent++
+			}
+			if late {
+				s.late++
 			}
 		}
-	}
-
-	// launch starts a single request, honouring the rate limit and concurrency
-	// semaphore. It returns false if the run should stop (context cancelled).
-	launch := func() bool {
-		// Prioritise cancellation: a plain select below could otherwise pick the
-		// semaphore branch even when ctx is already done (Go chooses randomly
-		// among ready cases).
-		select {
-		case <-ctx.Done():
-			return false
-		default:
+		bump(total)
+		if stage >= 0 {
+			bump(stageStats[stage])
+		}
+		if target >= 0 {
+			bump(mixStats[target])
 		}
-		if rateCh != nil {
-			select {
-			case <-ctx.Done():
-				return false
-			case <-rateCh:
+		if dropped {
+			window.dropped++
+			if recent != nil {
+				recent.current().dropped++
 			}
+		} else {
+			inFlight++
 		}
-		select {
-		case <-ctx.Done():
-			return false
-		case semaphore <- struct{}{}:
+		if late {
+			window.late++
+			if recent != nil {
+				recent.current().late++
+			}
 		}
-		wg.Add(1)
-		sentCount++
-		go worker()
-		return true
 	}
 
-	if cfg.Duration > 0 {
-		deadline := startTime.Add(cfg.Duration)
-		for time.Now().Before(deadline) {
-			if !launch() {
+	// miss records n ticks of the closed executor's schedule skipped in stage.
+	miss := func(stage, n int) {
+		mu.Lock()
+		defer mu.Unlock()
+		total.missed += n
+		if stage >= 0 {
+			stageStats[stage].missed += n
+		}
+		window.missed += n
+		if recent != nil {
+			recent.current().missed += n
+		}
+	}
+
+	exhausted := false // The feeder ran out of rows
+
+	var timer *time.Timer
+	if sched.rated {
+		timer = time.NewTimer(0)
+		defer timer.Stop()
+	}
+
+	// Each pass launches the n-th request. The arrival-rate executor starts it
+	// at its scheduled time, derived from the schedule rather than from when the
+	// previous request went out, so a late wake-up is caught up rather than
+	// lost. The closed executor paces like a time.Ticker: its first request
+	// goes out one interval in, and ticks missed while it waited for a slot are
+	// dropped, so a stall never turns into a burst above the rate. Its
+	// corrected latency is measured from the tick each request waited for, so
+	// one stall does not skew every request after it; the ticks dropped are
+	// counted as missed instead.
+	tick := 0 // Closed executor: index of the schedule's last tick
+	for n := 0; ; n++ {
+		if len(cfg.Stages) == 0 && cfg.Duration <= 0 && n >= cfg.Count {
+			break
+		}
+		var intended time.Time
+		var offset time.Duration
+		missed := 0 // Closed executor: ticks skipped since the last request
+		if sched.rated {
+			off, ok := sched.at(n)
+			if executor != ExecutorArrivalRate {
+				// The k-th tick closes the k-th interval of the schedule,
+				// which must begin before the end of the run.
+				next := max(tick+1, sched.next(time.Since(startTime)))
+				missed, tick = next-tick-1, next
+				if _, ok = sched.at(tick - 1); ok {
+					off = tickAt(sched, tick)
+				}
+			}
+			if !ok {
 				break
 			}
+			intended = startTime.Add(off)
+			offset = off
+			if wait := time.Until(intended); wait > 0 {
+				timer.Reset(wait)
+				select {
+				case <-runCtx.Done():
+				case <-timer.C:
+				}
+			}
+		} else {
+			offset = time.Since(startTime)
 		}
-	} else {
-		for i := 0; i < cfg.Count; i++ {
-			if !launch() {
+		// Prioritise cancellation: the waits above and below could otherwise
+		// launch a request after the run is over.
+		if runCtx.Err() != nil {
+			break
+		}
+		stage := sched.stageAt(offset)
+		if missed > 0 {
+			miss(stage, missed)
+		}
+
+		if executor == ExecutorArrivalRate {
+			// Waiting for a free slot would silently lower the arrival rate
+			// (coordinated omission), so a request that finds MaxInFlight
+			// requests still running is counted as dropped instead.
+			if !slots.tryAcquire() {
+				count(stage, -1, true, false)
+				continue
+			}
+		} else if !slots.acquire(runCtx) {
+			break
+		}
+		// A row is taken only once the request is sure to go out, so a
+		// dropped iteration does not use one up. In a mix, the row comes from
+		// the feeder of the endpoint picked.
+		target, fd := -1, cfg.Feeder
+		if len(cfg.Mix) > 0 {
+			target = picker.next()
+			fd = cfg.Mix[target].Feeder
+		}
+		var row feeder.Row
+		if fd != nil {
+			var ok bool
+			if row, ok = fd.Next(); !ok {
+				slots.release()
+				exhausted = true
 				break
 			}
 		}
+		// An arrival-rate request that starts more than one interval after
+		// its scheduled time was sent in a burst catching up on the schedule.
+		late := false
+		if executor == ExecutorArrivalRate {
+			next, ok := sched.at(n + 1)
+			if !ok {
+				next = sched.end
+			}
+			late = time.Since(intended) > next-offset
+		}
+		count(stage, target, false, late)
+		wg.Add(1)
+		go worker(stage, target, intended, row)
 	}
 	wg.Wait()
 
 	totalDuration := time.Since(startTime) // Total execution time
 
-	// Statistics output
-	var averageResponseTime, successRate, requestsPerSec, bytesPerSec float64
-	if completedCount > 0 {
-		averageResponseTime = totalResponseTime.Seconds() / float64(completedCount)
-	}
-	if sentCount > 0 {
-		successRate = (float64(successCount) / float64(sentCount)) * 100
-	}
-	if totalDuration.Seconds() > 0 {
-		requestsPerSec = float64(sentCount) / totalDuration.Seconds()
-		bytesPerSec = float64(totalBytes) / totalDuration.Seconds()
-	}
-
-	// Average connection phase timings (seconds). Each phase divides by the
-	// number of requests where it actually occurred, so reused connections do
-	// not deflate the DNS/connect/TLS averages.
-	avgSeconds := func(sum time.Duration, n int) float64 {
-		if n == 0 {
-			return 0
-		}
-		return sum.Seconds() / float64(n)
+	// Stop the monitors, so Progress is not called after we return, and close
+	// the last, partial window.
+	close(stopMonitors)
+	monitors.Wait()
+	mu.Lock()
+	if end := startTime.Add(totalDuration); end.After(windowStart) {
+		closeWindow(end)
 	}
-	avgDNS := avgSeconds(sumDNS, cntDNS)
-	avgConnect := avgSeconds(sumConnect, cntConnect)
-	avgTLS := avgSeconds(sumTLS, cntTLS)
-	avgTTFB := avgSeconds(sumTTFB, completedCount)
-	var connReuseRate float64
-	if completedCount > 0 {
-		connReuseRate = (float64(reusedCount) / float64(completedCount)) * 100
-	}
-
-	sort.Slice(responseTimes, func(i, j int) bool { return responseTimes[i] < responseTimes[j] })
+	mu.Unlock()
 
 	// Create a report using the unified Report structure
-	return GeneratorReport{
+	report := GeneratorReport{
+		Name:            cfg.Name,
 		URL:             cfg.URL,
 		Method:          cfg.Method,
-		Count:           sentCount,
 		Concurrency:     cfg.Concurrency,
-		TotalDuration:   totalDuration,
-		RequestsPerSec:  requestsPerSec,
-		TotalBytes:      totalBytes,
-		BytesPerSec:     bytesPerSec,
+		Executor:        executor,
+		Rate:            cfg.Rate,
+		WarmupCount:     warmupCount,
+		FeederExhausted: exhausted,
+		AbortReason:     abortReason,
 		ParsedHeaders:   cfg.ParsedHeaders,
 		ParsedData:      cfg.Data,
-		AverageResponse: averageResponseTime,
-		P50Response:     percentile(responseTimes, 50),
-		P90Response:     percentile(responseTimes, 90),
-		P95Response:     percentile(responseTimes, 95),
-		P99Response:     percentile(responseTimes, 99),
-		MinResponse:     minResponseTime.Seconds(),
-		MaxResponse:     maxResponseTime.Seconds(),
-		AvgDNS:          avgDNS,
-		AvgConnect:      avgConnect,
-		AvgTLS:          avgTLS,
-		AvgTTFB:         avgTTFB,
-		ConnReuseRate:   connReuseRate,
-		SuccessCount:    successCount,
-		SuccessRate:     successRate,
-		StatusCodes:     statusCodes,
-		ErrorCount:      errorCount,
-		Errors:          errorTypes,
-		Histogram:       histogram(responseTimes, 10),
+		Timeseries:      series,
 	}
-}
+	total.fill(&report, totalDuration)
 
-// histogram splits the ascending-sorted response times into `buckets` equal-width
-// ranges between the min and max, counting how many samples fall in each. It
-// returns nil for an empty input, and a single bucket when every sample is equal.
-func histogram(sorted []time.Duration, buckets int) []Bucket {
-	if len(sorted) == 0 || buckets <= 0 {
-		return nil
-	}
-	min := sorted[0].Seconds()
-	max := sorted[len(sorted)-1].Seconds()
-	if max <= min {
-		return []Bucket{{Start: min, End: max, Count: len(sorted)}}
+	for i, seg := range sched.segments {
+		if seg.start >= totalDuration {
+			break // the run ended before this stage began
+		}
+		var sr GeneratorReport
+		stageStats[i].fill(&sr, min(seg.dur, totalDuration-seg.start))
+		report.Stages = append(report.Stages, StageReport{
+			Start:             seg.start,
+			Duration:          sr.TotalDuration,
+			Rate:              cfg.Stages[i].Rate,
+			Concurrency:       stageConcurrency[i],
+			Count:             sr.Count,
+			DroppedIterations: sr.DroppedIterations,
+			LateIterations:    sr.LateIterations,
+			MissedTicks:       sr.MissedTicks,
+			RequestsPerSec:    sr.RequestsPerSec,
+			SuccessRate:       sr.SuccessRate,
+			ErrorCount:        sr.ErrorCount,
+			AverageResponse:   sr.AverageResponse,
+			P50Response:       sr.P50Response,
+			P95Response:       sr.P95Response,
+			P99Response:       sr.P99Response,
+			MaxResponse:       sr.MaxResponse,
+		})
 	}
 
-	width := (max - min) / float64(buckets)
-	res := make([]Bucket, buckets)
-	for i := range res {
-		res[i].Start = min + width*float64(i)
-		res[i].End = min + width*float64(i+1)
+	for i, st := range cfg.Steps {
+		var sr GeneratorReport
+		stepStats[i].fill(&sr, totalDuration)
+		report.Steps = append(report.Steps, StepReport{
+			Name:               st.Name,
+			Method:             st.Method,
+			URL:                st.URL,
+			Count:              sr.Count,
+			RequestsPerSec:     sr.RequestsPerSec,
+			SuccessRate:        sr.SuccessRate,
+			ErrorCount:         sr.ErrorCount,
+			ExtractionFailures: stepStats[i].extractFailures,
+			CheckFailures:      sr.CheckFailures,
+			StatusCodes:        sr.StatusCodes,
+			AverageResponse:    sr.AverageResponse,
+			P50Response:        sr.P50Response,
+			P95Response:        sr.P95Response,
+			P99Response:        sr.P99Response,
+			MaxResponse:        sr.MaxResponse,
+		})
 	}
-	for _, d := range sorted {
-		idx := int((d.Seconds() - min) / width)
-		if idx >= buckets {
-			idx = buckets - 1 // the max value lands in the last bucket
+
+	for i, t := range cfg.Mix {
+		er := GeneratorReport{
+			Name:          t.Name,
+			URL:           t.URL,
+			Method:        t.Method,
+			Weight:        picker.weights[i],
+			ParsedHeaders: t.ParsedHeaders,
+			ParsedData:    t.Data,
 		}
-		res[idx].Count++
+		mixStats[i].fill(&er, totalDuration)
+		report.Endpoints = append(report.Endpoints, er)
 	}
-	return res
+	return report
 }
 
-// classifyError groups a transport error into a short, human-readable category
-// for the report (e.g. "timeout", "connection refused", "dns", "other").
-func classifyError(err error) string {
-	var netErr net.Error
-	if errors.As(err, &netErr) && netErr.Timeout() {
-		return "timeout"
-	}
-	switch s := err.Error(); {
-	case strings.Contains(s, "connection refused"):
-		return "connection refused"
-	case strings.Contains(s, "no such host"):
-		return "dns"
-	case strings.Contains(s, "context deadline exceeded"):
-		return "timeout"
-	default:
-		return "other"
+// exchange sends one request, reads its response body and runs the checks
+// against it. The body is kept, and returned, if keepBody is set or a check
+// needs it. The result's latency covers the whole exchange; the response is
+// returned with its body closed.
+func (g *Generator) exchange(method, url string, headers map[string]string, data interface{}, checks []*check.Check, keepBody, verbose bool) (result, *http.Response, []byte) {
+	for _, c := range checks {
+		keepBody = keepBody || c.NeedsBody()
 	}
-}
 
-// percentile returns the p-th percentile (0-100) of the ascending-sorted
-// durations, in seconds, using the nearest-rank method. Returns 0 if empty.
-func percentile(sorted []time.Duration, p float64) float64 {
-	n := len(sorted)
-	if n == 0 {
-		return 0
+	start := time.Now()
+	// Send the request using the HTTP client
+	resp, trace, err := g.Client.SendRequest(method, url, headers, data)
+	// Latency runs until the response headers arrive; draining the body
+	// below does not count towards it.
+	res := result{trace: trace, err: err, latency: time.Since(start)}
+
+	// Drain and close the body so the connection can be reused (keep-alive).
+	// io.Copy already reports how many bytes were read, so byte throughput
+	// costs nothing extra. Only a check or an extraction needs the body
+	// itself. A body cut short fails the request like any transport error.
+	var body []byte
+	if err == nil {
+		res.status = resp.StatusCode
+		var readErr error
+		if keepBody {
+			body, readErr = io.ReadAll(resp.Body)
+			res.bytes = int64(len(body))
+		} else {
+			res.bytes, readErr = io.Copy(io.Discard, resp.Body)
+		}
+		_ = resp.Body.Close()
+		if readErr != nil {
+			res.err = &bodyError{readErr}
+			res.trace = nil
+		}
 	}
-	rank := int(math.Ceil(p / 100 * float64(n)))
-	if rank < 1 {
-		rank = 1
+
+	if res.err == nil {
+		for _, c := range checks {
+			if !c.Pass(resp, body, res.bytes) {
+				res.checkFailures = append(res.checkFailures, c.Name)
+			} else if c.ChecksStatus() {
+				res.statusExpected = true
+			}
+		}
 	}
-	if rank > n {
-		rank = n
+
+	// Output response status only when verbose is enabled
+	if verbose {
+		if res.err != nil {
+			fmt.Println("Error:", res.err)
+		} else {
+			fmt.Println("Response Status:", resp.Status)
+			for _, name := range res.checkFailures {
+				fmt.Println("Check failed:", name)
+			}
+		}
 	}
-	return sorted[rank-1].Seconds()
+	return res, resp, body
 }
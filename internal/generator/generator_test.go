@@ -1,14 +1,25 @@
 package generator_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/idesyatov/http-runner/internal/check"
+	"github.com/idesyatov/http-runner/internal/extract"
+	"github.com/idesyatov/http-runner/internal/feeder"
 	"github.com/idesyatov/http-runner/internal/generator"
+	"github.com/idesyatov/http-runner/internal/results"
+	"github.com/idesyatov/http-runner/internal/templating"
 	"github.com/idesyatov/http-runner/pkg/httpclient"
 )
 
@@ -125,6 +136,34 @@ func TestGenerateRequests_Failure(t *testing.T) {
 	if report.Errors["other"] != 5 {
 		t.Errorf("expected 5 errors classified as 'other', got %d", report.Errors["other"])
 	}
+	if got := report.ErrorSamples["other"]; len(got) != 1 || got[0] != "Get: network error" {
+		t.Errorf("expected one sample 'Get: network error', got %q", got)
+	}
+}
+
+// TestGenerateRequests_BodyReadError verifies that a response whose body is
+// cut short counts as a transport error rather than a success.
+func TestGenerateRequests_BodyReadError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Length", "100")
+		_, _ = w.Write([]byte("short"))
+	}))
+	defer srv.Close()
+
+	gen := generator.NewGenerator(&httpclient.Client{Client: *srv.Client()})
+	report := gen.GenerateRequests(context.Background(), generator.RequestConfig{
+		Method: "GET", URL: srv.URL, Count: 3, Concurrency: 1,
+	})
+
+	if report.SuccessCount != 0 || report.ErrorCount != 3 {
+		t.Errorf("expected 3 errors and no successes, got %d errors and %d successes", report.ErrorCount, report.SuccessCount)
+	}
+	if report.Errors["body read"] != 3 {
+		t.Errorf("expected 3 body read errors, got %v", report.Errors)
+	}
+	if len(report.ErrorSamples["body read"]) == 0 {
+		t.Error("expected a sample message for body read errors")
+	}
 }
 
 // TestGenerateRequests_BytesAndHistogram drives a real server so the response
@@ -210,3 +249,995 @@ func TestGenerateRequests_ContextCancelled(t *testing.T) {
 		t.Errorf("expected 0 requests with a cancelled context, got %d", report.Count)
 	}
 }
+
+// slowServer returns a test server that holds every response for delay, so
+// requests pile up in flight.
+func slowServer(t *testing.T, delay time.Duration) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(delay)
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// TestGenerateRequests_ClosedRateDropsMissedTicks verifies that the closed
+// executor paces -rate like a ticker: the first request goes out one interval
+// in, and after a stall the requests that fell behind are not sent in a burst
+// to catch up.
+func TestGenerateRequests_ClosedRateDropsMissedTicks(t *testing.T) {
+	var mu sync.Mutex
+	var arrivals []time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		mu.Lock()
+		arrivals = append(arrivals, time.Now())
+		first := len(arrivals) == 1
+		mu.Unlock()
+		if first {
+			time.Sleep(250 * time.Millisecond) // the stall
+		}
+	}))
+	defer srv.Close()
+	gen := generator.NewGenerator(httpclient.NewClient(5*time.Second, false, true, 1))
+
+	cfg := generator.RequestConfig{
+		Method:      "GET",
+		URL:         srv.URL,
+		Count:       5,
+		Concurrency: 1,
+		Rate:        20, // a tick every 50ms
+	}
+	start := time.Now()
+	report := gen.GenerateRequests(context.Background(), cfg)
+
+	if report.Count != 5 || len(arrivals) != 5 {
+		t.Fatalf("expected 5 requests, got %d sent and %d received", report.Count, len(arrivals))
+	}
+	if first := arrivals[0].Sub(start); first < 40*time.Millisecond {
+		t.Errorf("expected the first request one interval in, got it after %s", first)
+	}
+	for i := 1; i < len(arrivals); i++ {
+		if gap := arrivals[i].Sub(arrivals[i-1]); gap < 40*time.Millisecond {
+			t.Errorf("expected requests at least an interval apart, request %d followed after %s", i+1, gap)
+		}
+	}
+	if report.MissedTicks < 3 {
+		t.Errorf("expected the ticks skipped during the stall to be counted, got %d", report.MissedTicks)
+	}
+}
+
+// TestGenerateRequests_ClosedRateRecoversFromStall verifies that after a stall
+// the closed executor measures the corrected latency from the tick each
+// request waited for, so only the requests caught in the stall are corrected
+// and those after it come back to their plain latency.
+func TestGenerateRequests_ClosedRateRecoversFromStall(t *testing.T) {
+	var mu sync.Mutex
+	n := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		mu.Lock()
+		n++
+		first := n == 1
+		mu.Unlock()
+		if first {
+			time.Sleep(300 * time.Millisecond) // the stall
+		}
+	}))
+	defer srv.Close()
+	gen := generator.NewGenerator(httpclient.NewClient(5*time.Second, false, true, 1))
+
+	cfg := generator.RequestConfig{
+		Method:      "GET",
+		URL:         srv.URL,
+		Count:       20,
+		Concurrency: 1,
+		Rate:        50, // a tick every 20ms
+	}
+	report := gen.GenerateRequests(context.Background(), cfg)
+
+	if report.Count != 20 {
+		t.Fatalf("expected 20 requests, got %d", report.Count)
+	}
+	// The stalled request and the one queued behind it are 2 of 20, above
+	// the 90th percentile.
+	if report.CorrectedP90 > report.P90Response+0.015 {
+		t.Errorf("expected the corrected p90 back near the plain p90 after the stall, got corrected=%f p90=%f", report.CorrectedP90, report.P90Response)
+	}
+	if report.CorrectedP99 < 0.25 {
+		t.Errorf("expected the stall in the corrected p99, got %f", report.CorrectedP99)
+	}
+	if report.MissedTicks < 10 {
+		t.Errorf("expected the ticks skipped during the stall to be counted, got %d", report.MissedTicks)
+	}
+}
+
+// TestGenerateRequests_ArrivalRateIgnoresSlowServer verifies that the open
+// model keeps to its schedule: with concurrency 1 a closed run against a 200ms
+// server would take ~2s for 10 requests, while arrival-rate starts them all on
+// time and finishes in roughly one server delay.
+func TestGenerateRequests_ArrivalRateIgnoresSlowServer(t *testing.T) {
+	srv := slowServer(t, 200*time.Millisecond)
+	gen := generator.NewGenerator(httpclient.NewClient(5*time.Second, false, true, 10))
+
+	cfg := generator.RequestConfig{
+		Method:      "GET",
+		URL:         srv.URL,
+		Count:       10,
+		Concurrency: 1,
+		Rate:        200,
+		Executor:    generator.ExecutorArrivalRate,
+	}
+	report := gen.GenerateRequests(context.Background(), cfg)
+
+	if report.Count != 10 || report.DroppedIterations != 0 {
+		t.Errorf("expected 10 sent and 0 dropped, got %d sent and %d dropped", report.Count, report.DroppedIterations)
+	}
+	if report.TotalDuration > time.Second {
+		t.Errorf("expected the run to stay on schedule (<1s), took %s", report.TotalDuration)
+	}
+	if report.Executor != generator.ExecutorArrivalRate {
+		t.Errorf("expected executor %q in the report, got %q", generator.ExecutorArrivalRate, report.Executor)
+	}
+}
+
+// TestGenerateRequests_ArrivalRateDropsOverCap verifies that scheduled requests
+// finding MaxInFlight requests still running are dropped, not delayed.
+func TestGenerateRequests_ArrivalRateDropsOverCap(t *testing.T) {
+	srv := slowServer(t, 300*time.Millisecond)
+	gen := generator.NewGenerator(httpclient.NewClient(5*time.Second, false, true, 10))
+
+	cfg := generator.RequestConfig{
+		Method:      "GET",
+		URL:         srv.URL,
+		Count:       10,
+		Concurrency: 10,
+		Rate:        200, // the whole schedule fits well inside one server delay
+		Executor:    generator.ExecutorArrivalRate,
+		MaxInFlight: 3,
+	}
+	report := gen.GenerateRequests(context.Background(), cfg)
+
+	if report.Count != 3 {
+		t.Errorf("expected 3 requests sent under the in-flight cap, got %d", report.Count)
+	}
+	if report.DroppedIterations != 7 {
+		t.Errorf("expected 7 dropped iterations, got %d", report.DroppedIterations)
+	}
+	if report.SuccessCount != 3 {
+		t.Errorf("expected the 3 sent requests to succeed, got %d", report.SuccessCount)
+	}
+}
+
+// TestGenerateRequests_ArrivalRateCountsLate verifies that requests started
+// more than one interval behind schedule are counted as late: at a rate no
+// scheduler keeps up with, the run catches up in bursts, and every late
+// request also shows in the timeseries window it started in.
+func TestGenerateRequests_ArrivalRateCountsLate(t *testing.T) {
+	mockClient := &MockClient{Response: &http.Response{StatusCode: 200, Body: http.NoBody}}
+	gen := generator.NewGenerator(&httpclient.Client{Client: http.Client{Transport: mockClient}})
+
+	cfg := generator.RequestConfig{
+		Method:   "GET",
+		URL:      "https://example.com",
+		Count:    5000,
+		Rate:     1000000, // a request every microsecond
+		Executor: generator.ExecutorArrivalRate,
+	}
+	report := gen.GenerateRequests(context.Background(), cfg)
+
+	if report.LateIterations == 0 || report.LateIterations > report.Count {
+		t.Errorf("expected some of the %d requests to start late, got %d", report.Count, report.LateIterations)
+	}
+	late := 0
+	for _, iv := range report.Timeseries {
+		late += iv.LateIterations
+	}
+	if late != report.LateIterations {
+		t.Errorf("expected the timeseries to add up to %d late iterations, got %d", report.LateIterations, late)
+	}
+}
+
+// TestGenerateRequests_CorrectedPercentiles verifies coordinated omission
+// correction: with concurrency 1 against a slow server the closed model falls
+// behind its 50 req/s schedule, so latency measured from the tick a request
+// was due at must exceed latency measured from its actual start.
+func TestGenerateRequests_CorrectedPercentiles(t *testing.T) {
+	srv := slowServer(t, 100*time.Millisecond)
+	gen := generator.NewGenerator(httpclient.NewClient(5*time.Second, false, true, 1))
+
+	cfg := generator.RequestConfig{
+		Method:      "GET",
+		URL:         srv.URL,
+		Count:       5,
+		Concurrency: 1,
+		Rate:        50,
+	}
+	report := gen.GenerateRequests(context.Background(), cfg)
+
+	// Each request is due at the tick after the one before it went out, but
+	// can only start once that one is done, ~80ms later.
+	if report.CorrectedP99 < report.P99Response+0.05 {
+		t.Errorf("expected corrected p99 well above p99, got corrected=%f p99=%f", report.CorrectedP99, report.P99Response)
+	}
+	if report.CorrectedP50 < report.P50Response {
+		t.Errorf("expected corrected p50 >= p50, got corrected=%f p50=%f", report.CorrectedP50, report.P50Response)
+	}
+}
+
+// TestGenerateRequests_CorrectedWithoutRate verifies that without a schedule
+// the corrected percentiles fall back to the measured ones.
+func TestGenerateRequests_CorrectedWithoutRate(t *testing.T) {
+	mockClient := &MockClient{Response: &http.Response{StatusCode: 200}}
+	gen := generator.NewGenerator(&httpclient.Client{Client: http.Client{Transport: mockClient}})
+
+	cfg := generator.RequestConfig{
+		Method:      "GET",
+		URL:         "https://example.com",
+		Count:       20,
+		Concurrency: 4,
+	}
+	report := gen.GenerateRequests(context.Background(), cfg)
+
+	if report.CorrectedP50 != report.P50Response || report.CorrectedP99 != report.P99Response {
+		t.Errorf("expected corrected percentiles to equal measured ones without a rate, got p50 %f/%f p99 %f/%f",
+			report.CorrectedP50, report.P50Response, report.CorrectedP99, report.P99Response)
+	}
+}
+
+// TestGenerateRequests_Stages verifies a staged run: the report breaks the
+// requests down per stage and the stage counts add up to the total.
+func TestGenerateRequests_Stages(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	defer srv.Close()
+	gen := generator.NewGenerator(httpclient.NewClient(5*time.Second, false, true, 5))
+
+	cfg := generator.RequestConfig{
+		Method:      "GET",
+		URL:         srv.URL,
+		Count:       1, // ignored: the stages bound the run
+		Concurrency: 5,
+		Stages: []generator.Stage{
+			{Duration: 200 * time.Millisecond, Rate: 100}, // ramp 0→100: ~10 requests
+			{Duration: 200 * time.Millisecond, Rate: 100}, // hold: ~20 requests
+		},
+	}
+	report := gen.GenerateRequests(context.Background(), cfg)
+
+	if len(report.Stages) != 2 {
+		t.Fatalf("expected 2 stages in the report, got %d", len(report.Stages))
+	}
+	sum := 0
+	for _, st := range report.Stages {
+		sum += st.Count
+	}
+	if sum != report.Count {
+		t.Errorf("expected stage counts to add up to %d, got %d", report.Count, sum)
+	}
+	if report.Stages[1].Count <= report.Stages[0].Count {
+		t.Errorf("expected the plateau to send more than the ramp, got %d vs %d", report.Stages[1].Count, report.Stages[0].Count)
+	}
+	if report.Stages[1].Start != 200*time.Millisecond || report.Stages[1].Rate != 100 {
+		t.Errorf("unexpected second stage: %+v", report.Stages[1])
+	}
+}
+
+// TestGenerateRequests_Timeseries verifies the per-window snapshots: windows
+// are contiguous, cover the run, and their counts add up to the total.
+func TestGenerateRequests_Timeseries(t *testing.T) {
+	srv := slowServer(t, 20*time.Millisecond)
+	gen := generator.NewGenerator(httpclient.NewClient(5*time.Second, false, true, 10))
+
+	cfg := generator.RequestConfig{
+		Method:      "GET",
+		URL:         srv.URL,
+		Concurrency: 10,
+		Duration:    450 * time.Millisecond,
+		Rate:        100,
+		Interval:    100 * time.Millisecond,
+	}
+	report := gen.GenerateRequests(context.Background(), cfg)
+
+	if n := len(report.Timeseries); n < 4 || n > 6 {
+		t.Fatalf("expected about 5 windows of 100ms, got %d", n)
+	}
+	var sum, inFlight int
+	var next time.Duration
+	for i, iv := range report.Timeseries {
+		if iv.Start != next {
+			t.Errorf("window %d: expected to start at %s, got %s", i, next, iv.Start)
+		}
+		next = iv.Start + iv.Duration
+		sum += iv.Count
+		inFlight += iv.InFlight
+	}
+	if sum != report.Count {
+		t.Errorf("expected window counts to add up to %d, got %d", report.Count, sum)
+	}
+	if inFlight == 0 {
+		t.Errorf("expected requests in flight at some window boundary with a 20ms server")
+	}
+	if last := report.Timeseries[len(report.Timeseries)-1]; last.InFlight != 0 {
+		t.Errorf("expected nothing in flight at the end of the run, got %d", last.InFlight)
+	}
+	if mid := report.Timeseries[1]; mid.P99Response < 0.02 || mid.SuccessRate != 100 {
+		t.Errorf("expected a full window with p99 >= 20ms and 100%% success, got %+v", mid)
+	}
+}
+
+// TestGenerateRequests_Progress verifies that the progress callback is called
+// while a run is in flight, and never after it returns.
+func TestGenerateRequests_Progress(t *testing.T) {
+	srv := slowServer(t, 10*time.Millisecond)
+	gen := generator.NewGenerator(httpclient.NewClient(5*time.Second, false, true, 5))
+
+	var mu sync.Mutex
+	var updates []generator.Progress
+	cfg := generator.RequestConfig{
+		Method:      "GET",
+		URL:         srv.URL,
+		Concurrency: 5,
+		Duration:    2200 * time.Millisecond,
+		Rate:        50,
+		Progress: func(p generator.Progress) {
+			mu.Lock()
+			defer mu.Unlock()
+			updates = append(updates, p)
+		},
+	}
+	report := gen.GenerateRequests(context.Background(), cfg)
+
+	mu.Lock()
+	n := len(updates)
+	mu.Unlock()
+	if n != 2 {
+		t.Fatalf("expected 2 progress updates in 2.2s, got %d", n)
+	}
+	last := updates[n-1]
+	if last.Sent == 0 || last.Sent > report.Count || last.Target != 0 {
+		t.Errorf("expected 0 < sent <= %d and no target, got %+v", report.Count, last)
+	}
+	if last.Remaining <= 0 || last.Remaining > time.Second {
+		t.Errorf("expected under a second remaining at the last update, got %s", last.Remaining)
+	}
+	if last.RequestsPerSec < 40 || last.StatusCodes[200] == 0 {
+		t.Errorf("expected about 50 req/s and 200s counted, got %+v", last)
+	}
+
+	time.Sleep(generator.ProgressInterval + 100*time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(updates) != n {
+		t.Errorf("expected no progress updates after the run returned")
+	}
+}
+
+// TestGenerateRequests_Warmup verifies that warm-up requests are sent but left
+// out of the report: the server is slow for its first requests only, and none
+// of that shows up in the measured latencies.
+func TestGenerateRequests_Warmup(t *testing.T) {
+	var mu sync.Mutex
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		mu.Lock()
+		hits++
+		cold := hits <= 3
+		mu.Unlock()
+		if cold {
+			time.Sleep(200 * time.Millisecond)
+		}
+	}))
+	defer srv.Close()
+	gen := generator.NewGenerator(httpclient.NewClient(5*time.Second, false, true, 1))
+
+	cfg := generator.RequestConfig{
+		Method:      "GET",
+		URL:         srv.URL,
+		Count:       10,
+		Concurrency: 1,
+		WarmupCount: 3,
+	}
+	report := gen.GenerateRequests(context.Background(), cfg)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if hits != 13 {
+		t.Errorf("expected 13 requests to reach the server, got %d", hits)
+	}
+	if report.WarmupCount != 3 || report.Count != 10 || report.SuccessCount != 10 {
+		t.Errorf("expected 3 warm-up and 10 measured requests, got warmup=%d count=%d success=%d",
+			report.WarmupCount, report.Count, report.SuccessCount)
+	}
+	if report.MaxResponse >= 0.2 {
+		t.Errorf("expected the slow warm-up requests to be excluded, got max %f", report.MaxResponse)
+	}
+}
+
+// TestGenerateRequests_Template verifies that each request is rendered from
+// the template, so requests differ from one another.
+func TestGenerateRequests_Template(t *testing.T) {
+	var mu sync.Mutex
+	paths, ids := map[string]bool{}, map[string]bool{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		paths[r.URL.Path] = true
+		ids[r.Header.Get("X-Id")] = true
+	}))
+	defer srv.Close()
+	gen := generator.NewGenerator(httpclient.NewClient(5*time.Second, false, true, 4))
+
+	url := srv.URL + "/items/{{seq}}"
+	tmpl, err := templating.New(1).Compile(url, map[string]string{"X-Id": "{{uuid}}"}, nil)
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	cfg := generator.RequestConfig{
+		Method:      "GET",
+		URL:         url,
+		Count:       20,
+		Concurrency: 4,
+		Template:    tmpl,
+	}
+	report := gen.GenerateRequests(context.Background(), cfg)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if report.SuccessCount != 20 || len(paths) != 20 || len(ids) != 20 {
+		t.Errorf("expected 20 distinct successful requests, got %d successes, %d paths and %d ids", report.SuccessCount, len(paths), len(ids))
+	}
+	if !paths["/items/0"] || !paths["/items/19"] {
+		t.Errorf("expected paths /items/0 to /items/19, got %v", paths)
+	}
+}
+
+// TestGenerateRequests_FeederStops verifies that each request takes a feeder
+// row into its template, and that a feeder set to stop ends the run early.
+func TestGenerateRequests_FeederStops(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		paths = append(paths, r.URL.Path)
+	}))
+	defer srv.Close()
+	gen := generator.NewGenerator(httpclient.NewClient(5*time.Second, false, true, 1))
+
+	url := srv.URL + "/users/{{.id}}"
+	tmpl, err := templating.New(1).Compile(url, nil, nil)
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	rows := []feeder.Row{{"id": "a"}, {"id": "b"}, {"id": "c"}}
+	cfg := generator.RequestConfig{
+		Method:      "GET",
+		URL:         url,
+		Count:       10,
+		Concurrency: 1,
+		Template:    tmpl,
+		Feeder:      feeder.New(rows, []string{"id"}, feeder.StrategySequential, feeder.ExhaustedStop, 1),
+	}
+	report := gen.GenerateRequests(context.Background(), cfg)
+
+	if report.Count != 3 || !report.FeederExhausted {
+		t.Errorf("expected 3 requests and an exhausted feeder, got %d (exhausted=%v)", report.Count, report.FeederExhausted)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if strings.Join(paths, ",") != "/users/a,/users/b,/users/c" {
+		t.Errorf("expected one request per row in order, got %v", paths)
+	}
+}
+
+// TestGenerateRequests_WarmupUsesUpRows verifies that the warm-up uses up the
+// unique rows and sequence numbers it takes, so the measured run sends none of
+// them again, and that its random values are not those of the measured run.
+func TestGenerateRequests_WarmupUsesUpRows(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		paths = append(paths, r.URL.Path)
+	}))
+	defer srv.Close()
+	gen := generator.NewGenerator(httpclient.NewClient(5*time.Second, false, true, 1))
+
+	url := srv.URL + "/users/{{.id}}/{{seq}}/{{randInt 0 1000000}}"
+	newTemplate := func() *templating.Request {
+		tmpl, err := templating.New(1).Compile(url, nil, nil)
+		if err != nil {
+			t.Fatalf("Compile: %v", err)
+		}
+		return tmpl
+	}
+	rows := []feeder.Row{{"id": "a"}, {"id": "b"}, {"id": "c"}, {"id": "d"}, {"id": "e"}}
+	cfg := generator.RequestConfig{
+		Method:      "GET",
+		URL:         url,
+		Count:       3,
+		Concurrency: 1,
+		WarmupCount: 2,
+		Template:    newTemplate(),
+		Feeder:      feeder.New(rows, []string{"id"}, feeder.StrategyUnique, feeder.ExhaustedStop, 1),
+	}
+	report := gen.GenerateRequests(context.Background(), cfg)
+
+	if report.WarmupCount != 2 || report.Count != 3 || report.FeederExhausted {
+		t.Errorf("expected 2 warm-up and 3 measured requests, got %d and %d (exhausted=%v)", report.WarmupCount, report.Count, report.FeederExhausted)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(paths) != 5 {
+		t.Fatalf("expected 5 requests, got %v", paths)
+	}
+	ids := map[string]bool{}
+	for i, p := range paths {
+		parts := strings.Split(p, "/")
+		ids[parts[2]] = true
+		if parts[3] != strconv.Itoa(i) {
+			t.Errorf("expected request %d to have sequence number %d, got %s", i, i, p)
+		}
+	}
+	if len(ids) != 5 {
+		t.Errorf("expected every row to be sent once, got %v", paths)
+	}
+
+	// Without a warm-up, the measured run renders the same random values
+	// from its first request on; the warm-up must not have sent them.
+	replay := newTemplate()
+	for i := 0; i < 2; i++ {
+		u, _, _ := replay.Render(feeder.Row{"id": "x"})
+		want := strings.Split(u, "/")[6]
+		if got := strings.Split(paths[i], "/")[4]; got == want {
+			t.Errorf("expected warm-up request %d to draw random values of its own, got %s", i, paths[i])
+		}
+	}
+}
+
+// journeyServer serves a small log-in / create / fetch journey. Orders can
+// only be created with the token and session cookie handed out at log-in.
+func journeyServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /login", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "SESSION", Value: "s-1"})
+		w.Write([]byte(`{"token":"t-1"}`))
+	})
+	mux.HandleFunc("POST /orders", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer t-1" || r.Header.Get("Cookie") != "SESSION=s-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Location", "/orders/7")
+		w.WriteHeader(http.StatusCreated)
+	})
+	mux.HandleFunc("GET /orders/7", func(w http.ResponseWriter, r *http.Request) {})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// newStep compiles a scenario step.
+func newStep(t *testing.T, name, method, url string, headers map[string]string, extractors ...extract.Extractor) generator.Step {
+	t.Helper()
+	tmpl, err := templating.New(1).Compile(url, headers, nil)
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	return generator.Step{Name: name, Method: method, URL: url, Headers: headers, Template: tmpl, Extract: extractors}
+}
+
+// newExtractor builds an extractor, failing the test on a bad expression.
+func newExtractor(t *testing.T, name, source, expr string) extract.Extractor {
+	t.Helper()
+	x, err := extract.New(name, source, expr)
+	if err != nil {
+		t.Fatalf("extract.New: %v", err)
+	}
+	return x
+}
+
+// TestGenerateRequests_Scenario verifies that a scenario runs its steps in
+// order, passing extracted values on to later steps, and reports both whole
+// iterations and each step.
+func TestGenerateRequests_Scenario(t *testing.T) {
+	srv := journeyServer(t)
+	gen := generator.NewGenerator(httpclient.NewClient(5*time.Second, false, true, 2))
+
+	cfg := generator.RequestConfig{
+		Name:        "checkout",
+		Count:       5,
+		Concurrency: 2,
+		Steps: []generator.Step{
+			newStep(t, "log in", "POST", srv.URL+"/login", nil,
+				newExtractor(t, "token", extract.SourceJSONPath, "$.token"),
+				newExtractor(t, "session", extract.SourceCookie, "SESSION")),
+			newStep(t, "create order", "POST", srv.URL+"/orders",
+				map[string]string{"Authorization": "Bearer {{.token}}", "Cookie": "SESSION={{.session}}"},
+				newExtractor(t, "order", extract.SourceHeader, "Location")),
+			newStep(t, "fetch order", "GET", srv.URL+"{{.order}}", nil),
+		},
+	}
+	report := gen.GenerateRequests(context.Background(), cfg)
+
+	if report.Name != "checkout" || report.Count != 5 || report.SuccessRate != 100 {
+		t.Errorf("expected 5 successful iterations of checkout, got %d of %q at %.2f%%", report.Count, report.Name, report.SuccessRate)
+	}
+	if len(report.Steps) != 3 {
+		t.Fatalf("expected 3 steps, got %d", len(report.Steps))
+	}
+	for i, want := range []int{200, 201, 200} {
+		st := report.Steps[i]
+		if st.Count != 5 || st.SuccessRate != 100 || st.StatusCodes[want] != 5 {
+			t.Errorf("step %q: expected 5 successful %d responses, got %d at %.2f%% (%v)", st.Name, want, st.Count, st.SuccessRate, st.StatusCodes)
+		}
+	}
+	// An iteration spans all three requests (with some slack for histogram
+	// rounding).
+	var steps float64
+	for _, st := range report.Steps {
+		steps += st.AverageResponse
+	}
+	if report.AverageResponse < 0.99*steps {
+		t.Errorf("expected an iteration (avg %.6fs) to take as long as its steps together (%.6fs)", report.AverageResponse, steps)
+	}
+}
+
+// TestGenerateRequests_ScenarioStopsOnFailure verifies that an iteration ends
+// at a step whose value cannot be extracted, and that it counts as failed.
+func TestGenerateRequests_ScenarioStopsOnFailure(t *testing.T) {
+	srv := journeyServer(t)
+	gen := generator.NewGenerator(httpclient.NewClient(5*time.Second, false, true, 2))
+
+	cfg := generator.RequestConfig{
+		Count:       4,
+		Concurrency: 2,
+		Steps: []generator.Step{
+			newStep(t, "log in", "POST", srv.URL+"/login", nil,
+				newExtractor(t, "token", extract.SourceJSONPath, "$.access_token")),
+			newStep(t, "create order", "POST", srv.URL+"/orders", map[string]string{"Authorization": "Bearer {{.token}}"}),
+		},
+	}
+	report := gen.GenerateRequests(context.Background(), cfg)
+
+	if report.Count != 4 || report.SuccessCount != 0 || report.StatusCodes[200] != 4 {
+		t.Errorf("expected 4 failed iterations ending on a 200, got %d with %d successes (%v)", report.Count, report.SuccessCount, report.StatusCodes)
+	}
+	login, create := report.Steps[0], report.Steps[1]
+	if login.Count != 4 || login.ExtractionFailures != 4 || login.SuccessRate != 0 {
+		t.Errorf("expected 4 extraction failures at log-in, got %d of %d (%.2f%%)", login.ExtractionFailures, login.Count, login.SuccessRate)
+	}
+	if create.Count != 0 {
+		t.Errorf("expected no iteration to reach the second step, got %d", create.Count)
+	}
+}
+
+// newCheck compiles a check, failing the test on a bad spec.
+func newCheck(t *testing.T, spec check.Spec) *check.Check {
+	t.Helper()
+	c, err := check.New(spec)
+	if err != nil {
+		t.Fatalf("check.New: %v", err)
+	}
+	return c
+}
+
+// TestGenerateRequests_Checks verifies that responses failing a check are
+// counted per check and kept out of the success rate.
+func TestGenerateRequests_Checks(t *testing.T) {
+	var mu sync.Mutex
+	n := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		n++
+		down := n%4 == 0
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if down {
+			w.Write([]byte(`{"state":"down"}`))
+			return
+		}
+		w.Write([]byte(`{"state":"active"}`))
+	}))
+	defer srv.Close()
+	gen := generator.NewGenerator(httpclient.NewClient(5*time.Second, false, true, 1))
+
+	cfg := generator.RequestConfig{
+		Method:      "GET",
+		URL:         srv.URL,
+		Count:       8,
+		Concurrency: 1,
+		Checks: []*check.Check{
+			newCheck(t, check.Spec{Name: "active", JSONPath: "$.state", Equals: "active"}),
+			newCheck(t, check.Spec{Header: "Content-Type", Matches: "json"}),
+			newCheck(t, check.Spec{BodyContains: "active"}),
+			newCheck(t, check.Spec{MaxBodySize: 64}),
+		},
+	}
+	report := gen.GenerateRequests(context.Background(), cfg)
+
+	if report.StatusCodes[200] != 8 || report.SuccessCount != 6 || report.SuccessRate != 75 {
+		t.Errorf("expected 6 of 8 200s to succeed, got %d (%.2f%%)", report.SuccessCount, report.SuccessRate)
+	}
+	want := map[string]int{"active": 2, `body contains "active"`: 2}
+	if len(report.CheckFailures) != len(want) {
+		t.Errorf("expected check failures %v, got %v", want, report.CheckFailures)
+	}
+	for name, count := range want {
+		if report.CheckFailures[name] != count {
+			t.Errorf("expected %d failures of %q, got %d", count, name, report.CheckFailures[name])
+		}
+	}
+}
+
+// TestGenerateRequests_StatusCheck verifies that a status check replaces the
+// default 2xx rule, so an expected 404 is a success.
+func TestGenerateRequests_StatusCheck(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	gen := generator.NewGenerator(httpclient.NewClient(5*time.Second, false, true, 1))
+
+	cfg := generator.RequestConfig{
+		Method:      "GET",
+		URL:         srv.URL,
+		Count:       3,
+		Concurrency: 1,
+		Checks:      []*check.Check{newCheck(t, check.Spec{Status: []int{404, 410}})},
+	}
+	if report := gen.GenerateRequests(context.Background(), cfg); report.SuccessCount != 3 || len(report.CheckFailures) != 0 {
+		t.Errorf("expected 3 successful 404s, got %d (%v)", report.SuccessCount, report.CheckFailures)
+	}
+
+	cfg.Checks = []*check.Check{newCheck(t, check.Spec{Status: []int{200}})}
+	if report := gen.GenerateRequests(context.Background(), cfg); report.SuccessCount != 0 || report.CheckFailures["status 200"] != 3 {
+		t.Errorf("expected 3 failures of status 200, got %d successes (%v)", report.SuccessCount, report.CheckFailures)
+	}
+}
+
+// TestGenerateRequests_Mix verifies that a traffic mix splits the requests
+// between its endpoints by weight and reports each of them.
+func TestGenerateRequests_Mix(t *testing.T) {
+	var mu sync.Mutex
+	hits := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.Method+" "+r.URL.Path]++
+		mu.Unlock()
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	gen := generator.NewGenerator(httpclient.NewClient(5*time.Second, false, true, 4))
+
+	cfg := generator.RequestConfig{
+		Name:        "mix",
+		Count:       100,
+		Concurrency: 4,
+		Mix: []generator.RequestConfig{
+			{Name: "items", Method: "GET", URL: srv.URL + "/items", Weight: 7},
+			{Method: "POST", URL: srv.URL + "/orders", Weight: 2},
+			{Method: "GET", URL: srv.URL + "/missing", Weight: 1},
+		},
+	}
+	report := gen.GenerateRequests(context.Background(), cfg)
+
+	if report.Count != 100 || report.SuccessCount != 90 || report.StatusCodes[404] != 10 {
+		t.Errorf("expected 90 of 100 requests to succeed and 10 404s, got %d of %d (%v)", report.SuccessCount, report.Count, report.StatusCodes)
+	}
+	if len(report.Endpoints) != 3 {
+		t.Fatalf("expected 3 endpoints, got %d", len(report.Endpoints))
+	}
+	for i, want := range []struct {
+		name, hit string
+		count     int
+	}{{"items", "GET /items", 70}, {"", "POST /orders", 20}, {"", "GET /missing", 10}} {
+		ep := report.Endpoints[i]
+		if ep.Name != want.name || ep.Weight != cfg.Mix[i].Weight || ep.URL != cfg.Mix[i].URL {
+			t.Errorf("endpoint %d: unexpected labels %q %s weight %d", i, ep.Name, ep.URL, ep.Weight)
+		}
+		if ep.Count != want.count || hits[want.hit] != want.count {
+			t.Errorf("endpoint %d: expected %d requests, got %d (server saw %d)", i, want.count, ep.Count, hits[want.hit])
+		}
+	}
+	if report.Endpoints[0].SuccessRate != 100 || report.Endpoints[2].SuccessRate != 0 {
+		t.Errorf("expected per-endpoint success rates 100 and 0, got %.2f and %.2f", report.Endpoints[0].SuccessRate, report.Endpoints[2].SuccessRate)
+	}
+}
+
+// TestGenerateRequests_Abort verifies that Abort sees the rolling window once
+// it is full and that a reason stops the run early, keeping its report.
+func TestGenerateRequests_Abort(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	gen := generator.NewGenerator(httpclient.NewClient(5*time.Second, false, true, 2))
+
+	var mu sync.Mutex
+	var windows []generator.GeneratorReport
+	cfg := generator.RequestConfig{
+		Method:      "GET",
+		URL:         srv.URL,
+		Duration:    10 * time.Second,
+		Rate:        100,
+		Concurrency: 2,
+		AbortWindow: 300 * time.Millisecond,
+		Abort: func(w generator.GeneratorReport) string {
+			mu.Lock()
+			defer mu.Unlock()
+			windows = append(windows, w)
+			if w.SuccessRate < 90 {
+				return "success below 90%"
+			}
+			return ""
+		},
+	}
+	start := time.Now()
+	report := gen.GenerateRequests(context.Background(), cfg)
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected the run to stop soon after the first full window, took %s", elapsed)
+	}
+	if report.AbortReason != "success below 90%" {
+		t.Errorf("expected the abort reason in the report, got %q", report.AbortReason)
+	}
+	if report.Count == 0 || report.StatusCodes[503] != report.Count {
+		t.Errorf("expected a partial report of 503s, got %d requests (%v)", report.Count, report.StatusCodes)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(windows) != 1 {
+		t.Fatalf("expected Abort to be called once, got %d", len(windows))
+	}
+	if w := windows[0]; w.TotalDuration != cfg.AbortWindow || w.Count < 15 || w.Count > 40 {
+		t.Errorf("expected a 300ms window of about 30 requests, got %s with %d", w.TotalDuration, w.Count)
+	}
+}
+
+// TestGenerateRequests_AbortNotBreached verifies that a run whose window stays
+// within bounds runs to the end.
+func TestGenerateRequests_AbortNotBreached(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	gen := generator.NewGenerator(httpclient.NewClient(5*time.Second, false, true, 2))
+
+	calls := 0
+	cfg := generator.RequestConfig{
+		Method:      "GET",
+		URL:         srv.URL,
+		Duration:    500 * time.Millisecond,
+		Rate:        50,
+		Concurrency: 2,
+		AbortWindow: 100 * time.Millisecond,
+		Abort: func(w generator.GeneratorReport) string {
+			calls++
+			if w.ErrorCount > 0 {
+				return "errors"
+			}
+			return ""
+		},
+	}
+	report := gen.GenerateRequests(context.Background(), cfg)
+	if report.AbortReason != "" {
+		t.Errorf("expected no abort, got %q", report.AbortReason)
+	}
+	// 25 requests are due; a slow scheduler may miss a tick or two.
+	if report.Count < 20 || report.Count > 25 {
+		t.Errorf("expected the run to go on to about 25 requests, got %d", report.Count)
+	}
+	if calls < 10 {
+		t.Errorf("expected Abort to be called every 10ms once the window was full, got %d calls", calls)
+	}
+}
+
+// TestGenerateRequests_Results verifies that every measured request, but no
+// warm-up request, is written to the results log with its rendered URL,
+// status and timings.
+func TestGenerateRequests_Results(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/items/3" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	gen := generator.NewGenerator(httpclient.NewClient(5*time.Second, false, true, 2))
+
+	url := srv.URL + "/items/{{seq}}"
+	tmpl, err := templating.New(1).Compile(url, nil, nil)
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	var buf bytes.Buffer
+	w := results.NewWriter(&buf)
+	gen.GenerateRequests(context.Background(), generator.RequestConfig{
+		Name:        "items",
+		Method:      "GET",
+		URL:         url,
+		Count:       5,
+		Concurrency: 1,
+		WarmupCount: 2,
+		Template:    tmpl,
+		Results:     w,
+	})
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	var recs []results.Record
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var r results.Record
+		if err := dec.Decode(&r); err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		recs = append(recs, r)
+	}
+	if len(recs) != 5 {
+		t.Fatalf("expected 5 records (warm-up excluded), got %d", len(recs))
+	}
+	for i, r := range recs {
+		// The warm-up rendered /items/0 and /items/1.
+		wantURL := fmt.Sprintf("%s/items/%d", srv.URL, i+2)
+		if r.Endpoint != "items" || r.Method != "GET" || r.URL != wantURL {
+			t.Errorf("record %d: got endpoint %q, %s %s, want items, GET %s", i, r.Endpoint, r.Method, r.URL, wantURL)
+		}
+		if r.LatencySec <= 0 || r.Timestamp.IsZero() || !r.Intended.Equal(r.Timestamp) {
+			t.Errorf("record %d: expected a latency and an intended start equal to the timestamp, got %+v", i, r)
+		}
+	}
+	if recs[1].Status != 404 || recs[1].Success || recs[0].Status != 200 || !recs[0].Success {
+		t.Errorf("expected /items/2 to succeed and /items/3 to fail with 404, got %+v and %+v", recs[0], recs[1])
+	}
+}
+
+// TestAnalyzer verifies that a report rebuilt from the results log of a run
+// matches the report of the run itself.
+func TestAnalyzer(t *testing.T) {
+	var mu sync.Mutex
+	n := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		mu.Lock()
+		n++
+		fail := n%5 == 0
+		mu.Unlock()
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+	gen := generator.NewGenerator(httpclient.NewClient(5*time.Second, false, true, 4))
+
+	var buf bytes.Buffer
+	w := results.NewWriter(&buf)
+	run := gen.GenerateRequests(context.Background(), generator.RequestConfig{
+		Name: "api", Method: "GET", URL: srv.URL, Count: 50, Concurrency: 4, Results: w,
+	})
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	a := generator.NewAnalyzer("api", 0, 0)
+	if err := results.Scan(&buf, results.Filter{}, a.Add); err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	got := a.Report()
+	if got.Name != "api" || got.URL != srv.URL || got.Method != "GET" {
+		t.Errorf("expected api GET %s, got %s %s %s", srv.URL, got.Name, got.Method, got.URL)
+	}
+	if got.Count != run.Count || got.SuccessCount != run.SuccessCount || got.TotalBytes != run.TotalBytes {
+		t.Errorf("expected count %d, successes %d and %d bytes, got %d, %d and %d",
+			run.Count, run.SuccessCount, run.TotalBytes, got.Count, got.SuccessCount, got.TotalBytes)
+	}
+	if got.StatusCodes[200] != 40 || got.StatusCodes[503] != 10 {
+		t.Errorf("expected 40 200s and 10 503s, got %v", got.StatusCodes)
+	}
+	if got.P99Response != run.P99Response || got.MaxResponse != run.MaxResponse {
+		t.Errorf("expected p99 %f and max %f, got %f and %f", run.P99Response, run.MaxResponse, got.P99Response, got.MaxResponse)
+	}
+	if len(got.Timeseries) == 0 || len(got.Histogram) == 0 {
+		t.Errorf("expected a timeseries and a histogram, got %d windows and %d buckets", len(got.Timeseries), len(got.Histogram))
+	}
+}
@@ -1,46 +1,81 @@
 package generator
 
 import (
+	"math"
 	"testing"
 	"time"
+
+	"github.com/idesyatov/http-runner/pkg/histogram"
 )
 
 // TestHistogram covers bucketing: empty input, an all-equal degenerate range,
 // and a normal spread across equal-width buckets.
 func TestHistogram(t *testing.T) {
+	h := histogram.New(lowestLatency, highestLatency, DefaultPrecision)
+
 	// Empty input yields no buckets.
-	if got := histogram(nil, 10); got != nil {
+	if got := buckets(h, 10); got != nil {
 		t.Errorf("expected nil for empty input, got %v", got)
 	}
 
 	// All samples equal → a single bucket holding everything.
-	equal := []time.Duration{5 * time.Millisecond, 5 * time.Millisecond, 5 * time.Millisecond}
-	single := histogram(equal, 10)
+	for i := 0; i < 3; i++ {
+		h.Record(int64(5 * time.Millisecond))
+	}
+	single := buckets(h, 10)
 	if len(single) != 1 || single[0].Count != 3 {
 		t.Fatalf("expected one bucket with count 3, got %v", single)
 	}
 
 	// A 0..9ms spread into 10 buckets (width 0.9ms): all counts sum to the input
-	// size and the min/max land in the first/last buckets.
-	var spread []time.Duration
+	// size, the min/max land in the first/last buckets, and bounds are seconds.
+	h.Reset()
 	for i := 0; i < 10; i++ {
-		spread = append(spread, time.Duration(i)*time.Millisecond)
+		h.Record(int64(time.Duration(i) * time.Millisecond))
 	}
-	buckets := histogram(spread, 10)
-	if len(buckets) != 10 {
-		t.Fatalf("expected 10 buckets, got %d", len(buckets))
+	spread := buckets(h, 10)
+	if len(spread) != 10 {
+		t.Fatalf("expected 10 buckets, got %d", len(spread))
 	}
 	total := 0
-	for _, b := range buckets {
+	for _, b := range spread {
 		total += b.Count
 	}
-	if total != len(spread) {
-		t.Errorf("expected bucket counts to sum to %d, got %d", len(spread), total)
+	if total != 10 {
+		t.Errorf("expected bucket counts to sum to 10, got %d", total)
 	}
-	if buckets[0].Count == 0 {
+	if spread[0].Count == 0 {
 		t.Errorf("expected the minimum sample in the first bucket")
 	}
-	if buckets[len(buckets)-1].Count == 0 {
+	if spread[len(spread)-1].Count == 0 {
 		t.Errorf("expected the maximum sample in the last bucket")
 	}
+	if spread[0].Start != 0 || math.Abs(spread[9].End-0.009) > 1e-12 {
+		t.Errorf("expected buckets to span [0, 0.009]s, got [%v, %v]", spread[0].Start, spread[9].End)
+	}
+}
+
+// TestStatsPercentiles checks that stats report percentiles from the
+// histogram within its precision, and min/max/mean exactly.
+func TestStatsPercentiles(t *testing.T) {
+	s := newStats(0)
+	for i := 1; i <= 1000; i++ {
+		s.add(result{latency: time.Duration(i) * time.Millisecond, status: 200})
+	}
+	var rep GeneratorReport
+	s.fill(&rep, time.Second)
+
+	if rep.MinResponse != 0.001 || rep.MaxResponse != 1 {
+		t.Errorf("expected exact min/max 0.001/1, got %v/%v", rep.MinResponse, rep.MaxResponse)
+	}
+	if math.Abs(rep.AverageResponse-0.5005) > 1e-9 {
+		t.Errorf("expected exact mean 0.5005, got %v", rep.AverageResponse)
+	}
+	for _, c := range []struct {
+		got, want float64
+	}{{rep.P50Response, 0.5}, {rep.P90Response, 0.9}, {rep.P99Response, 0.99}} {
+		if math.Abs(c.got-c.want)/c.want > 1e-3 {
+			t.Errorf("expected %v within 0.1%%, got %v", c.want, c.got)
+		}
+	}
 }
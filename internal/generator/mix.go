@@ -0,0 +1,34 @@
+package generator
+
+// mixPicker chooses the target of each request of a traffic mix in proportion
+// to the targets' weights. It uses smooth weighted round-robin, so the targets
+// interleave evenly instead of coming in runs: weights 5, 1, 1 give a a b a c a a
+// rather than a a a a a b c. It is not safe for concurrent use.
+type mixPicker struct {
+	weights []int
+	current []int
+	total   int
+}
+
+// newMixPicker returns a picker over mix. A weight below 1 counts as 1.
+func newMixPicker(mix []RequestConfig) *mixPicker {
+	p := &mixPicker{weights: make([]int, len(mix)), current: make([]int, len(mix))}
+	for i, t := range mix {
+		p.weights[i] = max(t.Weight, 1)
+		p.total += p.weights[i]
+	}
+	return p
+}
+
+// next returns the index of the target of the next request.
+func (p *mixPicker) next() int {
+	best := 0
+	for i, w := range p.weights {
+		p.current[i] += w
+		if p.current[i] > p.current[best] {
+			best = i
+		}
+	}
+	p.current[best] -= p.total
+	return best
+}
@@ -0,0 +1,20 @@
+package generator
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestMixPicker checks that targets are picked in proportion to their weights
+// and interleaved rather than in runs.
+func TestMixPicker(t *testing.T) {
+	p := newMixPicker([]RequestConfig{{Weight: 5}, {Weight: 1}, {Weight: 0}})
+	var got []int
+	for range 14 {
+		got = append(got, p.next())
+	}
+	round := []int{0, 0, 1, 0, 2, 0, 0}
+	if want := append(slices.Clone(round), round...); !slices.Equal(got, want) {
+		t.Errorf("expected picks %v, got %v", want, got)
+	}
+}
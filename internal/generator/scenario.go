@@ -0,0 +1,104 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/idesyatov/http-runner/internal/check"
+	"github.com/idesyatov/http-runner/internal/extract"
+	"github.com/idesyatov/http-runner/internal/feeder"
+	"github.com/idesyatov/http-runner/internal/templating"
+	"github.com/idesyatov/http-runner/pkg/httpclient"
+)
+
+// Step is one request of a scenario. Its templates see the iteration's feeder
+// row and every value extracted by the steps before it.
+type Step struct {
+	Name     string              // Label in the report
+	Method   string              // The HTTP method to use
+	URL      string              // The URL to send the request to
+	Headers  map[string]string   // Headers to include in the request
+	Data     interface{}         // Data to include in the request body (arbitrary JSON)
+	Template *templating.Request // If set, renders the URL, headers and data (replacing the fields above)
+	Extract  []extract.Extractor // Values to take from the response into variables
+	Checks   []*check.Check      // Assertions the response must pass to count as a success
+}
+
+// StepReport holds the metrics of one step of a scenario across all
+// iterations that reached it.
+type StepReport struct {
+	Name               string         // Label of the step
+	Method             string         // The HTTP method used
+	URL                string         // The URL (template) requested
+	Count              int            // Requests sent, one per iteration that reached the step
+	RequestsPerSec     float64        // Requests per second over the run
+	SuccessRate        float64        // Percentage of requests that succeeded (passed their checks and extracted every value)
+	ErrorCount         int            // Transport errors
+	ExtractionFailures int            // Responses from which a value could not be extracted
+	CheckFailures      map[string]int // Check name -> responses that failed it
+	StatusCodes        map[int]int    // Status code -> count
+	AverageResponse    float64        // Average response time
+	P50Response        float64        // 50th percentile response time
+	P95Response        float64        // 95th percentile response time
+	P99Response        float64        // 99th percentile response time
+	MaxResponse        float64        // Maximum response time
+}
+
+// iterate runs one iteration of a scenario: every step in order, rendered with
+// the feeder row and the values extracted so far. It stops at the first step
+// that does not succeed, since the steps after it usually depend on it.
+// record is called with the result of each step sent.
+//
+// The returned result describes the iteration as a whole: the status, error
+// and outcome of the step it ended on, the bytes of every step, and the
+// connection phases summed over the steps. Its latency is left to the caller.
+func (g *Generator) iterate(cfg RequestConfig, row feeder.Row, record func(step int, res result)) result {
+	vars := make(map[string]string, len(row))
+	for k, v := range row {
+		vars[k] = v
+	}
+	var it result
+	it.trace = &httpclient.Trace{Reused: true}
+	for i, st := range cfg.Steps {
+		url, headers, data := st.URL, st.Headers, st.Data
+		if st.Template != nil {
+			url, headers, data = st.Template.Render(vars)
+		}
+		needsBody := false
+		for _, x := range st.Extract {
+			needsBody = needsBody || x.NeedsBody()
+		}
+		res, resp, body := g.exchange(st.Method, url, headers, data, st.Checks, needsBody, cfg.Verbose)
+		if res.err == nil {
+			for _, x := range st.Extract {
+				v, ok := x.Extract(resp, body)
+				if !ok {
+					res.extractFailed = true
+					if cfg.Verbose {
+						fmt.Printf("Extraction failed: %s (%s %s) in step %q\n", x.Name, x.Source, x.Expr, st.Name)
+					}
+					break
+				}
+				vars[x.Name] = v
+			}
+		}
+		record(i, res)
+
+		it.status, it.err = res.status, res.err
+		it.checkFailures, it.statusExpected, it.extractFailed = res.checkFailures, res.statusExpected, res.extractFailed
+		it.bytes += res.bytes
+		if t := res.trace; t != nil {
+			it.trace.DNS += t.DNS
+			it.trace.Connect += t.Connect
+			it.trace.TLS += t.TLS
+			it.trace.TTFB += t.TTFB
+			it.trace.Reused = it.trace.Reused && t.Reused
+		}
+		if !res.ok() {
+			break
+		}
+	}
+	if it.err != nil {
+		it.trace = nil
+	}
+	return it
+}
@@ -0,0 +1,219 @@
+package generator
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Stage is one step of a staged load profile. The target rate ramps linearly
+// from the previous stage's Rate (the run's Rate for the first stage) to this
+// stage's Rate over Duration, so equal consecutive rates hold a plateau.
+type Stage struct {
+	Duration    time.Duration // How long the stage lasts
+	Rate        int           // Target requests per second reached at the end of the stage
+	Concurrency int           // Closed executor: concurrency from the start of the stage (0 = keep the previous value)
+}
+
+// segment is a stage placed on the run's timeline.
+type segment struct {
+	start    time.Duration // Offset of the stage from the start of the run
+	dur      time.Duration // Length of the stage
+	from, to float64       // Rate at the start and end of the stage (req/s)
+	before   float64       // Requests scheduled before the stage begins
+}
+
+// schedule maps the n-th request of a run to its start offset. A flat rate
+// spaces requests evenly; stages integrate a piecewise-linear rate so the
+// number of requests started by time t always matches the area under the
+// rate curve, however steep the ramp.
+type schedule struct {
+	rate     float64       // Flat rate when there are no stages (req/s)
+	segments []segment     // Stages on the timeline; nil for a flat rate
+	end      time.Duration // Offset at which the run stops (0 = bounded by count only)
+	rated    bool          // Whether requests follow the schedule at all
+}
+
+// newSchedule builds the schedule for cfg. A run without a rate in either the
+// config or its stages is unrated: requests start as fast as slots allow and
+// only the stage boundaries matter.
+func newSchedule(cfg RequestConfig) *schedule {
+	s := &schedule{rate: float64(cfg.Rate), end: cfg.Duration, rated: cfg.Rate > 0}
+	if len(cfg.Stages) == 0 {
+		return s
+	}
+	var offset time.Duration
+	var before float64
+	from := float64(cfg.Rate)
+	for _, st := range cfg.Stages {
+		to := float64(st.Rate)
+		s.segments = append(s.segments, segment{start: offset, dur: st.Duration, from: from, to: to, before: before})
+		before += (from + to) / 2 * st.Duration.Seconds()
+		offset += st.Duration
+		from = to
+		if st.Rate > 0 {
+			s.rated = true
+		}
+	}
+	s.end = offset
+	return s
+}
+
+// at returns the start offset of the n-th request (0-based). It reports false
+// when the request falls past the end of the run.
+func (s *schedule) at(n int) (time.Duration, bool) {
+	k := float64(n)
+	if s.segments == nil {
+		off := time.Duration(k / s.rate * float64(time.Second))
+		return off, s.end <= 0 || off < s.end
+	}
+	for _, seg := range s.segments {
+		total := (seg.from + seg.to) / 2 * seg.dur.Seconds()
+		if k >= seg.before+total {
+			continue
+		}
+		// Requests started t seconds into the segment: from*t + a*t²/2.
+		// Solve for the remaining count; a zero slope is a plateau.
+		rem := k - seg.before
+		a := (seg.to - seg.from) / seg.dur.Seconds()
+		var t float64
+		if math.Abs(a) < 1e-9 {
+			t = rem / seg.from
+		} else {
+			t = (-seg.from + math.Sqrt(math.Max(0, seg.from*seg.from+2*a*rem))) / a
+		}
+		return seg.start + time.Duration(t*float64(time.Second)), true
+	}
+	return 0, false
+}
+
+// next returns the index of the first request scheduled at or after off. Past
+// the end of the run the index is one that at reports false for.
+func (s *schedule) next(off time.Duration) int {
+	// Requests scheduled before off, from the area under the rate curve;
+	// floating-point error is corrected against at below.
+	var k float64
+	if s.segments == nil {
+		k = off.Seconds() * s.rate
+	} else {
+		last := s.segments[len(s.segments)-1]
+		k = last.before + (last.from+last.to)/2*last.dur.Seconds()
+		for _, seg := range s.segments {
+			if off < seg.start+seg.dur {
+				t := (off - seg.start).Seconds()
+				a := (seg.to - seg.from) / seg.dur.Seconds()
+				k = seg.before + seg.from*t + a*t*t/2
+				break
+			}
+		}
+	}
+	n := max(int(math.Floor(k))-1, 0)
+	for {
+		at, ok := s.at(n)
+		if !ok || at >= off {
+			return n
+		}
+		n++
+	}
+}
+
+// tickAt returns when the closed executor's k-th tick (1-based) fires: at the
+// start of the k-th request's slot on the schedule, or at the end of the run
+// for the tick that closes its last interval.
+func tickAt(s *schedule, k int) time.Duration {
+	if off, ok := s.at(k); ok {
+		return off
+	}
+	return s.end
+}
+
+// stageAt returns the index of the stage running at offset off, or -1 for a
+// run without stages. Offsets past the end belong to the last stage.
+func (s *schedule) stageAt(off time.Duration) int {
+	if s.segments == nil {
+		return -1
+	}
+	for i, seg := range s.segments {
+		if off < seg.start+seg.dur {
+			return i
+		}
+	}
+	return len(s.segments) - 1
+}
+
+// limiter caps the number of requests in flight. Unlike a buffered channel its
+// limit can change while the run is going, which staged runs need.
+type limiter struct {
+	mu      sync.Mutex
+	limit   int           // Maximum in flight; <= 0 means unlimited
+	inUse   int           // Slots currently held
+	freed   chan struct{} // Closed (and replaced) when a slot frees or the limit grows
+	waiting bool          // Whether anyone is blocked on freed
+}
+
+func newLimiter(limit int) *limiter {
+	return &limiter{limit: limit, freed: make(chan struct{})}
+}
+
+// tryAcquire takes a slot if one is free, without waiting.
+func (l *limiter) tryAcquire() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.limit > 0 && l.inUse >= l.limit {
+		return false
+	}
+	l.inUse++
+	return true
+}
+
+// acquire waits for a free slot. It returns false if ctx is done first; an
+// already-cancelled ctx wins even when a slot is free.
+func (l *limiter) acquire(ctx context.Context) bool {
+	for {
+		if ctx.Err() != nil {
+			return false
+		}
+		l.mu.Lock()
+		if l.limit <= 0 || l.inUse < l.limit {
+			l.inUse++
+			l.mu.Unlock()
+			return true
+		}
+		freed := l.freed
+		l.waiting = true
+		l.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return false
+		case <-freed:
+		}
+	}
+}
+
+// release returns a slot taken by acquire or tryAcquire.
+func (l *limiter) release() {
+	l.mu.Lock()
+	l.inUse--
+	l.wake()
+	l.mu.Unlock()
+}
+
+// setLimit changes the limit. Lowering it never interrupts requests already in
+// flight; it only delays new ones until enough have finished.
+func (l *limiter) setLimit(limit int) {
+	l.mu.Lock()
+	l.limit = limit
+	l.wake()
+	l.mu.Unlock()
+}
+
+// wake unblocks waiters so they re-check the limit. Callers hold mu.
+func (l *limiter) wake() {
+	if !l.waiting {
+		return
+	}
+	close(l.freed)
+	l.freed = make(chan struct{})
+	l.waiting = false
+}
@@ -0,0 +1,140 @@
+package generator
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+// TestSchedule_FlatRate checks even spacing and the duration cut-off.
+func TestSchedule_FlatRate(t *testing.T) {
+	s := newSchedule(RequestConfig{Rate: 10, Duration: time.Second})
+	if off, ok := s.at(0); !ok || off != 0 {
+		t.Errorf("expected the first request at 0, got %s (ok=%v)", off, ok)
+	}
+	if off, ok := s.at(3); !ok || off != 300*time.Millisecond {
+		t.Errorf("expected the 4th request at 300ms, got %s (ok=%v)", off, ok)
+	}
+	if _, ok := s.at(10); ok {
+		t.Errorf("expected the 11th request to fall past the 1s duration")
+	}
+	if s.stageAt(0) != -1 {
+		t.Errorf("expected no stage for a flat run, got %d", s.stageAt(0))
+	}
+}
+
+// TestSchedule_Stages checks that a ramp starts requests in proportion to the
+// area under the rate curve: 0→100 req/s over 1s is 50 requests, then a 1s
+// plateau at 100 req/s adds 100 more.
+func TestSchedule_Stages(t *testing.T) {
+	s := newSchedule(RequestConfig{Stages: []Stage{
+		{Duration: time.Second, Rate: 100},
+		{Duration: time.Second, Rate: 100},
+	}})
+	if !s.rated || s.end != 2*time.Second {
+		t.Fatalf("expected a rated 2s schedule, got rated=%v end=%s", s.rated, s.end)
+	}
+
+	// N(t) = 50t² during the ramp: request 50 closes it at 1s and request 25
+	// starts at √0.5 s.
+	off, ok := s.at(50)
+	if !ok || math.Abs(off.Seconds()-1) > 1e-6 {
+		t.Errorf("expected request 50 at the end of the ramp (1s), got %s", off)
+	}
+	off, _ = s.at(25)
+	if want := math.Sqrt(0.5); math.Abs(off.Seconds()-want) > 1e-6 {
+		t.Errorf("expected request 25 at %.4fs, got %s", want, off)
+	}
+	off, ok = s.at(149)
+	if !ok || off < time.Second || off >= 2*time.Second {
+		t.Errorf("expected request 149 on the plateau, got %s (ok=%v)", off, ok)
+	}
+	if _, ok := s.at(150); ok {
+		t.Errorf("expected request 150 to fall past the last stage")
+	}
+
+	if s.stageAt(500*time.Millisecond) != 0 || s.stageAt(1500*time.Millisecond) != 1 || s.stageAt(3*time.Second) != 1 {
+		t.Errorf("stageAt mapped offsets to the wrong stages")
+	}
+}
+
+// TestSchedule_Next checks that next finds the first request at or after an
+// offset, on a flat rate and on a ramp, and runs past the end of the run.
+func TestSchedule_Next(t *testing.T) {
+	flat := newSchedule(RequestConfig{Rate: 10, Duration: time.Second})
+	for _, c := range []struct {
+		off  time.Duration
+		want int
+	}{{0, 0}, {300 * time.Millisecond, 3}, {301 * time.Millisecond, 4}, {time.Second, 10}} {
+		if got := flat.next(c.off); got != c.want {
+			t.Errorf("flat: expected next(%s) = %d, got %d", c.off, c.want, got)
+		}
+	}
+	if _, ok := flat.at(flat.next(time.Second)); ok {
+		t.Errorf("expected next past the end to fall outside the run")
+	}
+
+	ramp := newSchedule(RequestConfig{Stages: []Stage{{Duration: time.Second, Rate: 100}}})
+	for _, off := range []time.Duration{100 * time.Millisecond, 500 * time.Millisecond, 900 * time.Millisecond} {
+		n := ramp.next(off)
+		at, _ := ramp.at(n)
+		before, _ := ramp.at(n - 1)
+		if at < off || before >= off {
+			t.Errorf("ramp: expected request %d to be the first at or after %s, it starts at %s after %s", n, off, at, before)
+		}
+	}
+}
+
+// TestSchedule_UnratedStages checks that concurrency-only stages still bound
+// the run and map offsets to stages without scheduling requests.
+func TestSchedule_UnratedStages(t *testing.T) {
+	s := newSchedule(RequestConfig{Stages: []Stage{
+		{Duration: time.Second, Concurrency: 5},
+		{Duration: time.Second, Concurrency: 10},
+	}})
+	if s.rated {
+		t.Errorf("expected an unrated schedule")
+	}
+	if s.end != 2*time.Second {
+		t.Errorf("expected the run to end after 2s, got %s", s.end)
+	}
+}
+
+// TestLimiter checks that a blocked acquire is released by a freed slot and by
+// a raised limit, and gives up on a cancelled context.
+func TestLimiter(t *testing.T) {
+	l := newLimiter(1)
+	if !l.tryAcquire() {
+		t.Fatal("expected the first slot to be free")
+	}
+	if l.tryAcquire() {
+		t.Fatal("expected the limiter to be full")
+	}
+
+	done := make(chan bool)
+	go func() { done <- l.acquire(context.Background()) }()
+	l.setLimit(2)
+	if !<-done {
+		t.Error("expected a raised limit to unblock acquire")
+	}
+
+	go func() { done <- l.acquire(context.Background()) }()
+	l.release()
+	if !<-done {
+		t.Error("expected a released slot to unblock acquire")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if l.acquire(ctx) {
+		t.Error("expected acquire to fail on a cancelled context")
+	}
+
+	unlimited := newLimiter(0)
+	for i := 0; i < 100; i++ {
+		if !unlimited.tryAcquire() {
+			t.Fatal("expected a zero limit to be unlimited")
+		}
+	}
+}
@@ -0,0 +1,324 @@
+package generator
+
+import (
+	"time"
+
+	"github.com/idesyatov/http-runner/internal/results"
+	"github.com/idesyatov/http-runner/pkg/histogram"
+	"github.com/idesyatov/http-runner/pkg/httpclient"
+)
+
+// DefaultPrecision is the number of significant digits latency histograms keep
+// when RequestConfig.Precision is unset.
+const DefaultPrecision = 3
+
+// Latencies are recorded in nanoseconds between 1µs and 1h; anything slower
+// is clamped into the top bucket (Max stays exact).
+const (
+	lowestLatency  = int64(time.Microsecond)
+	highestLatency = int64(time.Hour)
+)
+
+// result is the outcome of one request, handed from a worker to the stats.
+type result struct {
+	stage     int               // Stage the request was scheduled in (-1 without stages)
+	latency   time.Duration     // From the actual start until the response headers arrive
+	corrected time.Duration     // From the scheduled start (coordinated omission corrected)
+	status    int               // HTTP status code; 0 on a transport error
+	bytes     int64             // Response body bytes read
+	trace     *httpclient.Trace // Connection phase timings; nil on a transport error
+	err       error             // Transport error, if any
+
+	checkFailures  []string // Names of the checks the response failed
+	statusExpected bool     // A status check passed, so the status counts as success even if not 2xx
+	extractFailed  bool     // A value could not be extracted from the response
+}
+
+// record returns r as a record of the results log. ep is the endpoint or
+// scenario the request was sent for and url the URL requested.
+func (r result) record(ep RequestConfig, url string, start, intended time.Time) results.Record {
+	rec := results.Record{
+		Timestamp:     start,
+		Intended:      intended,
+		Endpoint:      ep.Name,
+		Method:        ep.Method,
+		URL:           url,
+		LatencySec:    r.latency.Seconds(),
+		Status:        r.status,
+		Bytes:         r.bytes,
+		Success:       r.ok(),
+		CheckFailures: r.checkFailures,
+	}
+	if rec.Endpoint == "" {
+		rec.Endpoint = ep.URL
+	}
+	if t := r.trace; t != nil {
+		rec.DNSSec, rec.ConnectSec, rec.TLSSec, rec.TTFBSec = t.DNS.Seconds(), t.Connect.Seconds(), t.TLS.Seconds(), t.TTFB.Seconds()
+		rec.Reused = t.Reused
+	}
+	if r.err != nil {
+		rec.Error, rec.Message = classifyError(r.err), errorMessage(r.err)
+	}
+	return rec
+}
+
+// ok reports whether the request succeeded: a response with a 2xx (or
+// explicitly expected) status that passed every check and from which every
+// value was extracted.
+func (r result) ok() bool {
+	if r.err != nil || r.extractFailed || len(r.checkFailures) > 0 {
+		return false
+	}
+	return r.statusExpected || (r.status >= 200 && r.status < 300)
+}
+
+// stats accumulates the results of a run, or of one stage of it. It is not
+// safe for concurrent use; the generator guards it with its mutex.
+type stats struct {
+	sent      int // Requests actually launched
+	dropped   int // Arrival-rate only: scheduled requests skipped at the in-flight cap
+	late      int // Arrival-rate only: requests launched more than an interval behind schedule
+	missed    int // Closed executor only: ticks skipped while waiting for a free slot
+	completed int // Requests that got an HTTP response (no transport error)
+	success   int // Responses that succeeded (see result.ok)
+
+	extractFailures int // Responses from which a value could not be extracted
+	errors          int // Requests that failed with a transport error
+
+	// Response times of completed requests, in fixed memory however long the
+	// run: min, max, mean, percentiles and the text histogram all come from
+	// these.
+	latencies   *histogram.Histogram
+	corrected   *histogram.Histogram // Same, measured from the scheduled start
+	totalBytes  int64                // Response body bytes read across completed requests
+	statusCodes map[int]int          // Status code -> count
+	errorTypes  map[string]int       // Transport error category -> count
+	errorSample map[string][]string  // Transport error category -> first distinct messages
+	checks      map[string]int       // Check name -> responses that failed it
+
+	// Connection phase timings (httptrace). DNS/connect/TLS only accrue on new
+	// connections, so they carry their own counters; TTFB and reuse span all
+	// completed requests.
+	sumDNS, sumConnect, sumTLS, sumTTFB time.Duration
+	cntDNS, cntConnect, cntTLS, reused  int
+}
+
+// newStats returns empty stats whose histograms keep precision significant
+// digits (DefaultPrecision if <= 0).
+func newStats(precision int) *stats {
+	if precision <= 0 {
+		precision = DefaultPrecision
+	}
+	return &stats{
+		latencies:   histogram.New(lowestLatency, highestLatency, precision),
+		corrected:   histogram.New(lowestLatency, highestLatency, precision),
+		statusCodes: make(map[int]int),
+		errorTypes:  make(map[string]int),
+		errorSample: make(map[string][]string),
+		checks:      make(map[string]int),
+	}
+}
+
+// reset clears the stats for reuse, keeping the histograms' memory.
+func (s *stats) reset() {
+	latencies, corrected := s.latencies, s.corrected
+	latencies.Reset()
+	corrected.Reset()
+	*s = stats{
+		latencies:   latencies,
+		corrected:   corrected,
+		statusCodes: make(map[int]int),
+		errorTypes:  make(map[string]int),
+		errorSample: make(map[string][]string),
+		checks:      make(map[string]int),
+	}
+}
+
+// add records one result.
+func (s *stats) add(r result) {
+	// Latency metrics cover every completed request (one that returned an
+	// HTTP response); transport errors carry no meaningful response time.
+	// "Success" is narrower: only 2xx (or expected) responses that pass every
+	// check and yield every extracted value count toward success.
+	if r.err != nil {
+		s.errors++
+		cat := classifyError(r.err)
+		s.errorTypes[cat]++
+		s.errorSample[cat] = addSample(s.errorSample[cat], errorMessage(r.err))
+		return
+	}
+	s.completed++
+	s.totalBytes += r.bytes
+	s.latencies.Record(int64(r.latency))
+	s.corrected.Record(int64(r.corrected))
+	s.statusCodes[r.status]++
+	for _, name := range r.checkFailures {
+		s.checks[name]++
+	}
+	if r.extractFailed {
+		s.extractFailures++
+	}
+	if r.ok() {
+		s.success++
+	}
+	// Aggregate connection phase timings. DNS/connect/TLS are counted only
+	// when they actually happened (a new connection); TTFB and reuse apply
+	// to every completed request.
+	if t := r.trace; t != nil {
+		s.sumTTFB += t.TTFB
+		if t.Reused {
+			s.reused++
+		}
+		if t.DNS > 0 {
+			s.sumDNS += t.DNS
+			s.cntDNS++
+		}
+		if t.Connect > 0 {
+			s.sumConnect += t.Connect
+			s.cntConnect++
+		}
+		if t.TLS > 0 {
+			s.sumTLS += t.TLS
+			s.cntTLS++
+		}
+	}
+}
+
+// merge adds the results recorded in o.
+func (s *stats) merge(o *stats) {
+	s.sent += o.sent
+	s.dropped += o.dropped
+	s.late += o.late
+	s.missed += o.missed
+	s.completed += o.completed
+	s.success += o.success
+	s.extractFailures += o.extractFailures
+	s.errors += o.errors
+	s.latencies.Merge(o.latencies)
+	s.corrected.Merge(o.corrected)
+	s.totalBytes += o.totalBytes
+	for code, n := range o.statusCodes {
+		s.statusCodes[code] += n
+	}
+	for cat, n := range o.errorTypes {
+		s.errorTypes[cat] += n
+	}
+	for cat, msgs := range o.errorSample {
+		for _, msg := range msgs {
+			s.errorSample[cat] = addSample(s.errorSample[cat], msg)
+		}
+	}
+	for name, n := range o.checks {
+		s.checks[name] += n
+	}
+	s.sumDNS += o.sumDNS
+	s.sumConnect += o.sumConnect
+	s.sumTLS += o.sumTLS
+	s.sumTTFB += o.sumTTFB
+	s.cntDNS += o.cntDNS
+	s.cntConnect += o.cntConnect
+	s.cntTLS += o.cntTLS
+	s.reused += o.reused
+}
+
+// fill writes the accumulated metrics into rep, with rates computed over
+// elapsed.
+func (s *stats) fill(rep *GeneratorReport, elapsed time.Duration) {
+	rep.Count = s.sent
+	rep.DroppedIterations = s.dropped
+	rep.LateIterations = s.late
+	rep.MissedTicks = s.missed
+	rep.TotalDuration = elapsed
+	rep.TotalBytes = s.totalBytes
+	rep.AverageResponse = seconds(s.latencies.Mean())
+	if s.sent > 0 {
+		rep.SuccessRate = (float64(s.success) / float64(s.sent)) * 100
+	}
+	if elapsed.Seconds() > 0 {
+		rep.RequestsPerSec = float64(s.sent) / elapsed.Seconds()
+		rep.BytesPerSec = float64(s.totalBytes) / elapsed.Seconds()
+	}
+	rep.P50Response = percentile(s.latencies, 50)
+	rep.P90Response = percentile(s.latencies, 90)
+	rep.P95Response = percentile(s.latencies, 95)
+	rep.P99Response = percentile(s.latencies, 99)
+	rep.CorrectedP50 = percentile(s.corrected, 50)
+	rep.CorrectedP90 = percentile(s.corrected, 90)
+	rep.CorrectedP95 = percentile(s.corrected, 95)
+	rep.CorrectedP99 = percentile(s.corrected, 99)
+	rep.MinResponse = seconds(float64(s.latencies.Min()))
+	rep.MaxResponse = seconds(float64(s.latencies.Max()))
+
+	// Average connection phase timings (seconds). Each phase divides by the
+	// number of requests where it actually occurred, so reused connections do
+	// not deflate the DNS/connect/TLS averages.
+	avgSeconds := func(sum time.Duration, n int) float64 {
+		if n == 0 {
+			return 0
+		}
+		return sum.Seconds() / float64(n)
+	}
+	rep.AvgDNS = avgSeconds(s.sumDNS, s.cntDNS)
+	rep.AvgConnect = avgSeconds(s.sumConnect, s.cntConnect)
+	rep.AvgTLS = avgSeconds(s.sumTLS, s.cntTLS)
+	rep.AvgTTFB = avgSeconds(s.sumTTFB, s.completed)
+	if s.completed > 0 {
+		rep.ConnReuseRate = (float64(s.reused) / float64(s.completed)) * 100
+	}
+
+	rep.SuccessCount = s.success
+	rep.StatusCodes = s.statusCodes
+	rep.ErrorCount = s.errors
+	rep.Errors = s.errorTypes
+	rep.ErrorSamples = s.errorSample
+	rep.CheckFailures = s.checks
+	rep.Histogram = buckets(s.latencies, 10)
+}
+
+// interval returns the stats as a timeseries window starting at offset start
+// into the run and lasting width, with inFlight requests in flight at its end.
+func (s *stats) interval(start, width time.Duration, inFlight int) Interval {
+	var wr GeneratorReport
+	s.fill(&wr, width)
+	return Interval{
+		Start:             start,
+		Duration:          wr.TotalDuration,
+		Count:             wr.Count,
+		DroppedIterations: wr.DroppedIterations,
+		LateIterations:    wr.LateIterations,
+		MissedTicks:       wr.MissedTicks,
+		RequestsPerSec:    wr.RequestsPerSec,
+		SuccessRate:       wr.SuccessRate,
+		ErrorCount:        wr.ErrorCount,
+		P50Response:       wr.P50Response,
+		P95Response:       wr.P95Response,
+		P99Response:       wr.P99Response,
+		InFlight:          inFlight,
+	}
+}
+
+// seconds converts a nanosecond value read from a histogram to seconds.
+func seconds(ns float64) float64 {
+	return ns / float64(time.Second)
+}
+
+// percentile returns the p-th percentile (0-100) of the histogram, in seconds,
+// using the nearest-rank method. Returns 0 if empty.
+func percentile(h *histogram.Histogram, p float64) float64 {
+	return seconds(float64(h.ValueAt(p)))
+}
+
+// buckets splits the histogram into n equal-width ranges between the min and
+// max, in seconds. It returns nil for an empty histogram, and a single bucket
+// when every sample is equal.
+func buckets(h *histogram.Histogram, n int) []Bucket {
+	bars := h.Bars(n)
+	if bars == nil {
+		return nil
+	}
+	res := make([]Bucket, len(bars))
+	for i, b := range bars {
+		res[i] = Bucket{Start: seconds(float64(b.Start)), End: seconds(float64(b.End)), Count: int(b.Count)}
+	}
+	return res
+}
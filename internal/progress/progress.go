@@ -0,0 +1,149 @@
+// Package progress draws a live, single-line status of a run in flight, so a
+// long run is not silent until its final report. The line is redrawn in place
+// with a carriage return, so it is only drawn on a terminal.
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Status is a live view of a run in flight.
+type Status struct {
+	Elapsed           time.Duration // Time since the run started
+	Remaining         time.Duration // Time left in a run bounded in time (0 otherwise)
+	Sent              int           // Requests launched so far
+	Target            int           // Requests to send in a run bounded by count (0 otherwise)
+	DroppedIterations int           // Arrival-rate only: scheduled requests skipped so far
+	InFlight          int           // Requests launched but not finished
+	RequestsPerSec    float64       // Recent finished requests per second
+	P99Response       float64       // Recent 99th percentile response time in seconds
+	ErrorCount        int           // Transport errors so far
+	StatusCodes       map[int]int   // Status code -> count so far
+	Warmup            bool          // Whether the requests are those of the warm-up
+}
+
+// Display redraws a status line on a terminal. A nil *Display is valid and
+// draws nothing, so callers need not check whether progress is enabled.
+type Display struct {
+	mu    sync.Mutex
+	w     io.Writer
+	drawn bool // A line is on screen and must be cleared before other output
+}
+
+// New returns a display drawing on f, or nil if f is not a terminal (e.g.
+// redirected to a file or a pipe), where a redrawn line would only be noise.
+func New(f *os.File) *Display {
+	fi, err := f.Stat()
+	if err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		return nil
+	}
+	return &Display{w: f}
+}
+
+// Update replaces the status line with s.
+func (d *Display) Update(s Status) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	fmt.Fprintf(d.w, "\r\033[K%s", Line(s))
+	d.drawn = true
+}
+
+// Clear erases the status line, if one is drawn, so regular output can follow.
+func (d *Display) Clear() {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.drawn {
+		fmt.Fprint(d.w, "\r\033[K")
+		d.drawn = false
+	}
+}
+
+// Merge combines the statuses of runs in flight side by side into one: counts
+// and rates add up, while times and the p99 take the largest. The target is
+// the sum of the targets only if every run has one, and the status is that of
+// a warm-up if any run is still warming up.
+func Merge(statuses ...Status) Status {
+	var m Status
+	m.StatusCodes = make(map[int]int)
+	targeted := len(statuses) > 0
+	for _, s := range statuses {
+		m.Elapsed = max(m.Elapsed, s.Elapsed)
+		m.Remaining = max(m.Remaining, s.Remaining)
+		m.Sent += s.Sent
+		m.Target += s.Target
+		targeted = targeted && s.Target > 0
+		m.DroppedIterations += s.DroppedIterations
+		m.InFlight += s.InFlight
+		m.RequestsPerSec += s.RequestsPerSec
+		m.P99Response = max(m.P99Response, s.P99Response)
+		m.ErrorCount += s.ErrorCount
+		m.Warmup = m.Warmup || s.Warmup
+		for code, n := range s.StatusCodes {
+			m.StatusCodes[code] += n
+		}
+	}
+	if !targeted {
+		m.Target = 0
+	}
+	return m
+}
+
+// Line formats s as a single status line, e.g.
+//
+//	[00:12 / 09:48 left] sent 1234 | 98.5 req/s | p99 45.2ms | in flight 3 | errors 0 | 200:1200 503:34
+func Line(s Status) string {
+	var b strings.Builder
+	if s.Remaining > 0 {
+		fmt.Fprintf(&b, "[%s / %s left] ", clock(s.Elapsed), clock(s.Remaining))
+	} else {
+		fmt.Fprintf(&b, "[%s] ", clock(s.Elapsed))
+	}
+	if s.Warmup {
+		b.WriteString("warm-up ")
+	}
+	if s.Target > 0 {
+		fmt.Fprintf(&b, "sent %d/%d", s.Sent, s.Target)
+	} else {
+		fmt.Fprintf(&b, "sent %d", s.Sent)
+	}
+	if s.DroppedIterations > 0 {
+		fmt.Fprintf(&b, " (dropped %d)", s.DroppedIterations)
+	}
+	p99 := time.Duration(s.P99Response * float64(time.Second))
+	fmt.Fprintf(&b, " | %.1f req/s | p99 %s | in flight %d | errors %d",
+		s.RequestsPerSec, p99.Round(100*time.Microsecond), s.InFlight, s.ErrorCount)
+
+	if len(s.StatusCodes) > 0 {
+		codes := make([]int, 0, len(s.StatusCodes))
+		for code := range s.StatusCodes {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		b.WriteString(" |")
+		for _, code := range codes {
+			fmt.Fprintf(&b, " %d:%d", code, s.StatusCodes[code])
+		}
+	}
+	return b.String()
+}
+
+// clock formats d as mm:ss, or h:mm:ss from an hour up.
+func clock(d time.Duration) string {
+	sec := int(d.Round(time.Second) / time.Second)
+	if sec >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", sec/3600, sec/60%60, sec%60)
+	}
+	return fmt.Sprintf("%02d:%02d", sec/60, sec%60)
+}
@@ -0,0 +1,110 @@
+package progress
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLine(t *testing.T) {
+	tests := []struct {
+		name   string
+		status Status
+		want   string
+	}{
+		{
+			name: "duration run",
+			status: Status{
+				Elapsed:        12 * time.Second,
+				Remaining:      588 * time.Second,
+				Sent:           1234,
+				InFlight:       3,
+				RequestsPerSec: 98.5,
+				P99Response:    0.0452,
+				StatusCodes:    map[int]int{503: 34, 200: 1200},
+			},
+			want: "[00:12 / 09:48 left] sent 1234 | 98.5 req/s | p99 45.2ms | in flight 3 | errors 0 | 200:1200 503:34",
+		},
+		{
+			name: "count run with drops and errors",
+			status: Status{
+				Elapsed:           time.Hour + 2*time.Minute + 3*time.Second,
+				Sent:              40,
+				Target:            100,
+				DroppedIterations: 5,
+				ErrorCount:        2,
+			},
+			want: "[1:02:03] sent 40/100 (dropped 5) | 0.0 req/s | p99 0s | in flight 0 | errors 2",
+		},
+		{
+			name:   "warm-up",
+			status: Status{Elapsed: 3 * time.Second, Sent: 30, Warmup: true},
+			want:   "[00:03] warm-up sent 30 | 0.0 req/s | p99 0s | in flight 0 | errors 0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Line(tt.status); got != tt.want {
+				t.Errorf("Line() =\n  %q\nwant\n  %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	reads := Status{Elapsed: 10 * time.Second, Remaining: 50 * time.Second, Sent: 500, InFlight: 2, RequestsPerSec: 50, P99Response: 0.02, StatusCodes: map[int]int{200: 498}}
+	writes := Status{Elapsed: 9 * time.Second, Sent: 90, Target: 100, InFlight: 1, RequestsPerSec: 10, P99Response: 0.3, ErrorCount: 1, StatusCodes: map[int]int{200: 80, 503: 8}}
+
+	want := "[00:10 / 00:50 left] sent 590 | 60.0 req/s | p99 300ms | in flight 3 | errors 1 | 200:578 503:8"
+	if got := Line(Merge(reads, writes)); got != want {
+		t.Errorf("Line(Merge()) =\n  %q\nwant\n  %q", got, want)
+	}
+	if got := Merge(writes, Status{Sent: 5, Target: 10}); got.Target != 110 {
+		t.Errorf("expected a target of 110 when every run has one, got %d", got.Target)
+	}
+	if got := Merge(reads, Status{Warmup: true}); !got.Warmup {
+		t.Error("expected a warm-up status while one run is still warming up")
+	}
+	if reads.StatusCodes[200] != 498 {
+		t.Errorf("Merge modified its input: %v", reads.StatusCodes)
+	}
+}
+
+func TestDisplay(t *testing.T) {
+	var buf bytes.Buffer
+	d := &Display{w: &buf}
+
+	d.Clear() // nothing drawn yet: no output
+	d.Update(Status{Sent: 1})
+	d.Update(Status{Sent: 2})
+	d.Clear()
+	d.Clear()
+
+	out := buf.String()
+	if strings.Count(out, "\r\033[K") != 3 {
+		t.Errorf("expected two redraws and one clear, got %q", out)
+	}
+	if !strings.HasSuffix(out, "sent 2 | 0.0 req/s | p99 0s | in flight 0 | errors 0\r\033[K") {
+		t.Errorf("expected the last line to be cleared, got %q", out)
+	}
+
+	// A nil display is a no-op.
+	var none *Display
+	none.Update(Status{})
+	none.Clear()
+}
+
+// TestNew_NotTerminal verifies that nothing is drawn when output is not a
+// terminal, e.g. redirected to a file.
+func TestNew_NotTerminal(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "progress")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if d := New(f); d != nil {
+		t.Errorf("expected no display for a regular file")
+	}
+}
@@ -0,0 +1,171 @@
+package reporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Delta is the change of one metric from a baseline run.
+type Delta struct {
+	Metric   string  // Metric name, as in -fail-if (e.g. "p99"), or the JSON field of any other figure
+	Unit     string  // "s" for durations, "%" for percentages, "req/s" or "B/s" for rates, "" for counts
+	Baseline float64 // Value in the baseline run
+	Current  float64 // Value in this run
+}
+
+// Change returns how much the metric moved: Current - Baseline.
+func (d Delta) Change() float64 { return d.Current - d.Baseline }
+
+// Percent returns the change as a percentage of the baseline, or false if the
+// baseline is zero.
+func (d Delta) Percent() (float64, bool) {
+	if d.Baseline == 0 {
+		return 0, false
+	}
+	return d.Change() / abs(d.Baseline) * 100, true
+}
+
+func abs(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// format formats a value of the delta's metric in its unit; sign forces a +
+// on positive values.
+func (d Delta) format(v float64, sign bool) string {
+	f := "%.0f"
+	switch d.Unit {
+	case "s":
+		f = "%.6fs"
+	case "%":
+		f = "%.2f%%"
+	case "req/s", "B/s":
+		f = "%.2f"
+	}
+	if sign {
+		f = "%+" + f[1:]
+	}
+	return fmt.Sprintf(f, v)
+}
+
+// jsonDelta is the machine-readable shape of a delta. ChangePct is left out
+// when the baseline is zero.
+type jsonDelta struct {
+	Metric    string   `json:"metric"`
+	Baseline  float64  `json:"baseline"`
+	Current   float64  `json:"current"`
+	Change    float64  `json:"change"`
+	ChangePct *float64 `json:"change_pct,omitempty"`
+}
+
+func (d Delta) toJSON() jsonDelta {
+	jd := jsonDelta{Metric: d.Metric, Baseline: d.Baseline, Current: d.Current, Change: d.Change()}
+	if pct, ok := d.Percent(); ok {
+		jd.ChangePct = &pct
+	}
+	return jd
+}
+
+// ParseJSON reads the reports of a run written with -output json or ndjson:
+// the JSON document of the run, its NDJSON stream, or, as older versions
+// wrote, one JSON report per endpoint, scenario or mix, one after another.
+// Only the summary figures, status codes, errors and a mix's endpoints are
+// read back; the breakdowns over time, stages and steps are not.
+func ParseJSON(data []byte) ([]Report, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	var reports []Report
+	for {
+		var raw json.RawMessage
+		err := dec.Decode(&raw)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		// A document or an NDJSON summary has metadata; a report does not.
+		var head struct {
+			Metadata json.RawMessage `json:"metadata"`
+			Type     string          `json:"type"`
+		}
+		if err := json.Unmarshal(raw, &head); err != nil {
+			return nil, err
+		}
+		switch {
+		case head.Type == "summary":
+		case head.Metadata != nil:
+			var doc jsonDocument
+			if err := json.Unmarshal(raw, &doc); err != nil {
+				return nil, err
+			}
+			for _, jr := range doc.Endpoints {
+				reports = append(reports, jr.toReport())
+			}
+		default:
+			var jr jsonReport
+			if err := json.Unmarshal(raw, &jr); err != nil {
+				return nil, err
+			}
+			reports = append(reports, jr.toReport())
+		}
+	}
+	if len(reports) == 0 {
+		return nil, errors.New("no reports")
+	}
+	return reports, nil
+}
+
+// toReport converts a report read back from JSON to a Report. See ParseJSON.
+func (jr jsonReport) toReport() Report {
+	r := Report{
+		Name:              jr.Name,
+		URL:               jr.URL,
+		Method:            jr.Method,
+		Count:             jr.Count,
+		Concurrency:       jr.Concurrency,
+		Executor:          jr.Executor,
+		Rate:              jr.Rate,
+		DroppedIterations: jr.DroppedIterations,
+		LateIterations:    jr.LateIterations,
+		MissedTicks:       jr.MissedTicks,
+		WarmupCount:       jr.WarmupCount,
+		AbortReason:       jr.AbortReason,
+		TotalDuration:     time.Duration(jr.TotalDurationSec * float64(time.Second)),
+		RequestsPerSec:    jr.RequestsPerSec,
+		TotalBytes:        jr.TotalBytes,
+		BytesPerSec:       jr.BytesPerSec,
+		AverageResponse:   jr.AverageResponseSec,
+		P50Response:       jr.P50Sec,
+		P90Response:       jr.P90Sec,
+		P95Response:       jr.P95Sec,
+		P99Response:       jr.P99Sec,
+		CorrectedP50:      jr.CorrectedP50Sec,
+		CorrectedP90:      jr.CorrectedP90Sec,
+		CorrectedP95:      jr.CorrectedP95Sec,
+		CorrectedP99:      jr.CorrectedP99Sec,
+		MinResponse:       jr.MinSec,
+		MaxResponse:       jr.MaxSec,
+		AvgDNS:            jr.AvgDNSSec,
+		AvgConnect:        jr.AvgConnectSec,
+		AvgTLS:            jr.AvgTLSSec,
+		AvgTTFB:           jr.AvgTTFBSec,
+		ConnReuseRate:     jr.ConnReuseRate,
+		SuccessCount:      jr.SuccessCount,
+		SuccessRate:       jr.SuccessRate,
+		StatusCodes:       jr.StatusCodes,
+		ErrorCount:        jr.ErrorCount,
+		Errors:            jr.Errors,
+		CheckFailures:     jr.CheckFailures,
+		Weight:            jr.Weight,
+	}
+	for _, ep := range jr.Endpoints {
+		r.Endpoints = append(r.Endpoints, ep.toReport())
+	}
+	return r
+}
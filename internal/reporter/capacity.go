@@ -0,0 +1,128 @@
+package reporter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/idesyatov/http-runner/pkg/color"
+)
+
+// CapacityReport is the outcome of a capacity search against one endpoint.
+type CapacityReport struct {
+	URL          string         // The URL of the request
+	Method       string         // The HTTP method used
+	Mode         string         // Search strategy ("step" or "binary")
+	SLO          []string       // Failure conditions each step was judged by
+	MinRate      int            // Lowest rate tried (req/s)
+	MaxRate      int            // Highest rate allowed (req/s)
+	StepDuration float64        // How long each rate was held, in seconds
+	MaxPassing   int            // Highest rate that stayed within the SLO (0 if none)
+	Interrupted  bool           // The search was cut short
+	Steps        []CapacityStep // Every probe, in the order it ran
+}
+
+// CapacityStep is one probe of a capacity search.
+type CapacityStep struct {
+	Rate           int      // Target rate (req/s)
+	RequestsPerSec float64  // Achieved throughput
+	P50Response    float64  // 50th percentile response time
+	P99Response    float64  // 99th percentile response time
+	SuccessRate    float64  // Success rate as a percentage
+	ErrorCount     int      // Transport errors
+	Failures       []string // SLO conditions that held; empty when the step passed
+}
+
+// Generate outputs the capacity search to the console as a per-step table.
+func (c *CapacityReport) Generate() {
+	fmt.Printf("Capacity search: %s %s\n", c.Method, color.Colorize(color.Green, c.URL))
+	fmt.Printf("Mode: %s, %d-%d req/s, %.0fs per step\n", c.Mode, c.MinRate, c.MaxRate, c.StepDuration)
+	fmt.Printf("SLO (fail if): %s\n", strings.Join(c.SLO, ", "))
+	fmt.Printf("  %-5s %-10s %-10s %-10s %-10s %-9s %-7s %s\n", "step", "target", "req/s", "p50", "p99", "success", "errors", "result")
+	for i, st := range c.Steps {
+		verdict := color.Colorize(color.Green, "pass")
+		if len(st.Failures) > 0 {
+			verdict = color.Colorize(color.Red, "fail: "+strings.Join(st.Failures, ", "))
+		}
+		fmt.Printf("  %-5d %-10s %-10.2f %-10.6f %-10.6f %-9s %-7d %s\n",
+			i+1, fmt.Sprintf("%d/s", st.Rate), st.RequestsPerSec, st.P50Response, st.P99Response,
+			fmt.Sprintf("%.2f%%", st.SuccessRate), st.ErrorCount, verdict)
+	}
+	if c.Interrupted {
+		fmt.Println("Search interrupted; result covers completed steps only.")
+	}
+	if c.MaxPassing > 0 {
+		fmt.Printf("Max sustainable rate: %d req/s\n\n", c.MaxPassing)
+	} else {
+		fmt.Printf("Max sustainable rate: none (the SLO failed at %d req/s)\n\n", c.MinRate)
+	}
+}
+
+// jsonCapacityReport is the machine-readable shape of a capacity search.
+type jsonCapacityReport struct {
+	URL             string             `json:"url"`
+	Method          string             `json:"method"`
+	Mode            string             `json:"mode"`
+	SLO             []string           `json:"slo"`
+	MinRate         int                `json:"min_rate"`
+	MaxRate         int                `json:"max_rate"`
+	StepDurationSec float64            `json:"step_duration_sec"`
+	MaxPassingRate  int                `json:"max_passing_rate"`
+	Interrupted     bool               `json:"interrupted,omitempty"`
+	Steps           []jsonCapacityStep `json:"steps"`
+}
+
+// jsonCapacityStep is the machine-readable shape of one search step.
+type jsonCapacityStep struct {
+	Rate           int      `json:"rate"`
+	RequestsPerSec float64  `json:"requests_per_sec"`
+	P50Sec         float64  `json:"p50_sec"`
+	P99Sec         float64  `json:"p99_sec"`
+	SuccessRate    float64  `json:"success_rate"`
+	ErrorCount     int      `json:"error_count"`
+	Passed         bool     `json:"passed"`
+	Failures       []string `json:"failures,omitempty"`
+}
+
+// JSON returns the capacity search marshalled as indented JSON.
+func (c *CapacityReport) JSON() ([]byte, error) {
+	return marshalIndent(c.toJSON())
+}
+
+// toJSON converts the capacity search to its machine-readable shape.
+func (c *CapacityReport) toJSON() jsonCapacityReport {
+	steps := make([]jsonCapacityStep, 0, len(c.Steps))
+	for _, st := range c.Steps {
+		steps = append(steps, jsonCapacityStep{
+			Rate:           st.Rate,
+			RequestsPerSec: st.RequestsPerSec,
+			P50Sec:         st.P50Response,
+			P99Sec:         st.P99Response,
+			SuccessRate:    st.SuccessRate,
+			ErrorCount:     st.ErrorCount,
+			Passed:         len(st.Failures) == 0,
+			Failures:       st.Failures,
+		})
+	}
+	return jsonCapacityReport{
+		URL:             c.URL,
+		Method:          c.Method,
+		Mode:            c.Mode,
+		SLO:             c.SLO,
+		MinRate:         c.MinRate,
+		MaxRate:         c.MaxRate,
+		StepDurationSec: c.StepDuration,
+		MaxPassingRate:  c.MaxPassing,
+		Interrupted:     c.Interrupted,
+		Steps:           steps,
+	}
+}
+
+// GenerateJSON prints the capacity search as JSON to the console.
+func (c *CapacityReport) GenerateJSON() error {
+	b, err := c.JSON()
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(b))
+	return nil
+}
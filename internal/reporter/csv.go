@@ -0,0 +1,191 @@
+package reporter
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/idesyatov/http-runner/internal/generator"
+)
+
+// csvStatusCodes are the status codes with a column of their own; every code
+// is also counted in the column of its class (status_2xx etc.).
+var csvStatusCodes = []int{200, 201, 202, 204, 301, 302, 304, 400, 401, 403, 404, 409, 422, 429, 500, 502, 503, 504}
+
+// csvColumn is a column of the CSV report and how a report fills it.
+type csvColumn struct {
+	name  string
+	value func(r *Report) string
+}
+
+// csvColumns are the columns of the CSV report after time and mix, in order.
+// They follow the fields of the JSON report, with status codes and error
+// categories flattened into a column each and lists joined with "; ". The
+// histogram, stages, timeseries, steps and baseline have no column.
+var csvColumns = func() []csvColumn {
+	str := func(name string, f func(r *Report) string) csvColumn { return csvColumn{name, f} }
+	num := func(name string, f func(r *Report) float64) csvColumn {
+		return csvColumn{name, func(r *Report) string { return strconv.FormatFloat(f(r), 'f', -1, 64) }}
+	}
+	count := func(name string, f func(r *Report) int) csvColumn {
+		return csvColumn{name, func(r *Report) string { return strconv.Itoa(f(r)) }}
+	}
+	flag := func(name string, f func(r *Report) bool) csvColumn {
+		return csvColumn{name, func(r *Report) string { return strconv.FormatBool(f(r)) }}
+	}
+	list := func(name string, f func(r *Report) []string) csvColumn {
+		return csvColumn{name, func(r *Report) string { return strings.Join(f(r), "; ") }}
+	}
+
+	cols := []csvColumn{
+		str("name", func(r *Report) string { return r.Name }),
+		str("url", func(r *Report) string { return r.URL }),
+		str("method", func(r *Report) string { return r.Method }),
+		count("count", func(r *Report) int { return r.Count }),
+		count("concurrency", func(r *Report) int { return r.Concurrency }),
+		str("executor", func(r *Report) string { return r.Executor }),
+		count("rate", func(r *Report) int { return r.Rate }),
+		count("weight", func(r *Report) int { return r.Weight }),
+		count("dropped_iterations", func(r *Report) int { return r.DroppedIterations }),
+		count("late_iterations", func(r *Report) int { return r.LateIterations }),
+		count("missed_ticks", func(r *Report) int { return r.MissedTicks }),
+		count("warmup_count", func(r *Report) int { return r.WarmupCount }),
+		flag("feeder_exhausted", func(r *Report) bool { return r.FeederExhausted }),
+		flag("aborted", func(r *Report) bool { return r.AbortReason != "" }),
+		str("abort_reason", func(r *Report) string { return r.AbortReason }),
+		num("total_duration_sec", func(r *Report) float64 { return r.TotalDuration.Seconds() }),
+		num("requests_per_sec", func(r *Report) float64 { return r.RequestsPerSec }),
+		str("total_bytes", func(r *Report) string { return strconv.FormatInt(r.TotalBytes, 10) }),
+		num("bytes_per_sec", func(r *Report) float64 { return r.BytesPerSec }),
+		str("headers", func(r *Report) string {
+			keys := make([]string, 0, len(r.ParsedHeaders))
+			for k := range r.ParsedHeaders {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for i, k := range keys {
+				keys[i] = k + ": " + r.ParsedHeaders[k]
+			}
+			return strings.Join(keys, "; ")
+		}),
+		str("data", func(r *Report) string {
+			if r.ParsedData == nil {
+				return ""
+			}
+			b, _ := json.Marshal(r.ParsedData)
+			return string(b)
+		}),
+		num("average_response_sec", func(r *Report) float64 { return r.AverageResponse }),
+		num("p50_sec", func(r *Report) float64 { return r.P50Response }),
+		num("p90_sec", func(r *Report) float64 { return r.P90Response }),
+		num("p95_sec", func(r *Report) float64 { return r.P95Response }),
+		num("p99_sec", func(r *Report) float64 { return r.P99Response }),
+		num("corrected_p50_sec", func(r *Report) float64 { return r.CorrectedP50 }),
+		num("corrected_p90_sec", func(r *Report) float64 { return r.CorrectedP90 }),
+		num("corrected_p95_sec", func(r *Report) float64 { return r.CorrectedP95 }),
+		num("corrected_p99_sec", func(r *Report) float64 { return r.CorrectedP99 }),
+		num("min_sec", func(r *Report) float64 { return r.MinResponse }),
+		num("max_sec", func(r *Report) float64 { return r.MaxResponse }),
+		num("avg_dns_sec", func(r *Report) float64 { return r.AvgDNS }),
+		num("avg_connect_sec", func(r *Report) float64 { return r.AvgConnect }),
+		num("avg_tls_sec", func(r *Report) float64 { return r.AvgTLS }),
+		num("avg_ttfb_sec", func(r *Report) float64 { return r.AvgTTFB }),
+		num("conn_reuse_rate", func(r *Report) float64 { return r.ConnReuseRate }),
+		count("success_count", func(r *Report) int { return r.SuccessCount }),
+		num("success_rate", func(r *Report) float64 { return r.SuccessRate }),
+	}
+	for class := 1; class <= 5; class++ {
+		cols = append(cols, count(fmt.Sprintf("status_%dxx", class), func(r *Report) int {
+			n := 0
+			for code, c := range r.StatusCodes {
+				if code/100 == class {
+					n += c
+				}
+			}
+			return n
+		}))
+	}
+	for _, code := range csvStatusCodes {
+		cols = append(cols, count(fmt.Sprintf("status_%d", code), func(r *Report) int { return r.StatusCodes[code] }))
+	}
+	cols = append(cols, count("error_count", func(r *Report) int { return r.ErrorCount }))
+	// Every category the generator classifies errors into has a column; any
+	// other, as from a report of an older version, is counted in errors_other.
+	for _, cat := range generator.ErrorCategories {
+		cols = append(cols, count("errors_"+strings.ReplaceAll(cat, " ", "_"), func(r *Report) int {
+			if cat != "other" {
+				return r.Errors[cat]
+			}
+			n := r.Errors[cat]
+			for c, v := range r.Errors {
+				if !slices.Contains(generator.ErrorCategories, c) {
+					n += v
+				}
+			}
+			return n
+		}))
+	}
+	return append(cols,
+		str("check_failures", func(r *Report) string {
+			var fails []string
+			for _, name := range sortedKeys(r.CheckFailures) {
+				fails = append(fails, fmt.Sprintf("%s: %d", name, r.CheckFailures[name]))
+			}
+			return strings.Join(fails, "; ")
+		}),
+		list("thresholds", func(r *Report) []string { return r.Thresholds }),
+		list("threshold_failures", func(r *Report) []string { return r.ThresholdFailures }),
+		list("regressions", func(r *Report) []string { return r.Regressions }),
+		list("regression_failures", func(r *Report) []string { return r.RegressionFailures }),
+		str("verdict", func(r *Report) string { return r.Verdict() }),
+	)
+}()
+
+// csvHeader returns the header row of the CSV report, the same for every
+// report so that rows of many runs line up.
+func csvHeader() []string {
+	header := []string{"time", "mix"}
+	for _, c := range csvColumns {
+		header = append(header, c.name)
+	}
+	return header
+}
+
+// WriteCSV writes the reports to w as CSV, one row per report and one per
+// endpoint of a traffic mix (with the mix's label in the mix column), after
+// the header row if header is set. Every row carries the time it was written,
+// so rows appended over many runs keep their history.
+func WriteCSV(w io.Writer, reports []*Report, header bool) error {
+	cw := csv.NewWriter(w)
+	if header {
+		if err := cw.Write(csvHeader()); err != nil {
+			return err
+		}
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	row := func(mix string, r *Report) error {
+		rec := []string{now, mix}
+		for _, c := range csvColumns {
+			rec = append(rec, c.value(r))
+		}
+		return cw.Write(rec)
+	}
+	for _, r := range reports {
+		if err := row("", r); err != nil {
+			return err
+		}
+		for i := range r.Endpoints {
+			if err := row(r.Label(), &r.Endpoints[i]); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
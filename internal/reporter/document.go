@@ -0,0 +1,202 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Run describes an invocation of http-runner, for the metadata of the JSON
+// document.
+type Run struct {
+	Version    string    // Version of http-runner
+	Start, End time.Time // When the runs started and finished; zero if not known
+	Hostname   string    // Host http-runner ran on
+	ConfigFile string    // Path of the configuration file, if any
+	ConfigHash string    // SHA-256 of the configuration file, hex-encoded
+	Args       []string  // Command-line arguments, without the program name
+}
+
+// condition is the outcome of one threshold or regression condition of a
+// report.
+type condition struct {
+	Endpoint  string // Label of the report; "<endpoint> in <mix>" for a mix endpoint
+	Kind      string // "threshold" or "regression"
+	Condition string // The condition, as written
+	Failure   string // The condition with its values if it held, empty if it passed
+}
+
+// conditions lists the outcome of every threshold and regression condition
+// of the reports, and of the endpoints of a traffic mix, in order.
+func conditions(reports []*Report) []condition {
+	var out []condition
+	for _, r := range reports {
+		out = append(out, reportConditions(r.Label(), r)...)
+		for i := range r.Endpoints {
+			e := &r.Endpoints[i]
+			out = append(out, reportConditions(fmt.Sprintf("%s in %s", e.Label(), r.Label()), e)...)
+		}
+	}
+	return out
+}
+
+// reportConditions lists the outcome of the conditions of r, labelled label.
+func reportConditions(label string, r *Report) []condition {
+	var out []condition
+	for _, kind := range []struct {
+		name            string
+		conds, failures []string
+	}{{"threshold", r.Thresholds, r.ThresholdFailures}, {"regression", r.Regressions, r.RegressionFailures}} {
+		for _, cond := range kind.conds {
+			c := condition{Endpoint: label, Kind: kind.name, Condition: cond}
+			// A failure is the condition followed by its values, e.g.
+			// "p99>500ms (actual 0.612000s)".
+			for _, f := range kind.failures {
+				if strings.HasPrefix(f, cond+" (") {
+					c.Failure = f
+					break
+				}
+			}
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// verdict is "fail" if any report fails, "pass" if every report with a
+// verdict passes, or empty if none has a verdict.
+func verdict(reports []*Report) string {
+	v := ""
+	for _, r := range reports {
+		switch r.Verdict() {
+		case "fail":
+			return "fail"
+		case "pass":
+			v = "pass"
+		}
+	}
+	return v
+}
+
+// jsonDocument is the JSON document of a run: its metadata, verdict and
+// condition outcomes, and the report of every endpoint, scenario and mix, or
+// of every capacity search when searches replaced the runs.
+type jsonDocument struct {
+	Metadata   jsonMetadata         `json:"metadata"`
+	Verdict    string               `json:"verdict,omitempty"`
+	Thresholds []jsonCondition      `json:"thresholds,omitempty"`
+	Endpoints  []jsonReport         `json:"endpoints"`
+	Capacity   []jsonCapacityReport `json:"capacity,omitempty"`
+}
+
+type jsonMetadata struct {
+	Tool         string   `json:"tool"`
+	Version      string   `json:"version"`
+	StartedAt    string   `json:"started_at,omitempty"`
+	FinishedAt   string   `json:"finished_at,omitempty"`
+	DurationSec  float64  `json:"duration_sec,omitempty"`
+	Hostname     string   `json:"hostname,omitempty"`
+	ConfigFile   string   `json:"config_file,omitempty"`
+	ConfigSHA256 string   `json:"config_sha256,omitempty"`
+	Args         []string `json:"args"`
+}
+
+type jsonCondition struct {
+	Endpoint  string `json:"endpoint"`
+	Kind      string `json:"kind"`
+	Condition string `json:"condition"`
+	Passed    bool   `json:"passed"`
+	Message   string `json:"message,omitempty"`
+}
+
+// jsonSummary is the last line of an NDJSON stream: the document without the
+// reports, which came before it.
+type jsonSummary struct {
+	Type       string          `json:"type"`
+	Metadata   jsonMetadata    `json:"metadata"`
+	Verdict    string          `json:"verdict,omitempty"`
+	Thresholds []jsonCondition `json:"thresholds,omitempty"`
+}
+
+// jsonLine is a report as a line of an NDJSON stream.
+type jsonLine struct {
+	Type string `json:"type"`
+	jsonReport
+}
+
+func (run Run) toJSON() jsonMetadata {
+	m := jsonMetadata{
+		Tool:         "http-runner",
+		Version:      run.Version,
+		Hostname:     run.Hostname,
+		ConfigFile:   run.ConfigFile,
+		ConfigSHA256: run.ConfigHash,
+		Args:         run.Args,
+	}
+	if m.Args == nil {
+		m.Args = []string{}
+	}
+	if !run.Start.IsZero() {
+		m.StartedAt = run.Start.Format(time.RFC3339Nano)
+	}
+	if !run.End.IsZero() {
+		m.FinishedAt = run.End.Format(time.RFC3339Nano)
+	}
+	if !run.Start.IsZero() && !run.End.IsZero() {
+		m.DurationSec = run.End.Sub(run.Start).Seconds()
+	}
+	return m
+}
+
+func jsonConditions(reports []*Report) []jsonCondition {
+	var out []jsonCondition
+	for _, c := range conditions(reports) {
+		out = append(out, jsonCondition{Endpoint: c.Endpoint, Kind: c.Kind, Condition: c.Condition, Passed: c.Failure == "", Message: c.Failure})
+	}
+	return out
+}
+
+// WriteJSON writes the reports of a run to w as one JSON document, with the
+// run's metadata, the overall verdict and the outcome of every condition.
+// The capacity searches of a -search run, if any, go in its capacity array.
+func WriteJSON(w io.Writer, run Run, reports []*Report, searches []*CapacityReport) error {
+	doc := jsonDocument{
+		Metadata:   run.toJSON(),
+		Verdict:    verdict(reports),
+		Thresholds: jsonConditions(reports),
+		Endpoints:  []jsonReport{},
+	}
+	for _, r := range reports {
+		doc.Endpoints = append(doc.Endpoints, r.toJSON())
+	}
+	for _, c := range searches {
+		doc.Capacity = append(doc.Capacity, c.toJSON())
+	}
+	b, err := marshalIndent(doc)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(b))
+	return err
+}
+
+// WriteNDJSONReport writes a report to w as one line of an NDJSON stream,
+// typed "endpoint", so it can be streamed as soon as its run finishes.
+func WriteNDJSONReport(w io.Writer, r *Report) error {
+	return writeLine(w, jsonLine{Type: "endpoint", jsonReport: r.toJSON()})
+}
+
+// WriteNDJSONSummary writes the line that ends an NDJSON stream, typed
+// "summary": the run's metadata, the overall verdict and the outcome of every
+// condition of the reports streamed before it.
+func WriteNDJSONSummary(w io.Writer, run Run, reports []*Report) error {
+	return writeLine(w, jsonSummary{Type: "summary", Metadata: run.toJSON(), Verdict: verdict(reports), Thresholds: jsonConditions(reports)})
+}
+
+func writeLine(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(v)
+}
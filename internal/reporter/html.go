@@ -0,0 +1,370 @@
+package reporter
+
+import (
+	_ "embed"
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+//go:embed report.html.tmpl
+var htmlTemplate string
+
+// page is the compiled HTML report template.
+var page = template.Must(template.New("report").Funcs(template.FuncMap{
+	"seconds": func(v float64) string { return fmt.Sprintf("%.6f", v) },
+	"percent": func(v float64) string { return fmt.Sprintf("%.2f%%", v) },
+	"float":   func(v float64) string { return fmt.Sprintf("%.2f", v) },
+}).Parse(htmlTemplate))
+
+// palette colours the series, slices and segments of the charts.
+var palette = []string{"#4e79a7", "#f28e2b", "#e15759", "#76b7b2", "#59a14f", "#edc948", "#b07aa1", "#ff9da7", "#9c755f", "#bab0ac"}
+
+// htmlPage is what the HTML report template renders.
+type htmlPage struct {
+	Generated string
+	Reports   []htmlReport
+}
+
+// htmlReport is one report in the HTML page, with its charts drawn.
+type htmlReport struct {
+	*Report
+	ID          string
+	Status      []htmlCount // Status codes, then transport errors, by count
+	ErrorList   []htmlCount // Transport error categories, with samples
+	Checks      []htmlCount
+	Histogram   template.HTML
+	Percentiles template.HTML
+	StatusPie   template.HTML
+	Phases      template.HTML
+	Latency     template.HTML // Percentiles over time; empty without a timeseries
+	Throughput  template.HTML // Requests/sec over time; empty without a timeseries
+}
+
+// htmlCount is a labelled count, e.g. of a status code.
+type htmlCount struct {
+	Label   string
+	Count   int
+	Samples []string
+}
+
+// WriteHTML writes the reports to w as one self-contained HTML page, with a
+// summary table and, per report, charts of its latency distribution,
+// percentiles, status codes, connection phases and, when it has a timeseries,
+// of latency and throughput over the run. Charts are inline SVG and need no
+// network access to view.
+func WriteHTML(w io.Writer, reports []*Report) error {
+	p := htmlPage{Generated: time.Now().Format(time.RFC1123)}
+	for i, r := range reports {
+		p.Reports = append(p.Reports, newHTMLReport(i, r))
+	}
+	return page.Execute(w, p)
+}
+
+// newHTMLReport draws the charts of the i-th report.
+func newHTMLReport(i int, r *Report) htmlReport {
+	hr := htmlReport{Report: r, ID: fmt.Sprintf("report-%d", i+1)}
+
+	codes := make([]int, 0, len(r.StatusCodes))
+	for code := range r.StatusCodes {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	for _, code := range codes {
+		hr.Status = append(hr.Status, htmlCount{Label: fmt.Sprint(code), Count: r.StatusCodes[code]})
+	}
+	if r.ErrorCount > 0 {
+		hr.Status = append(hr.Status, htmlCount{Label: "transport error", Count: r.ErrorCount})
+	}
+	for _, cat := range sortedKeys(r.Errors) {
+		hr.ErrorList = append(hr.ErrorList, htmlCount{Label: cat, Count: r.Errors[cat], Samples: r.ErrorSamples[cat]})
+	}
+	for _, name := range sortedKeys(r.CheckFailures) {
+		hr.Checks = append(hr.Checks, htmlCount{Label: name, Count: r.CheckFailures[name]})
+	}
+
+	hr.Histogram = histogramChart(r.Histogram)
+	hr.Percentiles = percentileChart(r)
+	hr.StatusPie = pieChart(hr.Status)
+	hr.Phases = phaseChart(r)
+	if len(r.Timeseries) > 0 {
+		hr.Latency, hr.Throughput = timeseriesCharts(r.Timeseries)
+	}
+	return hr
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Chart geometry: the plot area sits inside a chart of chartW x chartH with
+// room for the axis labels on the left and at the bottom.
+const (
+	chartW, chartH = 560, 260
+	padL, padR     = 70, 16
+	padT, padB     = 16, 40
+	plotW, plotH   = chartW - padL - padR, chartH - padT - padB
+)
+
+// svg accumulates the markup of a chart.
+type svg struct{ b strings.Builder }
+
+func newSVG(w, h int) *svg {
+	s := &svg{}
+	fmt.Fprintf(&s.b, `<svg viewBox="0 0 %d %d" width="100%%" role="img" xmlns="http://www.w3.org/2000/svg">`, w, h)
+	return s
+}
+
+func (s *svg) printf(format string, args ...interface{}) { fmt.Fprintf(&s.b, format, args...) }
+
+// text writes a label; anchor is start, middle or end.
+func (s *svg) text(x, y float64, anchor, label string) {
+	s.printf(`<text x="%.1f" y="%.1f" text-anchor="%s">%s</text>`, x, y, anchor, html.EscapeString(label))
+}
+
+func (s *svg) html() template.HTML {
+	s.b.WriteString(`</svg>`)
+	return template.HTML(s.b.String())
+}
+
+// axes draws the frame of a plot, with yTicks horizontal grid lines labelled
+// from 0 to yMax, and the x axis labelled from xMin to xMax.
+func (s *svg) axes(xMin, xMax, yMax float64, fx, fy func(float64) string, xTitle string) {
+	const yTicks = 4
+	for i := 0; i <= yTicks; i++ {
+		y := float64(padT) + float64(plotH)*(1-float64(i)/yTicks)
+		s.printf(`<line class="grid" x1="%d" y1="%.1f" x2="%d" y2="%.1f"/>`, padL, y, padL+plotW, y)
+		s.text(padL-6, y+4, "end", fy(yMax*float64(i)/yTicks))
+	}
+	s.printf(`<line class="axis" x1="%d" y1="%d" x2="%d" y2="%d"/>`, padL, padT+plotH, padL+plotW, padT+plotH)
+	s.text(padL, padT+plotH+16, "start", fx(xMin))
+	s.text(padL+plotW, padT+plotH+16, "end", fx(xMax))
+	s.text(padL+plotW/2, padT+plotH+32, "middle", xTitle)
+}
+
+// ceilNice rounds v up to a round number for the top of an axis.
+func ceilNice(v float64) float64 {
+	if v <= 0 {
+		return 1
+	}
+	mag := math.Pow(10, math.Floor(math.Log10(v)))
+	for _, m := range []float64{1, 2, 2.5, 5, 10} {
+		if v <= m*mag {
+			return m * mag
+		}
+	}
+	return 10 * mag
+}
+
+func fmtSeconds(v float64) string {
+	switch {
+	case v == 0:
+		return "0"
+	case v < 0.001:
+		return fmt.Sprintf("%.0fµs", v*1e6)
+	case v < 1:
+		return fmt.Sprintf("%.1fms", v*1e3)
+	default:
+		return fmt.Sprintf("%.2fs", v)
+	}
+}
+
+func fmtFloat(v float64) string { return fmt.Sprintf("%.4g", v) }
+
+// emptyChart is shown instead of a chart without data.
+func emptyChart(msg string) template.HTML {
+	return template.HTML(`<p class="empty">` + html.EscapeString(msg) + `</p>`)
+}
+
+// histogramChart draws the latency distribution as bars.
+func histogramChart(buckets []Bucket) template.HTML {
+	if len(buckets) == 0 {
+		return emptyChart("No completed requests.")
+	}
+	maxCount := 0
+	for _, b := range buckets {
+		maxCount = max(maxCount, b.Count)
+	}
+	yMax := ceilNice(float64(maxCount))
+	s := newSVG(chartW, chartH)
+	s.axes(buckets[0].Start, buckets[len(buckets)-1].End, yMax, fmtSeconds, func(v float64) string { return fmt.Sprintf("%.0f", v) }, "response time")
+	bw := float64(plotW) / float64(len(buckets))
+	for i, b := range buckets {
+		h := float64(plotH) * float64(b.Count) / yMax
+		s.printf(`<rect class="bar" x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"><title>%s – %s: %d requests</title></rect>`,
+			float64(padL)+float64(i)*bw+1, float64(padT+plotH)-h, bw-2, h, palette[0], fmtSeconds(b.Start), fmtSeconds(b.End), b.Count)
+	}
+	return s.html()
+}
+
+// point is a point of a line chart.
+type point struct {
+	x, y float64
+	tip  string
+}
+
+// line is a series of a line chart.
+type line struct {
+	name   string
+	points []point
+}
+
+// lineChart draws series against shared axes, with a legend and a tooltip on
+// each point (or, for long series, on each line).
+func lineChart(series []line, fx, fy func(float64) string, xTitle string) template.HTML {
+	xMin, xMax, yMax := math.Inf(1), math.Inf(-1), 0.0
+	for _, l := range series {
+		for _, p := range l.points {
+			xMin, xMax, yMax = math.Min(xMin, p.x), math.Max(xMax, p.x), math.Max(yMax, p.y)
+		}
+	}
+	if math.IsInf(xMin, 0) {
+		return emptyChart("No data.")
+	}
+	if xMax == xMin {
+		xMax = xMin + 1
+	}
+	yMax = ceilNice(yMax)
+	s := newSVG(chartW, chartH)
+	s.axes(xMin, xMax, yMax, fx, fy, xTitle)
+	px := func(x float64) float64 { return float64(padL) + float64(plotW)*(x-xMin)/(xMax-xMin) }
+	py := func(y float64) float64 { return float64(padT) + float64(plotH)*(1-y/yMax) }
+	for i, l := range series {
+		colour := palette[i%len(palette)]
+		var pts []string
+		for _, p := range l.points {
+			pts = append(pts, fmt.Sprintf("%.1f,%.1f", px(p.x), py(p.y)))
+		}
+		s.printf(`<polyline class="line" points="%s" stroke="%s"><title>%s</title></polyline>`, strings.Join(pts, " "), colour, html.EscapeString(l.name))
+		if len(l.points) <= 120 {
+			for _, p := range l.points {
+				s.printf(`<circle class="dot" cx="%.1f" cy="%.1f" r="3" fill="%s"><title>%s</title></circle>`, px(p.x), py(p.y), colour, html.EscapeString(p.tip))
+			}
+		}
+		// Legend, top right.
+		ly := float64(padT + 12 + 16*i)
+		s.printf(`<rect x="%d" y="%.1f" width="10" height="10" fill="%s"/>`, padL+plotW-90, ly-9, colour)
+		s.text(float64(padL+plotW-76), ly, "start", l.name)
+	}
+	return s.html()
+}
+
+// percentileChart draws response time against percentile, from the minimum
+// through p50, p90, p95 and p99 to the maximum.
+func percentileChart(r *Report) template.HTML {
+	if r.Count == 0 || r.MaxResponse == 0 {
+		return emptyChart("No completed requests.")
+	}
+	l := line{name: "response time"}
+	for _, p := range []struct {
+		pct float64
+		v   float64
+	}{{0, r.MinResponse}, {50, r.P50Response}, {90, r.P90Response}, {95, r.P95Response}, {99, r.P99Response}, {100, r.MaxResponse}} {
+		l.points = append(l.points, point{x: p.pct, y: p.v, tip: fmt.Sprintf("p%g: %s", p.pct, fmtSeconds(p.v))})
+	}
+	series := []line{l}
+	if r.Rate > 0 {
+		c := line{name: "corrected"}
+		for _, p := range []struct {
+			pct float64
+			v   float64
+		}{{50, r.CorrectedP50}, {90, r.CorrectedP90}, {95, r.CorrectedP95}, {99, r.CorrectedP99}} {
+			c.points = append(c.points, point{x: p.pct, y: p.v, tip: fmt.Sprintf("corrected p%g: %s", p.pct, fmtSeconds(p.v))})
+		}
+		series = append(series, c)
+	}
+	return lineChart(series, func(v float64) string { return fmt.Sprintf("p%g", v) }, fmtSeconds, "percentile")
+}
+
+// pieChart draws the share of each count as a slice, with a legend.
+func pieChart(counts []htmlCount) template.HTML {
+	total := 0
+	for _, c := range counts {
+		total += c.Count
+	}
+	if total == 0 {
+		return emptyChart("No requests.")
+	}
+	const cx, cy, rad = 130.0, 130.0, 110.0
+	s := newSVG(chartW, chartH)
+	angle := -math.Pi / 2
+	for i, c := range counts {
+		colour := palette[i%len(palette)]
+		share := float64(c.Count) / float64(total)
+		tip := fmt.Sprintf("%s: %d (%.2f%%)", c.Label, c.Count, share*100)
+		if share >= 1 {
+			s.printf(`<circle cx="%.1f" cy="%.1f" r="%.1f" fill="%s"><title>%s</title></circle>`, cx, cy, rad, colour, html.EscapeString(tip))
+		} else {
+			end := angle + 2*math.Pi*share
+			large := 0
+			if share > 0.5 {
+				large = 1
+			}
+			s.printf(`<path class="slice" d="M%.1f,%.1f L%.1f,%.1f A%.1f,%.1f 0 %d 1 %.1f,%.1f Z" fill="%s"><title>%s</title></path>`,
+				cx, cy, cx+rad*math.Cos(angle), cy+rad*math.Sin(angle), rad, rad, large, cx+rad*math.Cos(end), cy+rad*math.Sin(end), colour, html.EscapeString(tip))
+			angle = end
+		}
+		ly := 30.0 + 20*float64(i)
+		s.printf(`<rect x="280" y="%.1f" width="12" height="12" fill="%s"/>`, ly-10, colour)
+		s.text(300, ly, "start", tip)
+	}
+	return s.html()
+}
+
+// phaseChart draws the average connection phases as one stacked bar, DNS,
+// connect, TLS, then the rest of the time to first byte.
+func phaseChart(r *Report) template.HTML {
+	phases := []struct {
+		name string
+		v    float64
+	}{{"DNS", r.AvgDNS}, {"connect", r.AvgConnect}, {"TLS", r.AvgTLS}, {"TTFB", r.AvgTTFB}}
+	total := 0.0
+	for _, p := range phases {
+		total += p.v
+	}
+	if total == 0 {
+		return emptyChart("No connection timings.")
+	}
+	const barY, barH = 30.0, 40.0
+	s := newSVG(chartW, 150)
+	x := float64(padL)
+	for i, p := range phases {
+		w := float64(plotW) * p.v / total
+		tip := fmt.Sprintf("%s: %s avg", p.name, fmtSeconds(p.v))
+		s.printf(`<rect class="bar" x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"><title>%s</title></rect>`, x, barY, w, barH, palette[i], html.EscapeString(tip))
+		x += w
+		lx := float64(padL + i*130)
+		s.printf(`<rect x="%.1f" y="95" width="12" height="12" fill="%s"/>`, lx, palette[i])
+		s.text(lx+18, 105, "start", tip)
+	}
+	s.text(float64(padL), 130, "start", fmt.Sprintf("Connections reused: %.2f%%", r.ConnReuseRate))
+	return s.html()
+}
+
+// timeseriesCharts draws p50, p95 and p99 latency, and requests per second,
+// over the run.
+func timeseriesCharts(series []Interval) (latency, throughput template.HTML) {
+	p50, p95, p99 := line{name: "p50"}, line{name: "p95"}, line{name: "p99"}
+	rps := line{name: "req/s"}
+	for _, iv := range series {
+		t := (iv.Start + iv.Duration).Seconds()
+		at := fmt.Sprintf("%.1fs", t)
+		p50.points = append(p50.points, point{x: t, y: iv.P50Response, tip: fmt.Sprintf("%s p50 %s", at, fmtSeconds(iv.P50Response))})
+		p95.points = append(p95.points, point{x: t, y: iv.P95Response, tip: fmt.Sprintf("%s p95 %s", at, fmtSeconds(iv.P95Response))})
+		p99.points = append(p99.points, point{x: t, y: iv.P99Response, tip: fmt.Sprintf("%s p99 %s", at, fmtSeconds(iv.P99Response))})
+		rps.points = append(rps.points, point{x: t, y: iv.RequestsPerSec, tip: fmt.Sprintf("%s %.2f req/s, %.2f%% success, %d errors", at, iv.RequestsPerSec, iv.SuccessRate, iv.ErrorCount)})
+	}
+	fx := func(v float64) string { return fmt.Sprintf("%.0fs", v) }
+	return lineChart([]line{p50, p95, p99}, fx, fmtSeconds, "time into the run"),
+		lineChart([]line{rps}, fx, fmtFloat, "time into the run")
+}
@@ -0,0 +1,121 @@
+package reporter
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// junitSuites is the root element of a JUnit XML report.
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+// junitSuite is the testsuite of one report.
+type junitSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Time       string          `xml:"time,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Cases      []junitCase     `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// junitCase is the testcase of one condition.
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the reports to w as a JUnit XML document for CI test
+// dashboards. Each report is a testsuite, as is each endpoint of a traffic
+// mix; each of its threshold and regression conditions is a testcase, failed
+// with the condition's message and actual value when it held. An aborted run
+// adds a failed "aborted" testcase. The suite's properties carry the key
+// figures of the report.
+func WriteJUnit(w io.Writer, reports []*Report) error {
+	doc := junitSuites{Name: "http-runner"}
+	total := 0.0
+	add := func(name string, r *Report) {
+		s := newJUnitSuite(name, r)
+		doc.Suites = append(doc.Suites, s)
+		doc.Tests += s.Tests
+		doc.Failures += s.Failures
+	}
+	for _, r := range reports {
+		total += r.TotalDuration.Seconds()
+		add(r.Label(), r)
+		for i := range r.Endpoints {
+			e := &r.Endpoints[i]
+			add(fmt.Sprintf("%s in %s", e.Label(), r.Label()), e)
+		}
+	}
+	doc.Time = fmt.Sprintf("%.3f", total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// newJUnitSuite builds the testsuite of r, named name.
+func newJUnitSuite(name string, r *Report) junitSuite {
+	s := junitSuite{Name: name, Time: fmt.Sprintf("%.3f", r.TotalDuration.Seconds())}
+	prop := func(key, format string, v interface{}) {
+		s.Properties = append(s.Properties, junitProperty{Name: key, Value: fmt.Sprintf(format, v)})
+	}
+	if r.URL != "" {
+		prop("url", "%s", r.URL)
+	}
+	if r.Method != "" {
+		prop("method", "%s", r.Method)
+	}
+	prop("requests", "%d", r.Count)
+	prop("requests_per_sec", "%.2f", r.RequestsPerSec)
+	prop("success_rate", "%.2f", r.SuccessRate)
+	prop("errors", "%d", r.ErrorCount)
+	prop("p50_sec", "%.6f", r.P50Response)
+	prop("p95_sec", "%.6f", r.P95Response)
+	prop("p99_sec", "%.6f", r.P99Response)
+
+	for _, cond := range reportConditions(name, r) {
+		c := junitCase{Name: cond.Condition, ClassName: name + "." + cond.Kind, Time: "0"}
+		if cond.Failure != "" {
+			c.Failure = &junitFailure{Message: cond.Failure, Type: cond.Kind, Text: cond.Failure}
+			s.Failures++
+		}
+		s.Cases = append(s.Cases, c)
+	}
+	if r.AbortReason != "" {
+		msg := "run aborted: " + r.AbortReason
+		s.Cases = append(s.Cases, junitCase{Name: "aborted", ClassName: name + ".abort", Time: "0", Failure: &junitFailure{Message: msg, Type: "abort", Text: msg}})
+		s.Failures++
+	}
+	s.Tests = len(s.Cases)
+	return s
+}
@@ -0,0 +1,145 @@
+package reporter
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"strings"
+)
+
+// WriteMarkdown writes the reports to w as GitHub-flavoured markdown, for PR
+// comments and CI step summaries: a table of every report's throughput,
+// percentiles, success rate, errors and verdict, then a collapsible section
+// per report with its status codes, error categories, check failures and
+// failed conditions.
+func WriteMarkdown(w io.Writer, reports []*Report) error {
+	var b strings.Builder
+	b.WriteString("## http-runner report\n\n")
+	b.WriteString("| Endpoint | Requests | Req/s | p50 | p95 | p99 | Success | Errors | Result |\n")
+	b.WriteString("|---|---:|---:|---:|---:|---:|---:|---:|:---:|\n")
+	for _, r := range reports {
+		fmt.Fprintf(&b, "| %s | %d | %.2f | %s | %s | %s | %.2f%% | %d | %s |\n",
+			mdCell(r.Label()), r.Count, r.RequestsPerSec, mdSeconds(r.P50Response), mdSeconds(r.P95Response), mdSeconds(r.P99Response),
+			r.SuccessRate, r.ErrorCount, mdVerdict(r.Verdict()))
+	}
+	for _, r := range reports {
+		b.WriteString("\n")
+		writeMarkdownDetails(&b, r)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeMarkdownDetails writes the collapsible section of r.
+func writeMarkdownDetails(b *strings.Builder, r *Report) {
+	fmt.Fprintf(b, "<details>\n<summary><strong>%s</strong>: %d requests in %.2fs", html.EscapeString(r.Label()), r.Count, r.TotalDuration.Seconds())
+	if v := r.Verdict(); v != "" {
+		fmt.Fprintf(b, ", %s", mdVerdict(v))
+	}
+	b.WriteString("</summary>\n\n")
+	if r.Method != "" && r.URL != "" {
+		fmt.Fprintf(b, "`%s %s`\n\n", r.Method, r.URL)
+	}
+	if r.AbortReason != "" {
+		fmt.Fprintf(b, "**Aborted:** `%s`\n\n", r.AbortReason)
+	}
+	for _, list := range []struct {
+		title    string
+		failures []string
+	}{{"Thresholds failed", r.ThresholdFailures}, {"Regressions against the baseline", r.RegressionFailures}} {
+		if len(list.failures) == 0 {
+			continue
+		}
+		fmt.Fprintf(b, "**%s:**\n\n", list.title)
+		for _, f := range list.failures {
+			fmt.Fprintf(b, "- `%s`\n", f)
+		}
+		b.WriteString("\n")
+	}
+
+	if len(r.StatusCodes) > 0 {
+		codes := make([]int, 0, len(r.StatusCodes))
+		for code := range r.StatusCodes {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		b.WriteString("| Status | Count | Share |\n|---|---:|---:|\n")
+		for _, code := range codes {
+			fmt.Fprintf(b, "| %d | %d | %s |\n", code, r.StatusCodes[code], mdShare(r.StatusCodes[code], r.Count))
+		}
+		b.WriteString("\n")
+	}
+	if len(r.Errors) > 0 {
+		b.WriteString("| Error | Count | Example |\n|---|---:|---|\n")
+		for _, cat := range sortedKeys(r.Errors) {
+			example := ""
+			if samples := r.ErrorSamples[cat]; len(samples) > 0 {
+				example = "`" + mdCell(samples[0]) + "`"
+			}
+			fmt.Fprintf(b, "| %s | %d | %s |\n", cat, r.Errors[cat], example)
+		}
+		b.WriteString("\n")
+	}
+	if len(r.CheckFailures) > 0 {
+		b.WriteString("| Check failed | Responses |\n|---|---:|\n")
+		for _, name := range sortedKeys(r.CheckFailures) {
+			fmt.Fprintf(b, "| %s | %d |\n", mdCell(name), r.CheckFailures[name])
+		}
+		b.WriteString("\n")
+	}
+	if len(r.Endpoints) > 0 {
+		b.WriteString("| Endpoint | Weight | Requests | p50 | p95 | p99 | Success | Errors | Result |\n")
+		b.WriteString("|---|---:|---:|---:|---:|---:|---:|---:|:---:|\n")
+		for i := range r.Endpoints {
+			e := &r.Endpoints[i]
+			fmt.Fprintf(b, "| %s | %d | %d | %s | %s | %s | %.2f%% | %d | %s |\n",
+				mdCell(e.Label()), e.Weight, e.Count, mdSeconds(e.P50Response), mdSeconds(e.P95Response), mdSeconds(e.P99Response),
+				e.SuccessRate, e.ErrorCount, mdVerdict(e.Verdict()))
+		}
+		b.WriteString("\n")
+	}
+	if len(r.Steps) > 0 {
+		b.WriteString("| Step | Requests | p50 | p95 | p99 | Success | Errors |\n")
+		b.WriteString("|---|---:|---:|---:|---:|---:|---:|\n")
+		for _, st := range r.Steps {
+			fmt.Fprintf(b, "| %s | %d | %s | %s | %s | %.2f%% | %d |\n",
+				mdCell(st.Name), st.Count, mdSeconds(st.P50Response), mdSeconds(st.P95Response), mdSeconds(st.P99Response), st.SuccessRate, st.ErrorCount)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("</details>\n")
+}
+
+// mdVerdict marks a verdict so it stands out in a table.
+func mdVerdict(v string) string {
+	switch v {
+	case "pass":
+		return "✅ pass"
+	case "fail":
+		return "❌ fail"
+	default:
+		return "-"
+	}
+}
+
+// mdSeconds formats a duration in seconds in the most readable unit.
+func mdSeconds(v float64) string {
+	if v == 0 {
+		return "-"
+	}
+	return fmtSeconds(v)
+}
+
+func mdShare(n, total int) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f%%", float64(n)/float64(total)*100)
+}
+
+// mdCell escapes text for a table cell, where a pipe would end the cell and a
+// newline the row.
+func mdCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
@@ -1,6 +1,7 @@
 package reporter
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"github.com/idesyatov/http-runner/pkg/color"
@@ -9,36 +10,120 @@ import (
 	"time"
 )
 
-// Report contains all data needed for generating a report.
+// Report contains all data needed for generating a report. For a scenario
+// (Steps set) the metrics describe whole iterations; for a traffic mix
+// (Endpoints set) they cover the requests to every endpoint.
 type Report struct {
-	URL             string            // The URL of the request
-	Method          string            // The HTTP method used
-	Count           int               // The number of requests made
-	Concurrency     int               // The level of concurrency
-	TotalDuration   time.Duration     // The total duration of the request execution
-	RequestsPerSec  float64           // Throughput: requests per second over the whole run
-	TotalBytes      int64             // Total response body bytes read across completed requests
-	BytesPerSec     float64           // Throughput: response body bytes per second over the whole run
-	ParsedHeaders   map[string]string // Headers passed to the request
-	ParsedData      interface{}       // Data passed to the request (arbitrary JSON)
-	AverageResponse float64           // The average response time
-	P50Response     float64           // The 50th percentile (median) response time
-	P90Response     float64           // The 90th percentile response time
-	P95Response     float64           // The 95th percentile response time
-	P99Response     float64           // The 99th percentile response time
-	MinResponse     float64           // The minimum response time
-	MaxResponse     float64           // The maximum response time
-	AvgDNS          float64           // Average DNS resolution time over new connections
-	AvgConnect      float64           // Average TCP connect time over new connections
-	AvgTLS          float64           // Average TLS handshake time over new connections
-	AvgTTFB         float64           // Average time to first response byte over completed requests
-	ConnReuseRate   float64           // Percentage of completed requests served over a reused connection
-	SuccessCount    int               // The count of successful (2xx) responses
-	SuccessRate     float64           // The success rate as a percentage
-	StatusCodes     map[int]int       // A map to store status codes and their counts
-	ErrorCount      int               // The number of requests that failed with a transport error
-	Errors          map[string]int    // Transport errors grouped by category
-	Histogram       []Bucket          // Latency distribution over completed requests
+	Name               string              // Label of the endpoint, scenario or mix (optional for endpoints)
+	URL                string              // The URL of the request
+	Method             string              // The HTTP method used
+	Count              int                 // The number of requests made
+	Concurrency        int                 // The level of concurrency
+	Executor           string              // Scheduling model used for the run ("closed" or "arrival-rate")
+	Rate               int                 // Target requests per second (0 = unlimited)
+	DroppedIterations  int                 // Arrival-rate only: scheduled requests skipped at the in-flight cap
+	LateIterations     int                 // Arrival-rate only: requests started more than one interval behind schedule
+	MissedTicks        int                 // Closed executor only: ticks of the schedule skipped while waiting for a free slot
+	WarmupCount        int                 // Requests sent during the warm-up and left out of the report
+	FeederExhausted    bool                // The run stopped early because the feeder ran out of rows
+	AbortReason        string              // Why the run was aborted early, if it was
+	TotalDuration      time.Duration       // The total duration of the request execution
+	RequestsPerSec     float64             // Throughput: requests per second over the whole run
+	TotalBytes         int64               // Total response body bytes read across completed requests
+	BytesPerSec        float64             // Throughput: response body bytes per second over the whole run
+	ParsedHeaders      map[string]string   // Headers passed to the request
+	ParsedData         interface{}         // Data passed to the request (arbitrary JSON)
+	AverageResponse    float64             // The average response time
+	P50Response        float64             // The 50th percentile (median) response time
+	P90Response        float64             // The 90th percentile response time
+	P95Response        float64             // The 95th percentile response time
+	P99Response        float64             // The 99th percentile response time
+	CorrectedP50       float64             // p50 measured from the scheduled start (coordinated omission corrected)
+	CorrectedP90       float64             // p90 measured from the scheduled start
+	CorrectedP95       float64             // p95 measured from the scheduled start
+	CorrectedP99       float64             // p99 measured from the scheduled start
+	MinResponse        float64             // The minimum response time
+	MaxResponse        float64             // The maximum response time
+	AvgDNS             float64             // Average DNS resolution time over new connections
+	AvgConnect         float64             // Average TCP connect time over new connections
+	AvgTLS             float64             // Average TLS handshake time over new connections
+	AvgTTFB            float64             // Average time to first response byte over completed requests
+	ConnReuseRate      float64             // Percentage of completed requests served over a reused connection
+	SuccessCount       int                 // The count of successful (2xx) responses
+	SuccessRate        float64             // The success rate as a percentage
+	StatusCodes        map[int]int         // A map to store status codes and their counts
+	ErrorCount         int                 // The number of requests that failed with a transport error
+	Errors             map[string]int      // Transport errors grouped by category
+	ErrorSamples       map[string][]string // Category -> the first distinct error messages
+	CheckFailures      map[string]int      // Check name -> responses that failed it
+	Histogram          []Bucket            // Latency distribution over completed requests
+	Stages             []Stage             // Per-stage breakdown of a staged run
+	Timeseries         []Interval          // Per-window snapshots over the run, in order
+	Steps              []Step              // Per-step breakdown of a scenario
+	Weight             int                 // Share of a traffic mix's requests (mix endpoints only)
+	Endpoints          []Report            // Per-endpoint breakdown of a traffic mix
+	Thresholds         []string            // Pass/fail conditions evaluated against the report, as written
+	ThresholdFailures  []string            // The conditions that held, with the actual values
+	Baseline           []Delta             // Change of each metric from a baseline run, if compared with one
+	Regressions        []string            // Regression conditions evaluated against the baseline, as written
+	RegressionFailures []string            // The regression conditions that held, with the values
+}
+
+// Step holds the metrics of one step of a scenario.
+type Step struct {
+	Name               string         // Label of the step
+	Method             string         // The HTTP method used
+	URL                string         // The URL (template) requested
+	Count              int            // Requests sent, one per iteration that reached the step
+	RequestsPerSec     float64        // Requests per second over the run
+	SuccessRate        float64        // Percentage of requests that succeeded (passed their checks and extracted every value)
+	ErrorCount         int            // Transport errors
+	ExtractionFailures int            // Responses from which a value could not be extracted
+	CheckFailures      map[string]int // Check name -> responses that failed it
+	StatusCodes        map[int]int    // Status code -> count
+	AverageResponse    float64        // Average response time
+	P50Response        float64        // 50th percentile response time
+	P95Response        float64        // 95th percentile response time
+	P99Response        float64        // 99th percentile response time
+	MaxResponse        float64        // Maximum response time
+}
+
+// Stage holds the metrics of one stage of a staged run.
+type Stage struct {
+	Start             time.Duration // Offset of the stage from the start of the run
+	Duration          time.Duration // Time the stage actually ran
+	Rate              int           // Target rate at the end of the stage (0 = unlimited)
+	Concurrency       int           // Concurrency in effect during the stage
+	Count             int           // Requests launched in the stage
+	DroppedIterations int           // Arrival-rate only: scheduled requests skipped in the stage
+	LateIterations    int           // Arrival-rate only: requests of the stage started over an interval late
+	MissedTicks       int           // Closed executor only: ticks of the stage skipped while waiting for a free slot
+	RequestsPerSec    float64       // Launched requests per second over the stage
+	SuccessRate       float64       // Percentage of launched requests that got a 2xx
+	ErrorCount        int           // Transport errors
+	AverageResponse   float64       // Average response time
+	P50Response       float64       // 50th percentile response time
+	P95Response       float64       // 95th percentile response time
+	P99Response       float64       // 99th percentile response time
+	MaxResponse       float64       // Maximum response time
+}
+
+// Interval is a snapshot of one timeseries window; requests belong to the
+// window they finished in.
+type Interval struct {
+	Start             time.Duration // Offset of the window from the start of the run
+	Duration          time.Duration // Width of the window
+	Count             int           // Requests that finished in the window
+	DroppedIterations int           // Arrival-rate only: scheduled requests skipped in the window
+	LateIterations    int           // Arrival-rate only: requests started over an interval late in the window
+	MissedTicks       int           // Closed executor only: ticks skipped in the window while waiting for a free slot
+	RequestsPerSec    float64       // Finished requests per second
+	SuccessRate       float64       // Percentage of finished requests that got a 2xx
+	ErrorCount        int           // Transport errors
+	P50Response       float64       // 50th percentile response time
+	P95Response       float64       // 95th percentile response time
+	P99Response       float64       // 99th percentile response time
+	InFlight          int           // Requests in flight at the end of the window
 }
 
 // Bucket is one bar of the latency histogram: [Start, End] seconds and how many
@@ -49,10 +134,45 @@ type Bucket struct {
 	Count int     // Number of completed requests in this range
 }
 
+// Label names the report's endpoint or scenario in messages: its name if it
+// has one, otherwise its URL.
+func (r *Report) Label() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return r.URL
+}
+
+// Verdict is "pass" or "fail" against the report's thresholds and regression
+// conditions, or empty if it has none. An aborted run always fails.
+func (r *Report) Verdict() string {
+	switch {
+	case r.AbortReason != "" || len(r.ThresholdFailures) > 0 || len(r.RegressionFailures) > 0:
+		return "fail"
+	case len(r.Thresholds) == 0 && len(r.Regressions) == 0:
+		return ""
+	default:
+		return "pass"
+	}
+}
+
 // Generate outputs the report to the console.
 func (r *Report) Generate() {
-	fmt.Printf("Request URL: %s\n", color.Colorize(color.Green, r.URL))
-	fmt.Printf("Request Method: %s\n", r.Method)
+	// A scenario has no single URL: its requests are listed under Steps, and
+	// the figures below are per iteration.
+	// Nor does a traffic mix, whose endpoints are listed under Endpoints.
+	if len(r.Steps) > 0 {
+		fmt.Printf("Scenario: %s\n", color.Colorize(color.Green, r.Name))
+		fmt.Printf("Iteration Count: %d (%d steps each)\n", r.Count, len(r.Steps))
+	} else if len(r.Endpoints) > 0 {
+		fmt.Printf("Traffic Mix: %s (%d endpoints)\n", color.Colorize(color.Green, r.Name), len(r.Endpoints))
+	} else {
+		if r.Name != "" {
+			fmt.Printf("Request Name: %s\n", r.Name)
+		}
+		fmt.Printf("Request URL: %s\n", color.Colorize(color.Green, r.URL))
+		fmt.Printf("Request Method: %s\n", r.Method)
+	}
 
 	// Output headers if they exist
 	if len(r.ParsedHeaders) > 0 {
@@ -68,8 +188,34 @@ func (r *Report) Generate() {
 			fmt.Printf("  %s\n", b)
 		}
 	}
-	fmt.Printf("Request Count: %d\n", r.Count)
-	fmt.Printf("Request Concurrency: %d\n", r.Concurrency)
+	if len(r.Steps) == 0 {
+		fmt.Printf("Request Count: %d\n", r.Count)
+	}
+	// A report rebuilt from a results log does not know the concurrency.
+	if r.Concurrency > 0 {
+		fmt.Printf("Request Concurrency: %d\n", r.Concurrency)
+	}
+	if r.WarmupCount > 0 {
+		fmt.Printf("Warm-up Requests Discarded: %d\n", r.WarmupCount)
+	}
+	if r.FeederExhausted {
+		fmt.Println("Feeder exhausted: the run stopped early")
+	}
+	if r.AbortReason != "" {
+		fmt.Printf("Aborted: %s\n", color.Colorize(color.Red, r.AbortReason))
+	}
+	// The open model keeps to its schedule by dropping what it cannot start, so
+	// the drops are part of the result, not a footnote.
+	if r.Executor == "arrival-rate" {
+		fmt.Printf("Executor: arrival-rate (%d req/s)\n", r.Rate)
+		fmt.Printf("Dropped Iterations: %d\n", r.DroppedIterations)
+		fmt.Printf("Late Iterations: %d\n", r.LateIterations)
+	}
+	// The closed model skips the ticks it cannot keep up with instead, which
+	// lowers the rate actually sent.
+	if r.MissedTicks > 0 {
+		fmt.Printf("Missed Ticks: %d\n", r.MissedTicks)
+	}
 	fmt.Printf("Requests/sec: %.2f\n", r.RequestsPerSec)
 	fmt.Printf("Bytes/sec: %.2f (%d total)\n", r.BytesPerSec, r.TotalBytes)
 	fmt.Printf("Average Response Time: %.6f seconds\n", r.AverageResponse)
@@ -80,6 +226,17 @@ func (r *Report) Generate() {
 	fmt.Printf("Minimum Response Time: %.6f seconds\n", r.MinResponse)
 	fmt.Printf("Maximum Response Time: %.6f seconds\n", r.MaxResponse)
 
+	// With a rate every request has a scheduled start; latency measured from it
+	// includes time queued inside the generator. Without a rate the corrected
+	// values equal the plain ones, so they are not repeated.
+	if r.Rate > 0 {
+		fmt.Println("Corrected for coordinated omission (from scheduled start):")
+		fmt.Printf("  p50: %.6f seconds\n", r.CorrectedP50)
+		fmt.Printf("  p90: %.6f seconds\n", r.CorrectedP90)
+		fmt.Printf("  p95: %.6f seconds\n", r.CorrectedP95)
+		fmt.Printf("  p99: %.6f seconds\n", r.CorrectedP99)
+	}
+
 	// Connection phase breakdown (averages). DNS/connect/TLS are zero when every
 	// request reused a pooled connection.
 	fmt.Println("Latency breakdown (avg):")
@@ -113,6 +270,23 @@ func (r *Report) Generate() {
 		sort.Strings(cats)
 		for _, cat := range cats {
 			fmt.Printf("  - %s: %d\n", cat, r.Errors[cat])
+			for _, msg := range r.ErrorSamples[cat] {
+				fmt.Printf("      e.g. %s\n", msg)
+			}
+		}
+	}
+
+	// Output check failures by check, if any. They are responses that arrived
+	// but are not counted as successes.
+	if len(r.CheckFailures) > 0 {
+		fmt.Println("Check failures:")
+		names := make([]string, 0, len(r.CheckFailures))
+		for name := range r.CheckFailures {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("  - %s: %d\n", name, r.CheckFailures[name])
 		}
 	}
 
@@ -136,6 +310,113 @@ func (r *Report) Generate() {
 		}
 	}
 
+	// Per-stage breakdown, one row per stage, so the point where latency starts
+	// to degrade during a ramp stands out.
+	if len(r.Stages) > 0 {
+		fmt.Println("Stages:")
+		fmt.Printf("  %-3s %-9s %-9s %-8s %-6s %-8s %-10s %-8s %-7s %-10s %-10s %s\n",
+			"#", "start", "duration", "target", "conc", "sent", "req/s", "success", "errors", "p50", "p95", "p99")
+		// A rate of 0 is a ramp down in a rated profile but "unlimited" in a
+		// concurrency-only one.
+		rated := r.Rate > 0
+		for _, st := range r.Stages {
+			rated = rated || st.Rate > 0
+		}
+		for i, st := range r.Stages {
+			target := "-"
+			if rated {
+				target = fmt.Sprintf("%d/s", st.Rate)
+			}
+			fmt.Printf("  %-3d %-9s %-9s %-8s %-6d %-8d %-10.2f %-8s %-7d %-10.6f %-10.6f %.6f\n",
+				i+1, st.Start, st.Duration.Round(time.Millisecond), target, st.Concurrency, st.Count,
+				st.RequestsPerSec, fmt.Sprintf("%.2f%%", st.SuccessRate), st.ErrorCount,
+				st.P50Response, st.P95Response, st.P99Response)
+		}
+	}
+
+	// Per-step breakdown of a scenario. Iterations stop at their first failed
+	// step, so later steps can see fewer requests than earlier ones.
+	if len(r.Steps) > 0 {
+		fmt.Println("Steps:")
+		fmt.Printf("  %-3s %-24s %-8s %-10s %-8s %-7s %-7s %-8s %-10s %-10s %s\n",
+			"#", "name", "sent", "req/s", "success", "errors", "checks", "extract", "p50", "p95", "p99")
+		for i, st := range r.Steps {
+			checks := 0
+			for _, n := range st.CheckFailures {
+				checks += n
+			}
+			fmt.Printf("  %-3d %-24s %-8d %-10.2f %-8s %-7d %-7d %-8d %-10.6f %-10.6f %.6f\n",
+				i+1, st.Name, st.Count, st.RequestsPerSec, fmt.Sprintf("%.2f%%", st.SuccessRate),
+				st.ErrorCount, checks, st.ExtractionFailures, st.P50Response, st.P95Response, st.P99Response)
+		}
+	}
+
+	// Per-endpoint breakdown of a traffic mix. The share is of the requests
+	// actually sent, to compare against the configured weights.
+	if len(r.Endpoints) > 0 {
+		weights := 0
+		for _, ep := range r.Endpoints {
+			weights += ep.Weight
+		}
+		fmt.Println("Endpoints:")
+		fmt.Printf("  %-3s %-7s %-8s %-8s %-10s %-8s %-7s %-10s %-10s %-10s %s\n",
+			"#", "weight", "share", "sent", "req/s", "success", "errors", "p50", "p95", "p99", "endpoint")
+		for i, ep := range r.Endpoints {
+			share := 0.0
+			if r.Count > 0 {
+				share = float64(ep.Count) / float64(r.Count) * 100
+			}
+			label := ep.Method + " " + ep.URL
+			if ep.Name != "" {
+				label = ep.Name
+			}
+			fmt.Printf("  %-3d %-7s %-8s %-8d %-10.2f %-8s %-7d %-10.6f %-10.6f %-10.6f %s\n",
+				i+1, fmt.Sprintf("%d/%d", ep.Weight, weights), fmt.Sprintf("%.2f%%", share), ep.Count,
+				ep.RequestsPerSec, fmt.Sprintf("%.2f%%", ep.SuccessRate), ep.ErrorCount,
+				ep.P50Response, ep.P95Response, ep.P99Response, label)
+		}
+	}
+
+	// Verdict against the thresholds, with the conditions that failed.
+	switch {
+	case len(r.Thresholds) == 0:
+	case len(r.ThresholdFailures) == 0:
+		fmt.Printf("Thresholds: %s (%d conditions)\n", color.Colorize(color.Green, "PASS"), len(r.Thresholds))
+	default:
+		fmt.Printf("Thresholds: %s (%d of %d conditions)\n", color.Colorize(color.Red, "FAIL"), len(r.ThresholdFailures), len(r.Thresholds))
+		for _, f := range r.ThresholdFailures {
+			fmt.Printf("  - %s\n", f)
+		}
+	}
+
+	// Change of each metric from the baseline run, then the verdict against
+	// the regression conditions.
+	if len(r.Baseline) > 0 {
+		fmt.Println("Compared with baseline:")
+		width := 8
+		for _, d := range r.Baseline {
+			width = max(width, len(d.Metric))
+		}
+		fmt.Printf("  %-*s %14s %14s %16s %10s\n", width, "metric", "baseline", "current", "change", "%")
+		for _, d := range r.Baseline {
+			pct := "-"
+			if p, ok := d.Percent(); ok {
+				pct = fmt.Sprintf("%+.2f%%", p)
+			}
+			fmt.Printf("  %-*s %14s %14s %16s %10s\n", width, d.Metric, d.format(d.Baseline, false), d.format(d.Current, false), d.format(d.Change(), true), pct)
+		}
+	}
+	switch {
+	case len(r.Regressions) == 0:
+	case len(r.RegressionFailures) == 0:
+		fmt.Printf("Regressions: %s (%d conditions)\n", color.Colorize(color.Green, "PASS"), len(r.Regressions))
+	default:
+		fmt.Printf("Regressions: %s (%d of %d conditions)\n", color.Colorize(color.Red, "FAIL"), len(r.RegressionFailures), len(r.Regressions))
+		for _, f := range r.RegressionFailures {
+			fmt.Printf("  - %s\n", f)
+		}
+	}
+
 	// Output total execution time
 	fmt.Printf("Total Duration: %.6f seconds\n\n", r.TotalDuration.Seconds())
 }
@@ -143,34 +424,117 @@ func (r *Report) Generate() {
 // jsonReport is the machine-readable shape of a report, with durations as
 // seconds and stable field names.
 type jsonReport struct {
-	URL                string            `json:"url"`
-	Method             string            `json:"method"`
-	Count              int               `json:"count"`
-	Concurrency        int               `json:"concurrency"`
-	TotalDurationSec   float64           `json:"total_duration_sec"`
-	RequestsPerSec     float64           `json:"requests_per_sec"`
-	TotalBytes         int64             `json:"total_bytes"`
-	BytesPerSec        float64           `json:"bytes_per_sec"`
-	Headers            map[string]string `json:"headers,omitempty"`
-	Data               interface{}       `json:"data,omitempty"`
-	AverageResponseSec float64           `json:"average_response_sec"`
-	P50Sec             float64           `json:"p50_sec"`
-	P90Sec             float64           `json:"p90_sec"`
-	P95Sec             float64           `json:"p95_sec"`
-	P99Sec             float64           `json:"p99_sec"`
-	MinSec             float64           `json:"min_sec"`
-	MaxSec             float64           `json:"max_sec"`
-	AvgDNSSec          float64           `json:"avg_dns_sec"`
-	AvgConnectSec      float64           `json:"avg_connect_sec"`
-	AvgTLSSec          float64           `json:"avg_tls_sec"`
-	AvgTTFBSec         float64           `json:"avg_ttfb_sec"`
-	ConnReuseRate      float64           `json:"conn_reuse_rate"`
-	SuccessCount       int               `json:"success_count"`
-	SuccessRate        float64           `json:"success_rate"`
-	StatusCodes        map[int]int       `json:"status_codes,omitempty"`
-	ErrorCount         int               `json:"error_count"`
-	Errors             map[string]int    `json:"errors,omitempty"`
-	Histogram          []jsonBucket      `json:"histogram,omitempty"`
+	Name               string              `json:"name,omitempty"`
+	URL                string              `json:"url"`
+	Method             string              `json:"method"`
+	Count              int                 `json:"count"`
+	Concurrency        int                 `json:"concurrency"`
+	Executor           string              `json:"executor,omitempty"`
+	Rate               int                 `json:"rate,omitempty"`
+	DroppedIterations  int                 `json:"dropped_iterations"`
+	LateIterations     int                 `json:"late_iterations"`
+	MissedTicks        int                 `json:"missed_ticks"`
+	WarmupCount        int                 `json:"warmup_count"`
+	FeederExhausted    bool                `json:"feeder_exhausted,omitempty"`
+	Aborted            bool                `json:"aborted,omitempty"`
+	AbortReason        string              `json:"abort_reason,omitempty"`
+	TotalDurationSec   float64             `json:"total_duration_sec"`
+	RequestsPerSec     float64             `json:"requests_per_sec"`
+	TotalBytes         int64               `json:"total_bytes"`
+	BytesPerSec        float64             `json:"bytes_per_sec"`
+	Headers            map[string]string   `json:"headers,omitempty"`
+	Data               interface{}         `json:"data,omitempty"`
+	AverageResponseSec float64             `json:"average_response_sec"`
+	P50Sec             float64             `json:"p50_sec"`
+	P90Sec             float64             `json:"p90_sec"`
+	P95Sec             float64             `json:"p95_sec"`
+	P99Sec             float64             `json:"p99_sec"`
+	CorrectedP50Sec    float64             `json:"corrected_p50_sec"`
+	CorrectedP90Sec    float64             `json:"corrected_p90_sec"`
+	CorrectedP95Sec    float64             `json:"corrected_p95_sec"`
+	CorrectedP99Sec    float64             `json:"corrected_p99_sec"`
+	MinSec             float64             `json:"min_sec"`
+	MaxSec             float64             `json:"max_sec"`
+	AvgDNSSec          float64             `json:"avg_dns_sec"`
+	AvgConnectSec      float64             `json:"avg_connect_sec"`
+	AvgTLSSec          float64             `json:"avg_tls_sec"`
+	AvgTTFBSec         float64             `json:"avg_ttfb_sec"`
+	ConnReuseRate      float64             `json:"conn_reuse_rate"`
+	SuccessCount       int                 `json:"success_count"`
+	SuccessRate        float64             `json:"success_rate"`
+	StatusCodes        map[int]int         `json:"status_codes,omitempty"`
+	ErrorCount         int                 `json:"error_count"`
+	Errors             map[string]int      `json:"errors,omitempty"`
+	ErrorSamples       map[string][]string `json:"error_samples,omitempty"`
+	CheckFailures      map[string]int      `json:"check_failures,omitempty"`
+	Histogram          []jsonBucket        `json:"histogram,omitempty"`
+	Stages             []jsonStage         `json:"stages,omitempty"`
+	Timeseries         []jsonInterval      `json:"timeseries,omitempty"`
+	Steps              []jsonStep          `json:"steps,omitempty"`
+	Weight             int                 `json:"weight,omitempty"`
+	Endpoints          []jsonReport        `json:"endpoints,omitempty"`
+	Thresholds         []string            `json:"thresholds,omitempty"`
+	ThresholdFailures  []string            `json:"threshold_failures,omitempty"`
+	Baseline           []jsonDelta         `json:"baseline,omitempty"`
+	Regressions        []string            `json:"regressions,omitempty"`
+	RegressionFailures []string            `json:"regression_failures,omitempty"`
+	Verdict            string              `json:"verdict,omitempty"`
+}
+
+// jsonStep is the machine-readable shape of a scenario step.
+type jsonStep struct {
+	Name               string         `json:"name"`
+	Method             string         `json:"method"`
+	URL                string         `json:"url"`
+	Count              int            `json:"count"`
+	RequestsPerSec     float64        `json:"requests_per_sec"`
+	SuccessRate        float64        `json:"success_rate"`
+	ErrorCount         int            `json:"error_count"`
+	ExtractionFailures int            `json:"extraction_failures"`
+	CheckFailures      map[string]int `json:"check_failures,omitempty"`
+	StatusCodes        map[int]int    `json:"status_codes,omitempty"`
+	AverageResponseSec float64        `json:"average_response_sec"`
+	P50Sec             float64        `json:"p50_sec"`
+	P95Sec             float64        `json:"p95_sec"`
+	P99Sec             float64        `json:"p99_sec"`
+	MaxSec             float64        `json:"max_sec"`
+}
+
+// jsonStage is the machine-readable shape of a stage breakdown.
+type jsonStage struct {
+	StartSec           float64 `json:"start_sec"`
+	DurationSec        float64 `json:"duration_sec"`
+	TargetRate         int     `json:"target_rate"`
+	Concurrency        int     `json:"concurrency"`
+	Count              int     `json:"count"`
+	DroppedIterations  int     `json:"dropped_iterations"`
+	LateIterations     int     `json:"late_iterations"`
+	MissedTicks        int     `json:"missed_ticks"`
+	RequestsPerSec     float64 `json:"requests_per_sec"`
+	SuccessRate        float64 `json:"success_rate"`
+	ErrorCount         int     `json:"error_count"`
+	AverageResponseSec float64 `json:"average_response_sec"`
+	P50Sec             float64 `json:"p50_sec"`
+	P95Sec             float64 `json:"p95_sec"`
+	P99Sec             float64 `json:"p99_sec"`
+	MaxSec             float64 `json:"max_sec"`
+}
+
+// jsonInterval is the machine-readable shape of a timeseries window.
+type jsonInterval struct {
+	StartSec          float64 `json:"start_sec"`
+	DurationSec       float64 `json:"duration_sec"`
+	Count             int     `json:"count"`
+	DroppedIterations int     `json:"dropped_iterations"`
+	LateIterations    int     `json:"late_iterations"`
+	MissedTicks       int     `json:"missed_ticks"`
+	RequestsPerSec    float64 `json:"requests_per_sec"`
+	SuccessRate       float64 `json:"success_rate"`
+	ErrorCount        int     `json:"error_count"`
+	P50Sec            float64 `json:"p50_sec"`
+	P95Sec            float64 `json:"p95_sec"`
+	P99Sec            float64 `json:"p99_sec"`
+	InFlight          int     `json:"in_flight"`
 }
 
 // jsonBucket is the machine-readable shape of a histogram bucket.
@@ -182,15 +546,111 @@ type jsonBucket struct {
 
 // JSON returns the report marshalled as indented JSON.
 func (r *Report) JSON() ([]byte, error) {
+	return marshalIndent(r.toJSON())
+}
+
+// marshalIndent marshals v as indented JSON, leaving <, > and & as they are:
+// thresholds such as "p99>300ms" are meant to be read.
+func marshalIndent(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// toJSON converts the report, and those of a mix's endpoints, to its
+// machine-readable shape.
+func (r *Report) toJSON() jsonReport {
 	var buckets []jsonBucket
 	for _, b := range r.Histogram {
 		buckets = append(buckets, jsonBucket{StartSec: b.Start, EndSec: b.End, Count: b.Count})
 	}
-	return json.MarshalIndent(jsonReport{
+	var stages []jsonStage
+	for _, st := range r.Stages {
+		stages = append(stages, jsonStage{
+			StartSec:           st.Start.Seconds(),
+			DurationSec:        st.Duration.Seconds(),
+			TargetRate:         st.Rate,
+			Concurrency:        st.Concurrency,
+			Count:              st.Count,
+			DroppedIterations:  st.DroppedIterations,
+			LateIterations:     st.LateIterations,
+			MissedTicks:        st.MissedTicks,
+			RequestsPerSec:     st.RequestsPerSec,
+			SuccessRate:        st.SuccessRate,
+			ErrorCount:         st.ErrorCount,
+			AverageResponseSec: st.AverageResponse,
+			P50Sec:             st.P50Response,
+			P95Sec:             st.P95Response,
+			P99Sec:             st.P99Response,
+			MaxSec:             st.MaxResponse,
+		})
+	}
+	var series []jsonInterval
+	for _, iv := range r.Timeseries {
+		series = append(series, jsonInterval{
+			StartSec:          iv.Start.Seconds(),
+			DurationSec:       iv.Duration.Seconds(),
+			Count:             iv.Count,
+			DroppedIterations: iv.DroppedIterations,
+			LateIterations:    iv.LateIterations,
+			MissedTicks:       iv.MissedTicks,
+			RequestsPerSec:    iv.RequestsPerSec,
+			SuccessRate:       iv.SuccessRate,
+			ErrorCount:        iv.ErrorCount,
+			P50Sec:            iv.P50Response,
+			P95Sec:            iv.P95Response,
+			P99Sec:            iv.P99Response,
+			InFlight:          iv.InFlight,
+		})
+	}
+	var steps []jsonStep
+	for _, st := range r.Steps {
+		steps = append(steps, jsonStep{
+			Name:               st.Name,
+			Method:             st.Method,
+			URL:                st.URL,
+			Count:              st.Count,
+			RequestsPerSec:     st.RequestsPerSec,
+			SuccessRate:        st.SuccessRate,
+			ErrorCount:         st.ErrorCount,
+			ExtractionFailures: st.ExtractionFailures,
+			CheckFailures:      st.CheckFailures,
+			StatusCodes:        st.StatusCodes,
+			AverageResponseSec: st.AverageResponse,
+			P50Sec:             st.P50Response,
+			P95Sec:             st.P95Response,
+			P99Sec:             st.P99Response,
+			MaxSec:             st.MaxResponse,
+		})
+	}
+	var endpoints []jsonReport
+	for i := range r.Endpoints {
+		endpoints = append(endpoints, r.Endpoints[i].toJSON())
+	}
+	var baseline []jsonDelta
+	for _, d := range r.Baseline {
+		baseline = append(baseline, d.toJSON())
+	}
+	return jsonReport{
+		Name:               r.Name,
 		URL:                r.URL,
 		Method:             r.Method,
 		Count:              r.Count,
 		Concurrency:        r.Concurrency,
+		Executor:           r.Executor,
+		Rate:               r.Rate,
+		DroppedIterations:  r.DroppedIterations,
+		LateIterations:     r.LateIterations,
+		MissedTicks:        r.MissedTicks,
+		WarmupCount:        r.WarmupCount,
+		FeederExhausted:    r.FeederExhausted,
+		Aborted:            r.AbortReason != "",
+		AbortReason:        r.AbortReason,
 		TotalDurationSec:   r.TotalDuration.Seconds(),
 		RequestsPerSec:     r.RequestsPerSec,
 		TotalBytes:         r.TotalBytes,
@@ -202,6 +662,10 @@ func (r *Report) JSON() ([]byte, error) {
 		P90Sec:             r.P90Response,
 		P95Sec:             r.P95Response,
 		P99Sec:             r.P99Response,
+		CorrectedP50Sec:    r.CorrectedP50,
+		CorrectedP90Sec:    r.CorrectedP90,
+		CorrectedP95Sec:    r.CorrectedP95,
+		CorrectedP99Sec:    r.CorrectedP99,
 		MinSec:             r.MinResponse,
 		MaxSec:             r.MaxResponse,
 		AvgDNSSec:          r.AvgDNS,
@@ -214,8 +678,21 @@ func (r *Report) JSON() ([]byte, error) {
 		StatusCodes:        r.StatusCodes,
 		ErrorCount:         r.ErrorCount,
 		Errors:             r.Errors,
+		ErrorSamples:       r.ErrorSamples,
+		CheckFailures:      r.CheckFailures,
 		Histogram:          buckets,
-	}, "", "  ")
+		Stages:             stages,
+		Timeseries:         series,
+		Steps:              steps,
+		Weight:             r.Weight,
+		Endpoints:          endpoints,
+		Thresholds:         r.Thresholds,
+		ThresholdFailures:  r.ThresholdFailures,
+		Baseline:           baseline,
+		Regressions:        r.Regressions,
+		RegressionFailures: r.RegressionFailures,
+		Verdict:            r.Verdict(),
+	}
 }
 
 // GenerateJSON prints the report as JSON to the console.
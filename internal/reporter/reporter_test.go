@@ -2,9 +2,13 @@ package reporter
 
 import (
 	"bytes"
+	"encoding/csv"
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
+	"reflect"
 	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -110,22 +114,36 @@ func TestGenerate(t *testing.T) {
 // TestReportJSON verifies the machine-readable JSON output.
 func TestReportJSON(t *testing.T) {
 	report := Report{
-		URL:             "https://example.com",
-		Method:          "GET",
-		Count:           10,
-		Concurrency:     5,
-		TotalDuration:   time.Second * 5,
-		RequestsPerSec:  20.0,
-		TotalBytes:      1000,
-		BytesPerSec:     200.0,
-		AverageResponse: 0.5,
-		P95Response:     0.9,
-		SuccessCount:    8,
-		SuccessRate:     80.0,
-		StatusCodes:     map[int]int{200: 8, 404: 2},
-		ErrorCount:      1,
-		Errors:          map[string]int{"timeout": 1},
-		Histogram:       []Bucket{{Start: 0.1, End: 0.5, Count: 6}, {Start: 0.5, End: 1.0, Count: 2}},
+		URL:               "https://example.com",
+		Method:            "GET",
+		Count:             10,
+		Concurrency:       5,
+		Executor:          "arrival-rate",
+		Rate:              50,
+		DroppedIterations: 3,
+		LateIterations:    4,
+		WarmupCount:       20,
+		FeederExhausted:   true,
+		TotalDuration:     time.Second * 5,
+		RequestsPerSec:    20.0,
+		TotalBytes:        1000,
+		BytesPerSec:       200.0,
+		AverageResponse:   0.5,
+		P95Response:       0.9,
+		CorrectedP99:      1.5,
+		SuccessCount:      8,
+		SuccessRate:       80.0,
+		StatusCodes:       map[int]int{200: 8, 404: 2},
+		ErrorCount:        1,
+		Errors:            map[string]int{"timeout": 1},
+		ErrorSamples:      map[string][]string{"timeout": {"Get: context deadline exceeded"}},
+		CheckFailures:     map[string]int{"status 200": 2},
+		Histogram:         []Bucket{{Start: 0.1, End: 0.5, Count: 6}, {Start: 0.5, End: 1.0, Count: 2}},
+		Stages:            []Stage{{Start: time.Minute, Duration: 30 * time.Second, Rate: 200, Count: 6000, P99Response: 0.25}},
+		Timeseries: []Interval{
+			{Start: 0, Duration: time.Second, Count: 2, RequestsPerSec: 2, SuccessRate: 100, P99Response: 0.3, InFlight: 1},
+			{Start: time.Second, Duration: time.Second, Count: 8, RequestsPerSec: 8, SuccessRate: 75, ErrorCount: 1, P99Response: 0.9},
+		},
 		ParsedData: map[string]interface{}{
 			"user": map[string]interface{}{"id": 1},
 		},
@@ -161,6 +179,35 @@ func TestReportJSON(t *testing.T) {
 	if first["count"] != float64(6) || first["start_sec"] != 0.1 {
 		t.Errorf("expected first bucket {start 0.1, count 6}, got %v", first)
 	}
+	if out["executor"] != "arrival-rate" || out["dropped_iterations"] != float64(3) || out["late_iterations"] != float64(4) {
+		t.Errorf("expected executor arrival-rate with 3 dropped and 4 late iterations, got %v / %v / %v", out["executor"], out["dropped_iterations"], out["late_iterations"])
+	}
+	if out["warmup_count"] != float64(20) || out["feeder_exhausted"] != true {
+		t.Errorf("expected warmup_count 20 and feeder_exhausted, got %v / %v", out["warmup_count"], out["feeder_exhausted"])
+	}
+	if out["corrected_p99_sec"] != 1.5 {
+		t.Errorf("expected corrected_p99_sec 1.5, got %v", out["corrected_p99_sec"])
+	}
+	stages, ok := out["stages"].([]interface{})
+	if !ok || len(stages) != 1 {
+		t.Fatalf("expected 1 stage, got %v", out["stages"])
+	}
+	stage := stages[0].(map[string]interface{})
+	if stage["start_sec"] != 60.0 || stage["target_rate"] != float64(200) || stage["p99_sec"] != 0.25 {
+		t.Errorf("expected stage {start 60, target 200, p99 0.25}, got %v", stage)
+	}
+	series, ok := out["timeseries"].([]interface{})
+	if !ok || len(series) != 2 {
+		t.Fatalf("expected 2 timeseries windows, got %v", out["timeseries"])
+	}
+	first = series[0].(map[string]interface{})
+	if first["start_sec"] != 0.0 || first["in_flight"] != float64(1) || first["p99_sec"] != 0.3 {
+		t.Errorf("expected first window {start 0, in_flight 1, p99 0.3}, got %v", first)
+	}
+	second := series[1].(map[string]interface{})
+	if second["start_sec"] != 1.0 || second["success_rate"] != 75.0 || second["error_count"] != float64(1) {
+		t.Errorf("expected second window {start 1, success 75, errors 1}, got %v", second)
+	}
 	if out["total_duration_sec"] != 5.0 {
 		t.Errorf("expected total_duration_sec 5, got %v", out["total_duration_sec"])
 	}
@@ -172,6 +219,14 @@ func TestReportJSON(t *testing.T) {
 	if !ok || errs["timeout"] != float64(1) {
 		t.Errorf("expected errors[timeout]=1, got %v", out["errors"])
 	}
+	samples, ok := out["error_samples"].(map[string]interface{})
+	if !ok || len(samples["timeout"].([]interface{})) != 1 {
+		t.Errorf("expected one timeout sample, got %v", out["error_samples"])
+	}
+	checks, ok := out["check_failures"].(map[string]interface{})
+	if !ok || checks["status 200"] != float64(2) {
+		t.Errorf("expected check_failures[status 200]=2, got %v", out["check_failures"])
+	}
 	data, ok := out["data"].(map[string]interface{})
 	if !ok {
 		t.Fatalf("expected data object, got %v", out["data"])
@@ -181,3 +236,523 @@ func TestReportJSON(t *testing.T) {
 		t.Errorf("expected data.user.id=1, got %v", data["user"])
 	}
 }
+
+// TestReportJSON_Scenario verifies the name and per-step breakdown of a
+// scenario in the JSON output.
+func TestReportJSON_Scenario(t *testing.T) {
+	report := Report{
+		Name:        "checkout",
+		Method:      "GET",
+		Count:       4,
+		SuccessRate: 50,
+		Steps: []Step{
+			{Name: "log in", Method: "POST", URL: "https://example.com/login", Count: 4, SuccessRate: 50, ExtractionFailures: 2, CheckFailures: map[string]int{"is json": 1}, StatusCodes: map[int]int{200: 4}, P99Response: 0.2},
+			{Name: "fetch", Method: "GET", URL: "https://example.com/orders/{{.id}}", Count: 2, SuccessRate: 100},
+		},
+	}
+	if report.Label() != "checkout" {
+		t.Errorf("expected the scenario to be labelled by name, got %q", report.Label())
+	}
+
+	b, err := report.JSON()
+	if err != nil {
+		t.Fatalf("JSON() returned error: %v", err)
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if out["name"] != "checkout" {
+		t.Errorf("expected name checkout, got %v", out["name"])
+	}
+	steps, ok := out["steps"].([]interface{})
+	if !ok || len(steps) != 2 {
+		t.Fatalf("expected 2 steps, got %v", out["steps"])
+	}
+	login := steps[0].(map[string]interface{})
+	if login["name"] != "log in" || login["extraction_failures"] != float64(2) || login["p99_sec"] != 0.2 {
+		t.Errorf("expected step {log in, 2 extraction failures, p99 0.2}, got %v", login)
+	}
+	if checks, ok := login["check_failures"].(map[string]interface{}); !ok || checks["is json"] != float64(1) {
+		t.Errorf("expected step check_failures[is json]=1, got %v", login["check_failures"])
+	}
+	if codes, ok := login["status_codes"].(map[string]interface{}); !ok || codes["200"] != float64(4) {
+		t.Errorf("expected step status_codes[200]=4, got %v", login["status_codes"])
+	}
+	if fetch := steps[1].(map[string]interface{}); fetch["count"] != float64(2) {
+		t.Errorf("expected 2 requests of the second step, got %v", fetch["count"])
+	}
+}
+
+// TestReportJSON_Mix verifies the per-endpoint breakdown of a traffic mix in
+// the JSON output.
+func TestReportJSON_Mix(t *testing.T) {
+	report := Report{
+		Name:        "mix",
+		Count:       10,
+		SuccessRate: 90,
+		Endpoints: []Report{
+			{Name: "items", Method: "GET", URL: "https://example.com/items", Weight: 9, Count: 9, SuccessRate: 100, StatusCodes: map[int]int{200: 9}},
+			{Method: "POST", URL: "https://example.com/orders", Weight: 1, Count: 1, StatusCodes: map[int]int{500: 1}},
+		},
+	}
+
+	b, err := report.JSON()
+	if err != nil {
+		t.Fatalf("JSON() returned error: %v", err)
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if out["name"] != "mix" || out["count"] != float64(10) {
+		t.Errorf("expected mix with count 10, got %v %v", out["name"], out["count"])
+	}
+	if _, ok := out["weight"]; ok {
+		t.Errorf("expected no weight on the mix itself, got %v", out["weight"])
+	}
+	endpoints, ok := out["endpoints"].([]interface{})
+	if !ok || len(endpoints) != 2 {
+		t.Fatalf("expected 2 endpoints, got %v", out["endpoints"])
+	}
+	items := endpoints[0].(map[string]interface{})
+	if items["name"] != "items" || items["weight"] != float64(9) || items["count"] != float64(9) || items["success_rate"] != float64(100) {
+		t.Errorf("expected endpoint {items, weight 9, count 9, 100%%}, got %v", items)
+	}
+	orders := endpoints[1].(map[string]interface{})
+	if codes, ok := orders["status_codes"].(map[string]interface{}); !ok || codes["500"] != float64(1) || orders["url"] != "https://example.com/orders" {
+		t.Errorf("expected endpoint orders with status_codes[500]=1, got %v", orders)
+	}
+}
+
+// TestReportJSON_Verdict verifies the thresholds and pass/fail verdict in the
+// JSON output, and that a report without thresholds has no verdict.
+func TestReportJSON_Verdict(t *testing.T) {
+	report := Report{
+		URL:               "https://example.com/search",
+		Thresholds:        []string{"p99>300ms", "success<99"},
+		ThresholdFailures: []string{"p99>300ms (actual 0.450000s)"},
+	}
+	b, err := report.JSON()
+	if err != nil {
+		t.Fatalf("JSON() returned error: %v", err)
+	}
+	if !strings.Contains(string(b), `"p99>300ms"`) {
+		t.Errorf("expected thresholds to be written unescaped, got %s", b)
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if out["verdict"] != "fail" {
+		t.Errorf("expected verdict fail, got %v", out["verdict"])
+	}
+	if fails, ok := out["threshold_failures"].([]interface{}); !ok || len(fails) != 1 {
+		t.Errorf("expected 1 threshold failure, got %v", out["threshold_failures"])
+	}
+
+	report.ThresholdFailures = nil
+	if report.Verdict() != "pass" {
+		t.Errorf("expected verdict pass, got %q", report.Verdict())
+	}
+	report.Thresholds = nil
+	b, _ = report.JSON()
+	if strings.Contains(string(b), "verdict") {
+		t.Errorf("expected no verdict without thresholds, got %s", b)
+	}
+}
+
+// TestReportJSON_Aborted verifies that an aborted run carries its reason and
+// fails its verdict even without thresholds.
+func TestReportJSON_Aborted(t *testing.T) {
+	report := Report{URL: "https://example.com/soak", Count: 120, AbortReason: "errors>100 (actual 104)"}
+	if report.Verdict() != "fail" {
+		t.Errorf("expected an aborted run to fail, got %q", report.Verdict())
+	}
+	b, err := report.JSON()
+	if err != nil {
+		t.Fatalf("JSON() returned error: %v", err)
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if out["aborted"] != true || out["abort_reason"] != "errors>100 (actual 104)" || out["verdict"] != "fail" {
+		t.Errorf("expected aborted with its reason and a fail verdict, got %v %v %v", out["aborted"], out["abort_reason"], out["verdict"])
+	}
+}
+
+// TestReportJSON_Baseline checks the deltas and regression verdict in the JSON
+// report, and that a zero baseline has no percentage.
+func TestReportJSON_Baseline(t *testing.T) {
+	report := Report{
+		URL: "https://example.com",
+		Baseline: []Delta{
+			{Metric: "p99", Unit: "s", Baseline: 0.2, Current: 0.25},
+			{Metric: "errors", Baseline: 0, Current: 3},
+		},
+		Regressions:        []string{"p99 +10%"},
+		RegressionFailures: []string{"p99 +10% (baseline 0.200000s, actual 0.250000s, +25.00%)"},
+	}
+	if report.Verdict() != "fail" {
+		t.Errorf("expected a regression to fail, got %q", report.Verdict())
+	}
+	b, err := report.JSON()
+	if err != nil {
+		t.Fatalf("JSON() returned error: %v", err)
+	}
+	var out struct {
+		Baseline []map[string]interface{} `json:"baseline"`
+		Verdict  string                   `json:"verdict"`
+	}
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if len(out.Baseline) != 2 || out.Verdict != "fail" {
+		t.Fatalf("expected 2 deltas and a fail verdict, got %s", b)
+	}
+	if p99 := out.Baseline[0]; p99["metric"] != "p99" || fmt.Sprintf("%.2f", p99["change_pct"]) != "25.00" {
+		t.Errorf("unexpected p99 delta: %v", p99)
+	}
+	if _, ok := out.Baseline[1]["change_pct"]; ok || out.Baseline[1]["change"] != 3.0 {
+		t.Errorf("expected errors to change by 3 with no percentage, got %v", out.Baseline[1])
+	}
+}
+
+// TestParseJSON reads back concatenated reports, as older versions wrote for
+// a run of several endpoints, including a mix's breakdown.
+func TestParseJSON(t *testing.T) {
+	a := Report{Name: "a", URL: "https://example.com/a", Count: 10, P99Response: 0.3, SuccessRate: 90, StatusCodes: map[int]int{200: 9, 500: 1}}
+	mix := Report{Name: "mix", Count: 20, RequestsPerSec: 40, Endpoints: []Report{{Name: "b", Weight: 3, ErrorCount: 2}}}
+	var data []byte
+	for _, r := range []Report{a, mix} {
+		b, err := r.JSON()
+		if err != nil {
+			t.Fatalf("JSON() returned error: %v", err)
+		}
+		data = append(append(data, b...), '\n')
+	}
+
+	reports, err := ParseJSON(data)
+	if err != nil {
+		t.Fatalf("ParseJSON: %v", err)
+	}
+	if len(reports) != 2 {
+		t.Fatalf("expected 2 reports, got %d", len(reports))
+	}
+	got := reports[0]
+	if got.Label() != "a" || got.Count != 10 || got.P99Response != 0.3 || got.SuccessRate != 90 || got.StatusCodes[500] != 1 {
+		t.Errorf("report a read back as %+v", got)
+	}
+	if m := reports[1]; m.RequestsPerSec != 40 || len(m.Endpoints) != 1 || m.Endpoints[0].ErrorCount != 2 || m.Endpoints[0].Weight != 3 {
+		t.Errorf("mix read back as %+v", m)
+	}
+
+	for _, bad := range []string{"", "not json", `{"count": "ten"}`} {
+		if _, err := ParseJSON([]byte(bad)); err == nil {
+			t.Errorf("ParseJSON(%q): expected an error", bad)
+		}
+	}
+}
+
+// TestWriteHTML checks that the HTML report has a section and charts per
+// report, escapes labels, and loads nothing over the network.
+func TestWriteHTML(t *testing.T) {
+	a := Report{
+		Name: "<list>", URL: "https://example.com/items", Method: "GET", Count: 100,
+		P50Response: 0.1, P99Response: 0.4, MinResponse: 0.05, MaxResponse: 0.5, AvgTTFB: 0.08,
+		StatusCodes:  map[int]int{200: 95, 503: 3},
+		ErrorCount:   2,
+		Errors:       map[string]int{"timeout": 2},
+		ErrorSamples: map[string][]string{"timeout": {"Get: context deadline exceeded"}},
+		Histogram:    []Bucket{{0.05, 0.2, 80}, {0.2, 0.5, 18}},
+		Timeseries:   []Interval{{Duration: time.Second, RequestsPerSec: 50, P99Response: 0.4}, {Start: time.Second, Duration: time.Second, RequestsPerSec: 48, P99Response: 0.3}},
+		Thresholds:   []string{"p99>0.3"}, ThresholdFailures: []string{"p99>0.3 (actual 0.400000)"},
+	}
+	empty := Report{URL: "https://example.com/empty"}
+	var buf bytes.Buffer
+	if err := WriteHTML(&buf, []*Report{&a, &empty}); err != nil {
+		t.Fatalf("WriteHTML returned error: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{`id="report-1"`, `id="report-2"`, "&lt;list&gt;", "https://example.com/empty", "context deadline exceeded", "p99&gt;0.3 (actual 0.400000)", "Latency over time", "No completed requests."} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected the page to contain %q", want)
+		}
+	}
+	if strings.Contains(out, "<list>") {
+		t.Error("expected the report name to be escaped")
+	}
+	if n := strings.Count(out, "<svg"); n != 6 {
+		t.Errorf("expected 6 charts for the first report and none for the empty one, got %d", n)
+	}
+	for _, asset := range []string{"<script src", "<link", "@import", "url("} {
+		if strings.Contains(out, asset) {
+			t.Errorf("expected a self-contained page, found %q", asset)
+		}
+	}
+}
+
+// TestWriteJUnit checks the testsuites and testcases of the JUnit report: one
+// suite per report and mix endpoint, one case per condition, failed with its
+// message when it held.
+func TestWriteJUnit(t *testing.T) {
+	a := Report{
+		Name: "list", URL: "https://example.com/items", Count: 100, TotalDuration: 2 * time.Second,
+		Thresholds:        []string{"p99>500ms", "success<99"},
+		ThresholdFailures: []string{"p99>500ms (actual 0.612000s)"},
+		AbortReason:       "errors>10 (actual 12)",
+	}
+	mix := Report{
+		Name: "mix", Count: 50,
+		Regressions: []string{"rps -5%"},
+		Endpoints:   []Report{{Name: "view", Thresholds: []string{"errors>0"}, ThresholdFailures: []string{"errors>0 (actual 3)"}}},
+	}
+	var buf bytes.Buffer
+	if err := WriteJUnit(&buf, []*Report{&a, &mix}); err != nil {
+		t.Fatalf("WriteJUnit returned error: %v", err)
+	}
+
+	var doc junitSuites
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("output is not valid XML: %v\n%s", err, buf.String())
+	}
+	if doc.Tests != 5 || doc.Failures != 3 || len(doc.Suites) != 3 {
+		t.Fatalf("expected 3 suites with 5 tests and 3 failures, got %d suites, %d tests, %d failures", len(doc.Suites), doc.Tests, doc.Failures)
+	}
+	list := doc.Suites[0]
+	if list.Name != "list" || list.Time != "2.000" || len(list.Cases) != 3 {
+		t.Fatalf("unexpected suite %+v", list)
+	}
+	if f := list.Cases[0].Failure; f == nil || f.Message != "p99>500ms (actual 0.612000s)" || f.Type != "threshold" {
+		t.Errorf("expected p99 to fail with its actual value, got %+v", f)
+	}
+	if list.Cases[1].Name != "success<99" || list.Cases[1].Failure != nil {
+		t.Errorf("expected success<99 to pass, got %+v", list.Cases[1])
+	}
+	if c := list.Cases[2]; c.Name != "aborted" || c.Failure == nil {
+		t.Errorf("expected a failed aborted case, got %+v", c)
+	}
+	if s := doc.Suites[1]; s.Name != "mix" || len(s.Cases) != 1 || s.Cases[0].ClassName != "mix.regression" || s.Failures != 0 {
+		t.Errorf("unexpected mix suite %+v", s)
+	}
+	if s := doc.Suites[2]; s.Name != "view in mix" || s.Failures != 1 {
+		t.Errorf("unexpected mix endpoint suite %+v", s)
+	}
+}
+
+// TestWriteMarkdown checks the summary table and the collapsible section of
+// each report, and that table cells are escaped.
+func TestWriteMarkdown(t *testing.T) {
+	a := Report{
+		Name: "a|b", URL: "https://example.com/items", Method: "GET", Count: 200, RequestsPerSec: 40,
+		P50Response: 0.012, P95Response: 0.25, P99Response: 1.5, SuccessRate: 97.5,
+		StatusCodes:       map[int]int{200: 195, 503: 3},
+		ErrorCount:        2,
+		Errors:            map[string]int{"timeout": 2},
+		ErrorSamples:      map[string][]string{"timeout": {"Get: context deadline exceeded"}},
+		Thresholds:        []string{"p99>1s"},
+		ThresholdFailures: []string{"p99>1s (actual 1.500000s)"},
+	}
+	b := Report{Name: "health", Count: 10, SuccessRate: 100}
+	var buf bytes.Buffer
+	if err := WriteMarkdown(&buf, []*Report{&a, &b}); err != nil {
+		t.Fatalf("WriteMarkdown returned error: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"| a\\|b | 200 | 40.00 | 12.0ms | 250.0ms | 1.50s | 97.50% | 2 | ❌ fail |",
+		"| health | 10 | 0.00 | - | - | - | 100.00% | 0 | - |",
+		"<summary><strong>a|b</strong>: 200 requests",
+		"- `p99>1s (actual 1.500000s)`",
+		"| 503 | 3 | 1.50% |",
+		"| timeout | 2 | `Get: context deadline exceeded` |",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected the markdown to contain %q, got:\n%s", want, out)
+		}
+	}
+	if n := strings.Count(out, "<details>"); n != 2 {
+		t.Errorf("expected a section per report, got %d", n)
+	}
+}
+
+// TestWriteCSV checks the rows of the CSV report: one per report and mix
+// endpoint, the same width as the header, with status codes and error
+// categories flattened into columns.
+func TestWriteCSV(t *testing.T) {
+	a := Report{
+		URL: "https://example.com/items", Method: "GET", Count: 10, P99Response: 0.25,
+		StatusCodes: map[int]int{200: 6, 503: 2, 418: 1},
+		ErrorCount:  3,
+		Errors:      map[string]int{"timeout": 2, "something new": 1},
+		Thresholds:  []string{"p99>1s", "errors>5"},
+	}
+	mix := Report{Name: "mix", Count: 4, Endpoints: []Report{{Name: "view", Weight: 3, Count: 4}}}
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, []*Report{&a, &mix}, true); err != nil {
+		t.Fatalf("WriteCSV returned error: %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("expected a header and 3 rows, got %d rows", len(rows))
+	}
+	header := rows[0]
+	row := func(i int) map[string]string {
+		m := map[string]string{}
+		for j, col := range header {
+			m[col] = rows[i][j]
+		}
+		return m
+	}
+	first := row(1)
+	for col, want := range map[string]string{
+		"url": "https://example.com/items", "p99_sec": "0.25", "status_2xx": "6", "status_4xx": "1", "status_503": "2",
+		"errors_timeout": "2", "errors_other": "1", "thresholds": "p99>1s; errors>5", "verdict": "pass", "mix": "",
+	} {
+		if first[col] != want {
+			t.Errorf("%s = %q, want %q", col, first[col], want)
+		}
+	}
+	if view := row(3); view["mix"] != "mix" || view["name"] != "view" || view["weight"] != "3" {
+		t.Errorf("unexpected mix endpoint row %v", view)
+	}
+
+	// Every scalar field of the JSON report has a column.
+	cols := map[string]bool{}
+	for _, col := range header {
+		cols[col] = true
+	}
+	typ := reflect.TypeOf(jsonReport{})
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		key := strings.Split(f.Tag.Get("json"), ",")[0]
+		switch f.Type.Kind() {
+		case reflect.Slice, reflect.Map:
+			continue
+		}
+		if !cols[key] {
+			t.Errorf("no column for the JSON field %s", key)
+		}
+	}
+
+	buf.Reset()
+	if err := WriteCSV(&buf, []*Report{&a}, false); err != nil {
+		t.Fatalf("WriteCSV returned error: %v", err)
+	}
+	if strings.HasPrefix(buf.String(), "time,") || strings.Count(buf.String(), "\n") != 1 {
+		t.Errorf("expected a single row without a header, got %q", buf.String())
+	}
+}
+
+// TestWriteJSON checks the JSON document of a run: its metadata, overall
+// verdict, condition outcomes and endpoints, and that ParseJSON reads it back
+// as well as the NDJSON stream.
+func TestWriteJSON(t *testing.T) {
+	a := Report{Name: "list", URL: "https://example.com/items", Count: 10, P99Response: 0.3,
+		Thresholds: []string{"p99>200ms", "errors>0"}, ThresholdFailures: []string{"p99>200ms (actual 0.300000s)"}}
+	mix := Report{Name: "mix", Count: 20, Endpoints: []Report{{Name: "view", Weight: 1, Thresholds: []string{"success<99"}}}}
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	meta := Run{Version: "1.2.3", Start: start, End: start.Add(90 * time.Second), Hostname: "ci-1",
+		ConfigFile: "load.yml", ConfigHash: "abc123", Args: []string{"-config-file", "load.yml"}}
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, meta, []*Report{&a, &mix}, nil); err != nil {
+		t.Fatalf("WriteJSON returned error: %v", err)
+	}
+	var doc struct {
+		Metadata   map[string]interface{}   `json:"metadata"`
+		Verdict    string                   `json:"verdict"`
+		Thresholds []map[string]interface{} `json:"thresholds"`
+		Endpoints  []map[string]interface{} `json:"endpoints"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("output is not one JSON document: %v", err)
+	}
+	m := doc.Metadata
+	if m["tool"] != "http-runner" || m["version"] != "1.2.3" || m["started_at"] != "2024-05-01T12:00:00Z" || m["duration_sec"] != 90.0 ||
+		m["hostname"] != "ci-1" || m["config_sha256"] != "abc123" || len(m["args"].([]interface{})) != 2 {
+		t.Errorf("unexpected metadata %v", m)
+	}
+	if doc.Verdict != "fail" || len(doc.Endpoints) != 2 || doc.Endpoints[1]["name"] != "mix" {
+		t.Errorf("expected a fail verdict over 2 endpoints, got %q and %d", doc.Verdict, len(doc.Endpoints))
+	}
+	if len(doc.Thresholds) != 3 {
+		t.Fatalf("expected 3 condition outcomes, got %v", doc.Thresholds)
+	}
+	if c := doc.Thresholds[0]; c["endpoint"] != "list" || c["passed"] != false || c["message"] != "p99>200ms (actual 0.300000s)" {
+		t.Errorf("unexpected p99 outcome %v", c)
+	}
+	if c := doc.Thresholds[2]; c["endpoint"] != "view in mix" || c["passed"] != true || c["kind"] != "threshold" {
+		t.Errorf("unexpected mix endpoint outcome %v", c)
+	}
+
+	var stream bytes.Buffer
+	for _, r := range []*Report{&a, &mix} {
+		if err := WriteNDJSONReport(&stream, r); err != nil {
+			t.Fatalf("WriteNDJSONReport returned error: %v", err)
+		}
+	}
+	if err := WriteNDJSONSummary(&stream, meta, []*Report{&a, &mix}); err != nil {
+		t.Fatalf("WriteNDJSONSummary returned error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(stream.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], `{"type":"endpoint","name":"list"`) || !strings.HasPrefix(lines[2], `{"type":"summary"`) {
+		t.Errorf("expected 2 endpoint lines and a summary, got:\n%s", stream.String())
+	}
+
+	for name, data := range map[string][]byte{"document": buf.Bytes(), "ndjson": stream.Bytes()} {
+		reports, err := ParseJSON(data)
+		if err != nil {
+			t.Fatalf("ParseJSON(%s): %v", name, err)
+		}
+		if len(reports) != 2 || reports[0].P99Response != 0.3 || len(reports[1].Endpoints) != 1 {
+			t.Errorf("ParseJSON(%s) read back %+v", name, reports)
+		}
+	}
+}
+
+// TestCapacityReportJSON verifies the machine-readable capacity search output.
+func TestCapacityReportJSON(t *testing.T) {
+	report := CapacityReport{
+		URL:        "https://example.com",
+		Method:     "GET",
+		Mode:       "step",
+		SLO:        []string{"p99>300ms"},
+		MinRate:    100,
+		MaxRate:    500,
+		MaxPassing: 200,
+		Steps: []CapacityStep{
+			{Rate: 100, RequestsPerSec: 99.5, P99Response: 0.1},
+			{Rate: 200, RequestsPerSec: 198.2, P99Response: 0.2},
+			{Rate: 300, RequestsPerSec: 240.0, P99Response: 0.6, Failures: []string{"p99>300ms (actual 0.600000s)"}},
+		},
+	}
+
+	b, err := report.JSON()
+	if err != nil {
+		t.Fatalf("JSON() returned error: %v", err)
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if out["max_passing_rate"] != float64(200) {
+		t.Errorf("expected max_passing_rate 200, got %v", out["max_passing_rate"])
+	}
+	steps, ok := out["steps"].([]interface{})
+	if !ok || len(steps) != 3 {
+		t.Fatalf("expected 3 steps, got %v", out["steps"])
+	}
+	last := steps[2].(map[string]interface{})
+	if last["passed"] != false || last["rate"] != float64(300) {
+		t.Errorf("expected the last step to fail at 300, got %v", last)
+	}
+	if first := steps[0].(map[string]interface{}); first["passed"] != true {
+		t.Errorf("expected the first step to pass, got %v", first)
+	}
+}
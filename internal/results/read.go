@@ -0,0 +1,127 @@
+package results
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Bound is one end of a time range over a results log: an instant, or an
+// offset from the start of the first record.
+type Bound struct {
+	At     time.Time     // The instant, if set
+	Offset time.Duration // Otherwise the offset from the start of the log
+	set    bool
+}
+
+// ParseBound parses a bound written as an RFC 3339 time (e.g.
+// 2024-05-01T12:00:00Z) or as an offset (e.g. 90s or 5m). An empty string is
+// no bound.
+func ParseBound(s string) (Bound, error) {
+	if s == "" {
+		return Bound{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		if d < 0 {
+			return Bound{}, fmt.Errorf("negative offset %q", s)
+		}
+		return Bound{Offset: d, set: true}, nil
+	}
+	at, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return Bound{}, fmt.Errorf("%q is neither an offset (e.g. 90s) nor an RFC 3339 time", s)
+	}
+	return Bound{At: at, set: true}, nil
+}
+
+// IsZero reports whether b is no bound.
+func (b Bound) IsZero() bool { return !b.set }
+
+// resolve returns the instant of b in a log starting at origin.
+func (b Bound) resolve(origin time.Time) time.Time {
+	if !b.At.IsZero() {
+		return b.At
+	}
+	return origin.Add(b.Offset)
+}
+
+// Filter selects records of a results log. A zero field selects every record.
+type Filter struct {
+	From      Bound    // Keep requests started at or after this
+	To        Bound    // Keep requests started before this
+	Endpoints []string // Keep requests to these endpoints
+	Statuses  []string // Keep these status codes, or classes such as 5xx; 0 is a transport error
+}
+
+// ValidStatus reports whether s is a status code or a class such as 5xx, as
+// taken by Filter.Statuses.
+func ValidStatus(s string) bool {
+	if len(s) == 3 && s[0] >= '1' && s[0] <= '5' && s[1:] == "xx" {
+		return true
+	}
+	code, err := strconv.Atoi(s)
+	return err == nil && code >= 0 && code <= 999
+}
+
+// match reports whether f selects r; from and to are its bounds resolved, or
+// zero.
+func (f Filter) match(r Record, from, to time.Time) bool {
+	if !from.IsZero() && r.Timestamp.Before(from) {
+		return false
+	}
+	if !to.IsZero() && !r.Timestamp.Before(to) {
+		return false
+	}
+	if len(f.Endpoints) > 0 && !slices.Contains(f.Endpoints, r.Endpoint) {
+		return false
+	}
+	if len(f.Statuses) == 0 {
+		return true
+	}
+	code := strconv.Itoa(r.Status)
+	for _, s := range f.Statuses {
+		if s == code || (strings.HasSuffix(s, "xx") && r.Status/100 == int(s[0]-'0')) {
+			return true
+		}
+	}
+	return false
+}
+
+// Scan reads the records of a results log from r in order and calls fn with
+// each that f selects. Offsets in f count from the start of the first record.
+// It stops at the first line that is not a record.
+func Scan(r io.Reader, f Filter, fn func(Record)) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	var from, to time.Time
+	first := true
+	line := 0
+	for sc.Scan() {
+		line++
+		if len(strings.TrimSpace(sc.Text())) == 0 {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if first {
+			first = false
+			if f.From.set {
+				from = f.From.resolve(rec.Timestamp)
+			}
+			if f.To.set {
+				to = f.To.resolve(rec.Timestamp)
+			}
+		}
+		if f.match(rec, from, to) {
+			fn(rec)
+		}
+	}
+	return sc.Err()
+}
@@ -0,0 +1,86 @@
+package results
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseBound(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want Bound
+	}{
+		{"", Bound{}},
+		{"90s", Bound{Offset: 90 * time.Second, set: true}},
+		{"2024-05-01T12:00:00Z", Bound{At: at, set: true}},
+	}
+	for _, tt := range tests {
+		got, err := ParseBound(tt.in)
+		if err != nil {
+			t.Errorf("ParseBound(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseBound(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+	for _, in := range []string{"-5s", "yesterday", "2024-05-01"} {
+		if _, err := ParseBound(in); err == nil {
+			t.Errorf("ParseBound(%q): expected an error", in)
+		}
+	}
+}
+
+// TestScan checks each filter against a small log, with offsets counted from
+// the first record.
+func TestScan(t *testing.T) {
+	log := `{"timestamp":"2024-05-01T12:00:00Z","endpoint":"a","status":200}
+{"timestamp":"2024-05-01T12:00:01Z","endpoint":"b","status":503}
+
+{"timestamp":"2024-05-01T12:00:02Z","endpoint":"a","status":0,"error":"timeout"}
+{"timestamp":"2024-05-01T12:00:03Z","endpoint":"b","status":201}
+`
+	bound := func(s string) Bound {
+		b, err := ParseBound(s)
+		if err != nil {
+			t.Fatalf("ParseBound(%q): %v", s, err)
+		}
+		return b
+	}
+	tests := []struct {
+		name   string
+		filter Filter
+		want   string // statuses of the records kept
+	}{
+		{"all", Filter{}, "200 503 0 201"},
+		{"from offset", Filter{From: bound("1s")}, "503 0 201"},
+		{"to offset", Filter{To: bound("2s")}, "200 503"},
+		{"from time", Filter{From: bound("2024-05-01T12:00:02Z")}, "0 201"},
+		{"endpoint", Filter{Endpoints: []string{"b"}}, "503 201"},
+		{"status class", Filter{Statuses: []string{"2xx"}}, "200 201"},
+		{"status codes", Filter{Statuses: []string{"503", "0"}}, "503 0"},
+	}
+	for _, tt := range tests {
+		var got []string
+		err := Scan(strings.NewReader(log), tt.filter, func(r Record) {
+			got = append(got, fmt.Sprint(r.Status))
+		})
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("%s: kept %v, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestScan_Invalid(t *testing.T) {
+	err := Scan(strings.NewReader("{\"status\":200}\nnot json\n"), Filter{}, func(Record) {})
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected an error on line 2, got %v", err)
+	}
+}
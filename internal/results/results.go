@@ -0,0 +1,85 @@
+// Package results records every request of a run, one JSON object per line,
+// so that slow or failed requests can be matched with server logs and a run
+// can be analysed after the fact.
+package results
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// queueSize is how many records may wait for the background writer before
+// Write blocks.
+const queueSize = 8192
+
+// Record is the outcome of one request, or of one iteration of a scenario.
+// Durations are in seconds; connection phases that did not happen (e.g. on a
+// reused connection, or after a transport error) are zero.
+type Record struct {
+	Timestamp     time.Time `json:"timestamp"` // When the request started
+	Intended      time.Time `json:"intended"`  // When it was scheduled to start; equals Timestamp without a rate
+	Endpoint      string    `json:"endpoint"`  // Name of the endpoint or scenario, or its URL if unnamed
+	Method        string    `json:"method,omitempty"`
+	URL           string    `json:"url,omitempty"` // The URL requested, after templating
+	LatencySec    float64   `json:"latency_sec"`   // From the start until the response headers arrive
+	DNSSec        float64   `json:"dns_sec"`
+	ConnectSec    float64   `json:"connect_sec"`
+	TLSSec        float64   `json:"tls_sec"`
+	TTFBSec       float64   `json:"ttfb_sec"`
+	Reused        bool      `json:"reused"`                   // The connection came from the idle pool
+	Status        int       `json:"status"`                   // HTTP status code; 0 on a transport error
+	Bytes         int64     `json:"bytes"`                    // Response body bytes read
+	Success       bool      `json:"success"`                  // Counted as a success: expected status, checks passed, values extracted
+	CheckFailures []string  `json:"check_failures,omitempty"` // Names of the checks the response failed
+	Error         string    `json:"error,omitempty"`          // Category of the transport error, if any
+	Message       string    `json:"message,omitempty"`        // Message of the transport error, if any
+}
+
+// Writer writes records as JSON lines from a background goroutine, through a
+// buffer, so that encoding and disk writes do not slow a run down. It is safe
+// for concurrent use.
+type Writer struct {
+	records chan Record
+	done    chan struct{}
+	err     error // First error writing; set by the background goroutine
+}
+
+// NewWriter returns a writer of records to w. It must be closed to flush the
+// records still buffered.
+func NewWriter(w io.Writer) *Writer {
+	rw := &Writer{records: make(chan Record, queueSize), done: make(chan struct{})}
+	go rw.run(w)
+	return rw
+}
+
+// run encodes records as they arrive until the writer is closed. After an
+// error it keeps draining the queue, so Write never blocks for good.
+func (w *Writer) run(out io.Writer) {
+	defer close(w.done)
+	buf := bufio.NewWriterSize(out, 64<<10)
+	enc := json.NewEncoder(buf)
+	for r := range w.records {
+		if w.err == nil {
+			w.err = enc.Encode(r)
+		}
+	}
+	if w.err == nil {
+		w.err = buf.Flush()
+	}
+}
+
+// Write queues r for writing. It blocks only while the queue is full. It must
+// not be called after Close.
+func (w *Writer) Write(r Record) {
+	w.records <- r
+}
+
+// Close writes the records still queued and returns the first error writing
+// any record.
+func (w *Writer) Close() error {
+	close(w.records)
+	<-w.done
+	return w.err
+}
@@ -0,0 +1,67 @@
+package results
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestWriter checks that records written from several goroutines all reach
+// the output, one JSON object per line, once the writer is closed.
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 250; i++ {
+				w.Write(Record{Timestamp: start, Intended: start, Endpoint: "api", LatencySec: 0.25, Status: 200, Success: true})
+			}
+		}()
+	}
+	wg.Wait()
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	lines := 0
+	sc := bufio.NewScanner(&buf)
+	for sc.Scan() {
+		var r Record
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			t.Fatalf("line %d is not a record: %v", lines+1, err)
+		}
+		if r.Endpoint != "api" || r.Status != 200 || r.LatencySec != 0.25 || !r.Timestamp.Equal(start) {
+			t.Fatalf("line %d = %+v", lines+1, r)
+		}
+		lines++
+	}
+	if lines != 1000 {
+		t.Errorf("expected 1000 records, got %d", lines)
+	}
+}
+
+// errWriter fails every write.
+type errWriter struct{}
+
+func (errWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+// TestWriter_Error checks that Close returns a write error, and that writes
+// after one do not block.
+func TestWriter_Error(t *testing.T) {
+	w := NewWriter(errWriter{})
+	for i := 0; i < 2*queueSize; i++ {
+		w.Write(Record{Endpoint: "api"})
+	}
+	if err := w.Close(); err == nil || err.Error() != "disk full" {
+		t.Errorf("expected the write error from Close, got %v", err)
+	}
+}
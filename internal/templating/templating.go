@@ -0,0 +1,491 @@
+// Package templating fills per-request dynamic values into the URL, headers
+// and JSON body of a request, so requests are not byte-identical and do not hit
+// server caches unrealistically.
+//
+// A template is a string with actions in double braces, each calling one
+// function with literal arguments:
+//
+//	{{uuid}}             a random UUID (version 4)
+//	{{randInt 1 1000}}   a random integer in [1, 1000]
+//	{{randString 16}}    a random alphanumeric string of that length
+//	{{seq}}              the request's sequence number (0, 1, 2, ...)
+//	{{now "RFC3339"}}    the current time in a named or Go reference layout
+//	{{env "TOKEN"}}      an environment variable, read once at compile time
+//	{{.name}}            the variable name, e.g. a column of a feeder row
+//
+// Random values come from a generator derived from the seed and the request's
+// sequence number, so a seeded run renders the same values for the same
+// request however requests are scheduled across goroutines.
+package templating
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// layouts maps the names accepted by {{now}} to time layouts. Any other
+// argument is used as a Go reference layout.
+var layouts = map[string]string{
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"RFC1123":     time.RFC1123,
+	"DateTime":    time.DateTime,
+	"DateOnly":    time.DateOnly,
+	"TimeOnly":    time.TimeOnly,
+	"Kitchen":     time.Kitchen,
+}
+
+const alphanumeric = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// Engine compiles request templates. Requests compiled by one engine share
+// its seed but draw from separate random streams.
+type Engine struct {
+	seed    uint64
+	streams atomic.Uint64 // Next stream, one per compiled request
+}
+
+// New returns an engine seeded with seed, or with a random seed if seed is 0.
+func New(seed int64) *Engine {
+	s := uint64(seed)
+	if s == 0 {
+		s = rand.Uint64()
+	}
+	return &Engine{seed: s}
+}
+
+// Request is a compiled request: its URL, headers and data with templates
+// parsed. It is safe for concurrent use.
+type Request struct {
+	url       *template
+	headers   map[string]*template // Header name -> value template
+	data      node
+	templated bool            // Anything at all to render; otherwise Render returns the input
+	vars      map[string]bool // Variables referenced anywhere
+
+	staticURL     string
+	staticHeaders map[string]string
+	staticData    interface{}
+
+	seed, stream uint64
+	seq          *atomic.Int64 // Sequence number of the next render, shared with forks
+}
+
+// forkStream marks the random stream of a fork. Streams are shifted 40 bits
+// into the PCG sequence, leaving 24 bits, of which this is the highest.
+const forkStream = 1 << 23
+
+// Compile parses the templates in url, the header values and every string
+// value of the data tree (maps, slices and strings as decoded from JSON or
+// YAML). Strings without "{{" are used as they are.
+func (e *Engine) Compile(url string, headers map[string]string, data interface{}) (*Request, error) {
+	r := &Request{
+		staticURL:     url,
+		staticHeaders: headers,
+		staticData:    data,
+		seed:          e.seed,
+		stream:        e.streams.Add(1),
+		seq:           new(atomic.Int64),
+	}
+	var err error
+	if r.url, err = parse(url); err != nil {
+		return nil, fmt.Errorf("url: %w", err)
+	}
+	r.templated = r.url != nil
+	r.url.collectVars(r)
+	for k, v := range headers {
+		t, err := parse(v)
+		if err != nil {
+			return nil, fmt.Errorf("header %s: %w", k, err)
+		}
+		if t != nil {
+			if r.headers == nil {
+				r.headers = make(map[string]*template)
+			}
+			r.headers[k] = t
+			r.templated = true
+			t.collectVars(r)
+		}
+	}
+	if r.data, err = compileNode(data, r); err != nil {
+		return nil, fmt.Errorf("data: %w", err)
+	}
+	r.templated = r.templated || r.data != nil
+	return r, nil
+}
+
+// Fork returns a copy of the request that draws its random values from a
+// stream of its own but shares the sequence numbers of r, so that renders of
+// the fork and of r never repeat each other, {{seq}} included.
+func (r *Request) Fork() *Request {
+	return &Request{
+		url:           r.url,
+		headers:       r.headers,
+		data:          r.data,
+		templated:     r.templated,
+		vars:          r.vars,
+		staticURL:     r.staticURL,
+		staticHeaders: r.staticHeaders,
+		staticData:    r.staticData,
+		seed:          r.seed,
+		stream:        r.stream | forkStream,
+		seq:           r.seq,
+	}
+}
+
+// Vars returns the names of the variables the request references, sorted.
+func (r *Request) Vars() []string {
+	names := make([]string, 0, len(r.vars))
+	for name := range r.vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Render returns the URL, headers and data of the next request with every
+// template evaluated and variables taken from vars (a missing one renders
+// empty). Parts without templates are returned as compiled and must not be
+// modified.
+func (r *Request) Render(vars map[string]string) (url string, headers map[string]string, data interface{}) {
+	url, headers, data = r.staticURL, r.staticHeaders, r.staticData
+	if !r.templated {
+		return url, headers, data
+	}
+	n := r.seq.Add(1) - 1
+	s := &scope{seq: n, rng: rand.New(rand.NewPCG(r.seed, r.stream<<40^uint64(n))), vars: vars}
+	if r.url != nil {
+		url = r.url.render(s)
+	}
+	if r.headers != nil {
+		headers = make(map[string]string, len(r.staticHeaders))
+		for k, v := range r.staticHeaders {
+			if t, ok := r.headers[k]; ok {
+				v = t.render(s)
+			}
+			headers[k] = v
+		}
+	}
+	if r.data != nil {
+		data = r.data.render(s)
+	}
+	return url, headers, data
+}
+
+// scope holds what one render may draw on.
+type scope struct {
+	seq  int64
+	rng  *rand.Rand
+	vars map[string]string
+}
+
+// template is a parsed string: literal text interleaved with actions.
+type template struct {
+	parts []part
+}
+
+// part is either literal text or an action to evaluate.
+type part struct {
+	text string
+	eval func(s *scope) string // nil for literal text
+	v    string                // Variable the action reads, if any
+}
+
+// collectVars records the variables t references in r. t may be nil.
+func (t *template) collectVars(r *Request) {
+	if t == nil {
+		return
+	}
+	for _, p := range t.parts {
+		if p.v == "" {
+			continue
+		}
+		if r.vars == nil {
+			r.vars = make(map[string]bool)
+		}
+		r.vars[p.v] = true
+	}
+}
+
+func (t *template) render(s *scope) string {
+	var b strings.Builder
+	for _, p := range t.parts {
+		if p.eval == nil {
+			b.WriteString(p.text)
+		} else {
+			b.WriteString(p.eval(s))
+		}
+	}
+	return b.String()
+}
+
+// parse parses src, returning nil if it holds no action.
+func parse(src string) (*template, error) {
+	if !strings.Contains(src, "{{") {
+		return nil, nil
+	}
+	t := &template{}
+	for rest := src; rest != ""; {
+		open := strings.Index(rest, "{{")
+		if open < 0 {
+			t.parts = append(t.parts, part{text: rest})
+			break
+		}
+		if open > 0 {
+			t.parts = append(t.parts, part{text: rest[:open]})
+		}
+		end := strings.Index(rest[open:], "}}")
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed action in %q", src)
+		}
+		action := rest[open+2 : open+end]
+		if name, ok := strings.CutPrefix(strings.TrimSpace(action), "."); ok {
+			if name == "" || strings.ContainsAny(name, " \t") {
+				return nil, fmt.Errorf("{{%s}}: invalid variable name", action)
+			}
+			t.parts = append(t.parts, part{v: name, eval: func(s *scope) string { return s.vars[name] }})
+		} else {
+			eval, err := parseAction(action)
+			if err != nil {
+				return nil, fmt.Errorf("{{%s}}: %w", action, err)
+			}
+			t.parts = append(t.parts, part{eval: eval})
+		}
+		rest = rest[open+end+2:]
+	}
+	return t, nil
+}
+
+// parseAction parses the inside of one action, a function name followed by
+// literal arguments, into its evaluator.
+func parseAction(action string) (func(s *scope) string, error) {
+	args, err := splitArgs(action)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty action")
+	}
+	name, args := args[0], args[1:]
+	want := func(n int) error {
+		if len(args) != n {
+			return fmt.Errorf("%s takes %d argument(s), got %d", name, n, len(args))
+		}
+		return nil
+	}
+
+	switch name {
+	case "uuid":
+		if err := want(0); err != nil {
+			return nil, err
+		}
+		return func(s *scope) string { return uuid(s.rng) }, nil
+
+	case "seq":
+		if err := want(0); err != nil {
+			return nil, err
+		}
+		return func(s *scope) string { return strconv.FormatInt(s.seq, 10) }, nil
+
+	case "randInt":
+		if err := want(2); err != nil {
+			return nil, err
+		}
+		lo, err1 := strconv.ParseInt(args[0], 10, 64)
+		hi, err2 := strconv.ParseInt(args[1], 10, 64)
+		if err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("randInt takes two integers, got %q and %q", args[0], args[1])
+		}
+		if hi < lo {
+			return nil, fmt.Errorf("randInt: max %d is below min %d", hi, lo)
+		}
+		return func(s *scope) string { return strconv.FormatInt(lo+s.rng.Int64N(hi-lo+1), 10) }, nil
+
+	case "randString":
+		if err := want(1); err != nil {
+			return nil, err
+		}
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("randString takes a length >= 0, got %q", args[0])
+		}
+		return func(s *scope) string {
+			b := make([]byte, n)
+			for i := range b {
+				b[i] = alphanumeric[s.rng.IntN(len(alphanumeric))]
+			}
+			return string(b)
+		}, nil
+
+	case "now":
+		layout := time.RFC3339
+		switch len(args) {
+		case 0:
+		case 1:
+			layout = args[0]
+			if l, ok := layouts[layout]; ok {
+				layout = l
+			}
+		default:
+			return nil, fmt.Errorf("now takes at most 1 argument, got %d", len(args))
+		}
+		switch layout {
+		case "Unix":
+			return func(*scope) string { return strconv.FormatInt(time.Now().Unix(), 10) }, nil
+		case "UnixMilli":
+			return func(*scope) string { return strconv.FormatInt(time.Now().UnixMilli(), 10) }, nil
+		}
+		return func(*scope) string { return time.Now().Format(layout) }, nil
+
+	case "env":
+		if err := want(1); err != nil {
+			return nil, err
+		}
+		v := os.Getenv(args[0])
+		return func(*scope) string { return v }, nil
+
+	default:
+		return nil, fmt.Errorf("unknown function %q", name)
+	}
+}
+
+// splitArgs splits an action into space-separated words, unquoting
+// double-quoted ones.
+func splitArgs(action string) ([]string, error) {
+	var args []string
+	rest := strings.TrimSpace(action)
+	for rest != "" {
+		if rest[0] == '"' {
+			end := 1
+			for end < len(rest) && rest[end] != '"' {
+				if rest[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(rest) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			s, err := strconv.Unquote(rest[:end+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string %s", rest[:end+1])
+			}
+			args = append(args, s)
+			rest = strings.TrimSpace(rest[end+1:])
+			continue
+		}
+		word, after, _ := strings.Cut(rest, " ")
+		args = append(args, word)
+		rest = strings.TrimSpace(after)
+	}
+	return args, nil
+}
+
+// uuid returns a random (version 4) UUID drawn from rng.
+func uuid(rng *rand.Rand) string {
+	var b [16]byte
+	hi, lo := rng.Uint64(), rng.Uint64()
+	for i := 0; i < 8; i++ {
+		b[i] = byte(hi >> (56 - 8*i))
+		b[8+i] = byte(lo >> (56 - 8*i))
+	}
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// node is a compiled data tree in which at least one string is templated.
+type node interface {
+	render(s *scope) interface{}
+}
+
+type stringNode struct{ t *template }
+
+func (n stringNode) render(s *scope) interface{} { return n.t.render(s) }
+
+// mapNode and sliceNode keep the static values and re-render only the
+// templated children.
+type mapNode struct {
+	static   map[string]interface{}
+	children map[string]node
+}
+
+func (n mapNode) render(s *scope) interface{} {
+	out := make(map[string]interface{}, len(n.static))
+	for k, v := range n.static {
+		if c, ok := n.children[k]; ok {
+			out[k] = c.render(s)
+		} else {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+type sliceNode struct {
+	static   []interface{}
+	children map[int]node
+}
+
+func (n sliceNode) render(s *scope) interface{} {
+	out := make([]interface{}, len(n.static))
+	for i, v := range n.static {
+		if c, ok := n.children[i]; ok {
+			out[i] = c.render(s)
+		} else {
+			out[i] = v
+		}
+	}
+	return out
+}
+
+// compileNode compiles a data tree, returning nil if no string in it holds a
+// template. Variables are recorded in r.
+func compileNode(v interface{}, r *Request) (node, error) {
+	switch v := v.(type) {
+	case string:
+		t, err := parse(v)
+		if err != nil || t == nil {
+			return nil, err
+		}
+		t.collectVars(r)
+		return stringNode{t}, nil
+	case map[string]interface{}:
+		children := make(map[string]node)
+		for k, c := range v {
+			n, err := compileNode(c, r)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", k, err)
+			}
+			if n != nil {
+				children[k] = n
+			}
+		}
+		if len(children) == 0 {
+			return nil, nil
+		}
+		return mapNode{static: v, children: children}, nil
+	case []interface{}:
+		children := make(map[int]node)
+		for i, c := range v {
+			n, err := compileNode(c, r)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			if n != nil {
+				children[i] = n
+			}
+		}
+		if len(children) == 0 {
+			return nil, nil
+		}
+		return sliceNode{static: v, children: children}, nil
+	default:
+		return nil, nil
+	}
+}
@@ -0,0 +1,198 @@
+package templating
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRender_Functions(t *testing.T) {
+	t.Setenv("HR_TOKEN", "secret")
+	r, err := New(1).Compile(
+		`https://x/{{seq}}/{{randInt 5 7}}?q={{randString 12}}`,
+		map[string]string{"Authorization": `Bearer {{env "HR_TOKEN"}}`, "X-Id": "{{uuid}}", "Accept": "*/*"},
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+
+	urlRe := regexp.MustCompile(`^https://x/(\d+)/([5-7])\?q=[a-zA-Z0-9]{12}$`)
+	uuidRe := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	for i := 0; i < 3; i++ {
+		url, headers, _ := r.Render(nil)
+		m := urlRe.FindStringSubmatch(url)
+		if m == nil {
+			t.Fatalf("unexpected url %q", url)
+		}
+		if m[1] != strconv.Itoa(i) {
+			t.Errorf("expected seq %d, got %s", i, m[1])
+		}
+		if headers["Authorization"] != "Bearer secret" || headers["Accept"] != "*/*" {
+			t.Errorf("unexpected headers %v", headers)
+		}
+		if !uuidRe.MatchString(headers["X-Id"]) {
+			t.Errorf("expected a v4 UUID, got %q", headers["X-Id"])
+		}
+	}
+}
+
+func TestRender_Now(t *testing.T) {
+	r, err := New(1).Compile(`{{now "RFC3339"}}|{{now "2006"}}|{{now "Unix"}}`, nil, nil)
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	url, _, _ := r.Render(nil)
+	parts := strings.Split(url, "|")
+	if _, err := time.Parse(time.RFC3339, parts[0]); err != nil {
+		t.Errorf("expected an RFC3339 time, got %q", parts[0])
+	}
+	if parts[1] != strconv.Itoa(time.Now().Year()) {
+		t.Errorf("expected the current year, got %q", parts[1])
+	}
+	if sec, err := strconv.ParseInt(parts[2], 10, 64); err != nil || time.Since(time.Unix(sec, 0)) > time.Minute {
+		t.Errorf("expected the current Unix time, got %q", parts[2])
+	}
+}
+
+// TestRender_Data checks that every string value in the data tree is
+// rendered and everything else is left alone.
+func TestRender_Data(t *testing.T) {
+	data := map[string]interface{}{
+		"id":    "user-{{seq}}",
+		"count": 3,
+		"tags":  []interface{}{"static", "{{seq}}"},
+		"inner": map[string]interface{}{"plain": "x"},
+	}
+	r, err := New(1).Compile("https://x", nil, data)
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	r.Render(nil)
+	_, _, out := r.Render(nil)
+	m := out.(map[string]interface{})
+	if m["id"] != "user-1" || m["count"] != 3 {
+		t.Errorf("unexpected data %v", m)
+	}
+	if tags := m["tags"].([]interface{}); tags[0] != "static" || tags[1] != "1" {
+		t.Errorf("unexpected tags %v", tags)
+	}
+	if data["id"] != "user-{{seq}}" {
+		t.Errorf("expected the compiled data to be left untouched, got %v", data["id"])
+	}
+}
+
+// TestRender_Static checks that a request without templates is passed through.
+func TestRender_Static(t *testing.T) {
+	headers := map[string]string{"A": "b"}
+	r, err := New(1).Compile("https://x", headers, map[string]interface{}{"k": "v"})
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	url, h, _ := r.Render(nil)
+	if url != "https://x" || h["A"] != "b" {
+		t.Errorf("unexpected static render %q %v", url, h)
+	}
+}
+
+// TestRender_Seeded checks that the same seed renders the same values for the
+// same request, and a different seed different ones.
+func TestRender_Seeded(t *testing.T) {
+	render := func(seed int64) []string {
+		r, err := New(seed).Compile("{{uuid}} {{randInt 1 1000000}} {{randString 8}}", nil, nil)
+		if err != nil {
+			t.Fatalf("Compile: %v", err)
+		}
+		var out []string
+		for i := 0; i < 5; i++ {
+			url, _, _ := r.Render(nil)
+			out = append(out, url)
+		}
+		return out
+	}
+	a, b, c := render(42), render(42), render(43)
+	for i := range a {
+		if a[i] != b[i] {
+			t.Errorf("request %d: expected equal renders with the same seed, got %q and %q", i, a[i], b[i])
+		}
+		if a[i] == c[i] {
+			t.Errorf("request %d: expected different renders with another seed, got %q", i, a[i])
+		}
+	}
+	if a[0] == a[1] {
+		t.Errorf("expected different values for different requests, got %q twice", a[0])
+	}
+}
+
+func TestFork(t *testing.T) {
+	const src = "/items/{{seq}}/{{uuid}}"
+	r, err := New(1).Compile(src, nil, nil)
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	again, _ := New(1).Compile(src, nil, nil)
+	r.Render(nil)
+	again.Render(nil)
+
+	url, _, _ := r.Fork().Render(nil)
+	if !strings.HasPrefix(url, "/items/1/") {
+		t.Errorf("expected the fork to go on from the sequence of the original, got %q", url)
+	}
+	if same, _, _ := again.Render(nil); url == same {
+		t.Errorf("expected the fork to draw random values of its own, got %q twice", url)
+	}
+	if url, _, _ := r.Render(nil); !strings.HasPrefix(url, "/items/2/") {
+		t.Errorf("expected the original to skip the sequence number taken by the fork, got %q", url)
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	cases := []string{
+		"{{nope}}",
+		"{{uuid",
+		"{{randInt 5}}",
+		"{{randInt 9 1}}",
+		"{{randInt a b}}",
+		"{{randString -1}}",
+		`{{env}}`,
+		`{{now "a" "b"}}`,
+		`{{env "unterminated}}`,
+		"{{}}",
+	}
+	for _, src := range cases {
+		if _, err := New(1).Compile(src, nil, nil); err == nil {
+			t.Errorf("Compile(%q): expected an error", src)
+		}
+	}
+	if _, err := New(1).Compile("x", nil, map[string]interface{}{"a": []interface{}{"{{bad}}"}}); err == nil || !strings.Contains(err.Error(), "data: a: [0]") {
+		t.Errorf("expected an error locating the bad data template, got %v", err)
+	}
+}
+
+func TestRender_Vars(t *testing.T) {
+	r, err := New(1).Compile("https://x/users/{{.id}}",
+		map[string]string{"Authorization": "Bearer {{ .token }}"},
+		map[string]interface{}{"q": "{{.term}}-{{seq}}"})
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	if got := strings.Join(r.Vars(), ","); got != "id,term,token" {
+		t.Errorf("expected vars id,term,token, got %s", got)
+	}
+	url, headers, data := r.Render(map[string]string{"id": "7", "token": "abc", "term": "shoes"})
+	if url != "https://x/users/7" || headers["Authorization"] != "Bearer abc" {
+		t.Errorf("unexpected render %q %v", url, headers)
+	}
+	if q := data.(map[string]interface{})["q"]; q != "shoes-0" {
+		t.Errorf("expected q shoes-0, got %v", q)
+	}
+	// A missing variable renders empty.
+	if url, _, _ := r.Render(nil); url != "https://x/users/" {
+		t.Errorf("expected an empty variable, got %q", url)
+	}
+	if _, err := New(1).Compile("{{.}}", nil, nil); err == nil {
+		t.Errorf("expected an error for an empty variable name")
+	}
+}
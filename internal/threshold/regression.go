@@ -0,0 +1,119 @@
+package threshold
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Metrics returns the names of the metrics conditions can be written on, in
+// the order reports show them.
+func Metrics() []string {
+	names := make([]string, len(metricList))
+	for i, mt := range metricList {
+		names[i] = mt.name
+	}
+	return names
+}
+
+// KindOf returns the kind of a metric.
+func KindOf(metric string) Kind {
+	return metrics[metric]
+}
+
+// Regression is a condition on how far a metric may move from a baseline run,
+// written "<metric> <+|-><value>[%]". It describes a FAILURE, like a
+// Condition: "p99 +10%" fails when p99 rose by more than 10% of the baseline,
+// "rps -5%" when rps fell by more than 5%, and "p99 +50ms" or "errors +10"
+// when the metric rose by more than that amount.
+type Regression struct {
+	Metric  string  // metric name (e.g. "p99")
+	Up      bool    // fails on a rise (+) rather than a fall (-)
+	Value   float64 // allowed change; a percentage, or in the metric's unit (durations in seconds)
+	Percent bool    // Value is a percentage of the baseline
+	Kind    Kind    // kind of the metric
+	Raw     string  // original text, for error messages
+}
+
+// ParseRegressions parses a comma-separated regression spec, e.g.
+// "p99 +10%,rps -5%". An empty spec yields no regressions.
+func ParseRegressions(spec string) ([]Regression, error) {
+	var regs []Regression
+	for _, tok := range strings.Split(spec, ",") {
+		tok = strings.TrimSpace(tok)
+		if tok == "" {
+			continue
+		}
+		i := strings.IndexAny(tok, "+-")
+		if i <= 0 {
+			return nil, fmt.Errorf("no direction (+ or -) in %q", tok)
+		}
+		metric := strings.TrimSpace(tok[:i])
+		kind, ok := metrics[metric]
+		if !ok {
+			return nil, fmt.Errorf("unknown metric %q in %q", metric, tok)
+		}
+		r := Regression{Metric: metric, Up: tok[i] == '+', Kind: kind, Raw: tok}
+		valStr := strings.TrimSpace(tok[i+1:])
+		var err error
+		if v, found := strings.CutSuffix(valStr, "%"); found {
+			r.Percent = true
+			r.Value, err = strconv.ParseFloat(strings.TrimSpace(v), 64)
+		} else {
+			r.Value, err = parseValue(kind, valStr)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid value in %q: %w", tok, err)
+		}
+		if r.Value < 0 {
+			return nil, fmt.Errorf("negative change in %q", tok)
+		}
+		regs = append(regs, r)
+	}
+	return regs, nil
+}
+
+// EvaluateRegressions returns a message for every regression that holds
+// between baseline and current (metric name -> value; durations in seconds).
+// An empty result means none did. Regressions on metrics absent from either
+// are skipped.
+func EvaluateRegressions(regs []Regression, baseline, current map[string]float64) []string {
+	var fails []string
+	for _, r := range regs {
+		base, ok := baseline[r.Metric]
+		if !ok {
+			continue
+		}
+		actual, ok := current[r.Metric]
+		if !ok {
+			continue
+		}
+		change := actual - base
+		if !r.Up {
+			change = -change
+		}
+		limit := r.Value
+		if r.Percent {
+			limit = math.Abs(base) * r.Value / 100
+		}
+		if change > limit {
+			fails = append(fails, fmt.Sprintf("%s (baseline %s, actual %s, %s)",
+				r.Raw, formatActual(r.Kind, base), formatActual(r.Kind, actual), FormatChange(base, actual)))
+		}
+	}
+	return fails
+}
+
+// FormatChange formats the change from base to actual as a signed percentage
+// of base, e.g. "+12.50%", or "new" when base is zero and actual is not.
+func FormatChange(base, actual float64) string {
+	switch {
+	case base == actual:
+		return "+0.00%"
+	case base == 0:
+		return "new"
+	default:
+		return fmt.Sprintf("%+.2f%%", (actual-base)/math.Abs(base)*100)
+	}
+}
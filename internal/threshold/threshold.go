@@ -12,6 +12,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/idesyatov/http-runner/internal/reporter"
 )
 
 // Kind describes how a metric's value is written and formatted.
@@ -24,20 +26,50 @@ const (
 	KindInt                  // a whole number (e.g. error count)
 )
 
-// metrics maps a condition metric name to its kind. Duration metrics are
-// compared in seconds, matching the report fields.
-var metrics = map[string]Kind{
-	"p50":     KindDuration,
-	"p90":     KindDuration,
-	"p95":     KindDuration,
-	"p99":     KindDuration,
-	"avg":     KindDuration,
-	"min":     KindDuration,
-	"max":     KindDuration,
-	"ttfb":    KindDuration,
-	"success": KindPercent,
-	"rps":     KindFloat,
-	"errors":  KindInt,
+// metric is a metric conditions can be written on.
+type metric struct {
+	name  string
+	kind  Kind
+	value func(r *reporter.Report) float64 // Durations in seconds, matching the report fields
+}
+
+// metricList lists the metrics in the order reports show them.
+var metricList = []metric{
+	{"p50", KindDuration, func(r *reporter.Report) float64 { return r.P50Response }},
+	{"p90", KindDuration, func(r *reporter.Report) float64 { return r.P90Response }},
+	{"p95", KindDuration, func(r *reporter.Report) float64 { return r.P95Response }},
+	{"p99", KindDuration, func(r *reporter.Report) float64 { return r.P99Response }},
+	// Corrected for coordinated omission
+	{"cp50", KindDuration, func(r *reporter.Report) float64 { return r.CorrectedP50 }},
+	{"cp90", KindDuration, func(r *reporter.Report) float64 { return r.CorrectedP90 }},
+	{"cp95", KindDuration, func(r *reporter.Report) float64 { return r.CorrectedP95 }},
+	{"cp99", KindDuration, func(r *reporter.Report) float64 { return r.CorrectedP99 }},
+	{"avg", KindDuration, func(r *reporter.Report) float64 { return r.AverageResponse }},
+	{"min", KindDuration, func(r *reporter.Report) float64 { return r.MinResponse }},
+	{"max", KindDuration, func(r *reporter.Report) float64 { return r.MaxResponse }},
+	{"ttfb", KindDuration, func(r *reporter.Report) float64 { return r.AvgTTFB }},
+	{"success", KindPercent, func(r *reporter.Report) float64 { return r.SuccessRate }},
+	{"rps", KindFloat, func(r *reporter.Report) float64 { return r.RequestsPerSec }},
+	{"errors", KindInt, func(r *reporter.Report) float64 { return float64(r.ErrorCount) }},
+}
+
+// metrics maps a metric name to its kind.
+var metrics = func() map[string]Kind {
+	m := make(map[string]Kind, len(metricList))
+	for _, mt := range metricList {
+		m[mt.name] = mt.kind
+	}
+	return m
+}()
+
+// Values returns the metrics of r by name (durations in seconds), as
+// Evaluate and EvaluateRegressions take them.
+func Values(r *reporter.Report) map[string]float64 {
+	v := make(map[string]float64, len(metricList))
+	for _, mt := range metricList {
+		v[mt.name] = mt.value(r)
+	}
+	return v
 }
 
 // ops are the supported comparison operators, longest first so ">=" is matched
@@ -107,6 +139,23 @@ func parseValue(kind Kind, s string) (float64, error) {
 	return strconv.ParseFloat(s, 64)
 }
 
+// Merge combines global conditions with those set for one endpoint. A metric
+// the endpoint sets conditions on keeps only the endpoint's; every other
+// metric keeps the global ones.
+func Merge(global, local []Condition) []Condition {
+	overridden := make(map[string]bool, len(local))
+	for _, c := range local {
+		overridden[c.Metric] = true
+	}
+	var merged []Condition
+	for _, c := range global {
+		if !overridden[c.Metric] {
+			merged = append(merged, c)
+		}
+	}
+	return append(merged, local...)
+}
+
 // Evaluate returns a message for every condition that holds against values
 // (metric name -> actual value; durations in seconds). An empty result means
 // all thresholds passed. Conditions on metrics absent from values are skipped.
@@ -1,6 +1,11 @@
 package threshold
 
-import "testing"
+import (
+	"strings"
+	"testing"
+
+	"github.com/idesyatov/http-runner/internal/reporter"
+)
 
 func TestParse_Valid(t *testing.T) {
 	conds, err := Parse("p99>500ms, success<99 , errors>0")
@@ -77,3 +82,96 @@ func TestEvaluate_AllPass(t *testing.T) {
 		t.Errorf("expected no violations, got %v", fails)
 	}
 }
+
+func TestParse_CorrectedPercentile(t *testing.T) {
+	conds, err := Parse("cp99>300ms")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if conds[0].Metric != "cp99" || conds[0].Kind != KindDuration || conds[0].Value != 0.3 {
+		t.Errorf("cp99 parsed wrong: %+v", conds[0])
+	}
+}
+
+func TestValues(t *testing.T) {
+	v := Values(&reporter.Report{P99Response: 0.25, CorrectedP99: 0.5, ErrorCount: 3})
+	if v["p99"] != 0.25 || v["cp99"] != 0.5 || v["errors"] != 3 {
+		t.Errorf("unexpected values %v", v)
+	}
+	for _, m := range Metrics() {
+		if _, ok := v[m]; !ok {
+			t.Errorf("expected a value for metric %s", m)
+		}
+		if _, err := Parse(m + ">0"); err != nil {
+			t.Errorf("expected a condition on metric %s to parse, got %v", m, err)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	global, _ := Parse("p99>500ms,p95>300ms,success<99")
+	local, _ := Parse("p99>2s,errors>0")
+	var got []string
+	for _, c := range Merge(global, local) {
+		got = append(got, c.Raw)
+	}
+	want := "p95>300ms,success<99,p99>2s,errors>0"
+	if strings.Join(got, ",") != want {
+		t.Errorf("Merge() = %v, want %s", got, want)
+	}
+	if n := len(Merge(global, nil)); n != 3 {
+		t.Errorf("expected the global conditions without local ones, got %d", n)
+	}
+}
+
+func TestParseRegressions(t *testing.T) {
+	regs, err := ParseRegressions("p99 +10%, rps -5%,p95+50ms, errors +3")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Regression{
+		{Metric: "p99", Up: true, Value: 10, Percent: true, Kind: KindDuration, Raw: "p99 +10%"},
+		{Metric: "rps", Up: false, Value: 5, Percent: true, Kind: KindFloat, Raw: "rps -5%"},
+		{Metric: "p95", Up: true, Value: 0.05, Kind: KindDuration, Raw: "p95+50ms"},
+		{Metric: "errors", Up: true, Value: 3, Kind: KindInt, Raw: "errors +3"},
+	}
+	if len(regs) != len(want) {
+		t.Fatalf("expected %d regressions, got %+v", len(want), regs)
+	}
+	for i := range want {
+		if regs[i] != want[i] {
+			t.Errorf("regression %d = %+v, want %+v", i, regs[i], want[i])
+		}
+	}
+
+	for _, spec := range []string{"p99 10%", "bogus +1%", "p99 +fast", "rps -x%", "+10%"} {
+		if _, err := ParseRegressions(spec); err == nil {
+			t.Errorf("expected error for %q, got nil", spec)
+		}
+	}
+}
+
+func TestEvaluateRegressions(t *testing.T) {
+	regs, err := ParseRegressions("p99 +10%,rps -5%,errors +2,success -1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	baseline := map[string]float64{"p99": 0.2, "rps": 100, "errors": 0, "success": 99.5}
+
+	pass := map[string]float64{"p99": 0.21, "rps": 96, "errors": 2, "success": 99}
+	if fails := EvaluateRegressions(regs, baseline, pass); len(fails) != 0 {
+		t.Errorf("expected no regressions, got %v", fails)
+	}
+
+	fail := map[string]float64{"p99": 0.25, "rps": 90, "errors": 3, "success": 98}
+	fails := EvaluateRegressions(regs, baseline, fail)
+	if len(fails) != 4 {
+		t.Fatalf("expected 4 regressions, got %v", fails)
+	}
+	if !strings.Contains(fails[0], "baseline 0.200000s, actual 0.250000s, +25.00%") {
+		t.Errorf("unexpected p99 message: %s", fails[0])
+	}
+	if !strings.Contains(fails[2], "baseline 0, actual 3, new") {
+		t.Errorf("unexpected errors message: %s", fails[2])
+	}
+}
@@ -1,15 +1,29 @@
 package main
 
 import (
+	"cmp"
 	"context"
+	"crypto/sha256"
 	"fmt"
+	"io"
+	"maps"
 	"os"
 	"os/signal"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/idesyatov/http-runner/internal/capacity"
+	"github.com/idesyatov/http-runner/internal/check"
+	"github.com/idesyatov/http-runner/internal/extract"
+	"github.com/idesyatov/http-runner/internal/feeder"
 	"github.com/idesyatov/http-runner/internal/flags"
 	"github.com/idesyatov/http-runner/internal/generator"
+	"github.com/idesyatov/http-runner/internal/progress"
 	"github.com/idesyatov/http-runner/internal/reporter"
+	"github.com/idesyatov/http-runner/internal/results"
+	"github.com/idesyatov/http-runner/internal/templating"
 	"github.com/idesyatov/http-runner/internal/threshold"
 	"github.com/idesyatov/http-runner/pkg/httpclient"
 )
@@ -19,6 +33,14 @@ import (
 var version = "1.9.0"
 
 func main() {
+	// "http-runner report" analyses a results log instead of sending requests.
+	if len(os.Args) > 1 && os.Args[1] == "report" {
+		if runReport(os.Args[2:]) {
+			os.Exit(1)
+		}
+		return
+	}
+
 	metadata := flags.Metadata{
 		GitURL:  "https://github.com/idesyatov/http-runner",
 		Version: version,
@@ -40,92 +62,807 @@ func main() {
 		os.Exit(130)
 	}()
 
+	// Live progress goes to stderr, and only when it is a terminal, so stdout
+	// (e.g. -output json) stays clean.
+	var display *progress.Display
+	if cfg.Progress {
+		display = progress.New(os.Stderr)
+	}
+
+	// Build every endpoint's RequestConfig up front, so a bad template or
+	// feeder file fails before the first run rather than halfway through.
+	// One template engine serves all endpoints, so a -seed reproduces every
+	// endpoint's values.
+	engine := templating.New(cfg.Seed)
+	var runs, mixed []run
+	for i, endpoint := range cfg.Endpoints {
+		rc, err := newRequestConfig(cfg, engine, i, endpoint)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid endpoint %d (%s): %s\n", i+1, endpoint.Label(), err)
+			os.Exit(1)
+		}
+		if cfg.Mix != nil && len(endpoint.Steps) == 0 {
+			// A mixed endpoint's own thresholds apply to its share of the
+			// mix; -fail-if applies to the mix as a whole.
+			mixed = append(mixed, run{endpoint: endpoint, rc: rc, thresholds: endpoint.Thresholds})
+		} else {
+			runs = append(runs, run{endpoint: endpoint, rc: rc, thresholds: threshold.Merge(cfg.Thresholds, endpoint.Thresholds)})
+		}
+	}
+	// A traffic mix sends all its endpoints' requests in one run, under the
+	// mix's load settings, ahead of any scenarios.
+	if cfg.Mix != nil {
+		runs = append([]run{newMixRun(cfg, *cfg.Mix, mixed)}, runs...)
+	}
+	// Each run's report is compared with the report of the same label in the
+	// baseline, if any.
+	if cfg.Baseline != "" {
+		baselines, err := loadBaseline(cfg.Baseline)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -baseline: %s\n", err)
+			os.Exit(1)
+		}
+		for i := range runs {
+			label := runs[i].endpoint.Label()
+			if runs[i].baseline = baselines[label]; runs[i].baseline == nil {
+				fmt.Fprintf(os.Stderr, "no baseline for %s in %s\n", label, cfg.Baseline)
+			}
+		}
+	}
+	// Every request measured is logged to the results file, if any, through
+	// one writer shared by all runs.
+	var resultsFile *os.File
+	var recorder *results.Writer
+	if cfg.ResultsFile != "" {
+		var err error
+		if resultsFile, err = os.Create(cfg.ResultsFile); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -results-file: %s\n", err)
+			os.Exit(1)
+		}
+		recorder = results.NewWriter(resultsFile)
+	}
+	// A run that aborts cancels ctx, stopping the runs alongside or after it
+	// as well.
+	for i := range runs {
+		r := &runs[i]
+		r.rc.Abort = abortOn(threshold.Merge(cfg.AbortOnFail, r.endpoint.AbortOnFail), cancel)
+		r.rc.AbortWindow = cfg.AbortWindow
+		if r.endpoint.AbortWindow > 0 {
+			r.rc.AbortWindow = time.Duration(r.endpoint.AbortWindow)
+		}
+		r.rc.Results = recorder
+	}
+	// The HTML and JUnit reports are written once every run has finished;
+	// their files are created up front so a bad path fails before the runs
+	// rather than after.
+	htmlFile := createDocument("-html-report", cfg.HTMLReport)
+	junitFile := createDocument("-junit-file", cfg.JUnitFile)
+	csvFile, csvHeader := createCSV(cfg.CSVFile, cfg.CSVAppend)
+	// In GitHub Actions the markdown report is appended to the job's summary
+	// page; elsewhere -step-summary does nothing, so one command serves both.
+	var summaryFile *os.File
+	if path := os.Getenv("GITHUB_STEP_SUMMARY"); cfg.StepSummary && path != "" {
+		var err error
+		if summaryFile, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -step-summary: %s\n", err)
+			os.Exit(1)
+		}
+	}
+
+	meta := runMetadata(cfg)
+	meta.Start = time.Now()
+	var reports []*reporter.Report
+	var searches []*reporter.CapacityReport
+	var thresholdFailed bool
+	if cfg.Parallel {
+		reports, thresholdFailed = runParallel(ctx, cfg, runs, display)
+	} else {
+		reports, searches, thresholdFailed = runSequential(ctx, cfg, runs, display)
+	}
+	meta.End = time.Now()
+	printDocument(cfg.Output, meta, reports, searches)
+	writeDocument(htmlFile, "-html-report", reporter.WriteHTML, reports)
+	writeDocument(junitFile, "-junit-file", reporter.WriteJUnit, reports)
+	writeDocument(summaryFile, "-step-summary", reporter.WriteMarkdown, reports)
+	writeDocument(csvFile, "-csv-file", func(w io.Writer, reports []*reporter.Report) error {
+		return reporter.WriteCSV(w, reports, csvHeader)
+	}, reports)
+	if recorder != nil {
+		err := recorder.Close()
+		if cerr := resultsFile.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "writing -results-file: %s\n", err)
+			os.Exit(1)
+		}
+	}
+	if thresholdFailed {
+		os.Exit(1)
+	}
+}
+
+// runSequential runs one run after another, printing each report as its run
+// finishes, and returns the reports, or the capacity searches that replaced
+// the runs, and whether any failed its thresholds. An interrupt skips the runs
+// not yet started.
+func runSequential(ctx context.Context, cfg *flags.Config, runs []run, display *progress.Display) ([]*reporter.Report, []*reporter.CapacityReport, bool) {
+	var reports []*reporter.Report
+	var searches []*reporter.CapacityReport
 	thresholdFailed := false
+	for _, r := range runs {
+		endpoint := r.endpoint
+		gen := newGenerator(cfg, endpoint)
 
-	// Iterate over all endpoints
-	for _, endpoint := range cfg.Endpoints {
-		client := httpclient.NewClient(time.Duration(endpoint.Timeout), cfg.Insecure, cfg.Redirects, endpoint.Concurrency)
-		gen := generator.NewGenerator(client)
+		requestConfig := r.rc
+		// Verbose mode prints a line per response, which a redrawn status line
+		// would garble.
+		if display != nil && !endpoint.Verbose {
+			requestConfig.Progress = func(p generator.Progress) { display.Update(toProgressStatus(p)) }
+		}
 
-		// Create RequestConfig for each endpoint
-		requestConfig := generator.RequestConfig{
-			Method:        endpoint.Method,
-			URL:           endpoint.URL,
-			Count:         endpoint.Count,
-			Verbose:       endpoint.Verbose,
-			Concurrency:   endpoint.Concurrency,
-			ParsedHeaders: endpoint.Headers,
-			Data:          endpoint.Data,
-			Duration:      time.Duration(endpoint.Duration),
-			Rate:          endpoint.Rate,
+		// A capacity search replaces the single run with a series of runs at
+		// increasing rates; -fail-if does not apply to its probes.
+		if cfg.Search != nil {
+			searches = append(searches, runSearch(ctx, cfg, gen, requestConfig, display))
+			if ctx.Err() != nil {
+				break
+			}
+			continue
 		}
 
 		// Generate requests based on the configuration
 		generatorReport := gen.GenerateRequests(ctx, requestConfig)
+		display.Clear()
 
 		// Create a new report using the generated data
-		report := &reporter.Report{
-			URL:             generatorReport.URL,
-			Method:          generatorReport.Method,
-			Count:           generatorReport.Count,
-			Concurrency:     generatorReport.Concurrency,
-			TotalDuration:   generatorReport.TotalDuration,
-			RequestsPerSec:  generatorReport.RequestsPerSec,
-			TotalBytes:      generatorReport.TotalBytes,
-			BytesPerSec:     generatorReport.BytesPerSec,
-			ParsedHeaders:   generatorReport.ParsedHeaders,
-			ParsedData:      generatorReport.ParsedData,
-			AverageResponse: generatorReport.AverageResponse,
-			P50Response:     generatorReport.P50Response,
-			P90Response:     generatorReport.P90Response,
-			P95Response:     generatorReport.P95Response,
-			P99Response:     generatorReport.P99Response,
-			MinResponse:     generatorReport.MinResponse,
-			MaxResponse:     generatorReport.MaxResponse,
-			AvgDNS:          generatorReport.AvgDNS,
-			AvgConnect:      generatorReport.AvgConnect,
-			AvgTLS:          generatorReport.AvgTLS,
-			AvgTTFB:         generatorReport.AvgTTFB,
-			ConnReuseRate:   generatorReport.ConnReuseRate,
-			SuccessCount:    generatorReport.SuccessCount,
-			SuccessRate:     generatorReport.SuccessRate,
-			StatusCodes:     generatorReport.StatusCodes,
-			ErrorCount:      generatorReport.ErrorCount,
-			Errors:          generatorReport.Errors,
-			Histogram:       toReporterBuckets(generatorReport.Histogram),
-		}
-
-		if cfg.Output == "json" {
-			if err := report.GenerateJSON(); err != nil {
-				fmt.Fprintln(os.Stderr, "error writing JSON report:", err)
-				os.Exit(1)
+		report := newReport(generatorReport)
+		thresholdFailed = printReport(cfg, r, report) || thresholdFailed
+		reports = append(reports, report)
+
+		// Stop processing further endpoints if the run was interrupted.
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return reports, searches, thresholdFailed
+}
+
+// newGenerator returns a generator whose client is set up for endpoint.
+func newGenerator(cfg *flags.Config, endpoint flags.Endpoint) *generator.Generator {
+	client := httpclient.NewClient(time.Duration(endpoint.Timeout), cfg.Insecure, cfg.Redirects, endpoint.PeakConcurrency())
+	return generator.NewGenerator(client)
+}
+
+// runParallel starts every run at once, each with its own client and load
+// settings, prints their reports in order once all have finished, and returns
+// the reports and whether any failed its thresholds. The progress line shows
+// the runs combined.
+func runParallel(ctx context.Context, cfg *flags.Config, runs []run, display *progress.Display) ([]*reporter.Report, bool) {
+	verbose := false
+	for _, r := range runs {
+		verbose = verbose || r.endpoint.Verbose
+	}
+
+	var mu sync.Mutex
+	statuses := make([]progress.Status, len(runs))
+	reports := make([]*reporter.Report, len(runs))
+	var wg sync.WaitGroup
+	for i, r := range runs {
+		rc := r.rc
+		if display != nil && !verbose {
+			rc.Progress = func(p generator.Progress) {
+				mu.Lock()
+				statuses[i] = toProgressStatus(p)
+				s := progress.Merge(statuses...)
+				mu.Unlock()
+				display.Update(s)
 			}
-		} else {
-			report.Generate()
 		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			gr := newGenerator(cfg, r.endpoint).GenerateRequests(ctx, rc)
+			reports[i] = newReport(gr)
+			// A finished run no longer adds to what is in flight.
+			mu.Lock()
+			statuses[i].InFlight, statuses[i].RequestsPerSec, statuses[i].Remaining = 0, 0, 0
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	display.Clear()
 
-		// Evaluate CI thresholds against this endpoint's metrics.
-		if len(cfg.Thresholds) > 0 {
-			if fails := threshold.Evaluate(cfg.Thresholds, reportMetrics(report)); len(fails) > 0 {
-				thresholdFailed = true
-				fmt.Fprintf(os.Stderr, "threshold failed for %s:\n", report.URL)
-				for _, f := range fails {
-					fmt.Fprintf(os.Stderr, "  - %s\n", f)
-				}
+	thresholdFailed := false
+	for i, report := range reports {
+		thresholdFailed = printReport(cfg, runs[i], report) || thresholdFailed
+	}
+	return reports, thresholdFailed
+}
+
+// printReport evaluates the thresholds of r against its report, and those of a
+// mix's endpoints against their breakdowns, writes the report in the
+// configured output format, and reports whether any threshold failed or the
+// run was aborted.
+func printReport(cfg *flags.Config, r run, report *reporter.Report) bool {
+	failed := judge(report, r.thresholds)
+	for i, m := range r.mixed {
+		failed = judge(&report.Endpoints[i], m.thresholds) || failed
+	}
+	if r.baseline != nil {
+		failed = compare(report, r.baseline, cfg.Regressions) || failed
+	}
+
+	switch cfg.Output {
+	case "ndjson":
+		if err := reporter.WriteNDJSONReport(os.Stdout, report); err != nil {
+			fmt.Fprintln(os.Stderr, "error writing JSON report:", err)
+			os.Exit(1)
+		}
+	case "json", "html", "junit", "markdown", "csv":
+		// Written as one document once every run has finished.
+	default:
+		report.Generate()
+	}
+
+	// CI logs get the failures on stderr whatever the output format.
+	logFailures := func(label string, rep *reporter.Report) {
+		if rep.AbortReason != "" {
+			fmt.Fprintf(os.Stderr, "run aborted for %s: %s\n", label, rep.AbortReason)
+		}
+		if len(rep.ThresholdFailures) > 0 {
+			fmt.Fprintf(os.Stderr, "threshold failed for %s:\n", label)
+			for _, f := range rep.ThresholdFailures {
+				fmt.Fprintf(os.Stderr, "  - %s\n", f)
+			}
+		}
+		if len(rep.RegressionFailures) > 0 {
+			fmt.Fprintf(os.Stderr, "regression against baseline for %s:\n", label)
+			for _, f := range rep.RegressionFailures {
+				fmt.Fprintf(os.Stderr, "  - %s\n", f)
 			}
 		}
+	}
+	logFailures(report.Label(), report)
+	for i := range report.Endpoints {
+		logFailures(fmt.Sprintf("%s in %s", report.Endpoints[i].Label(), report.Label()), &report.Endpoints[i])
+	}
+	return failed || report.AbortReason != ""
+}
 
-		// Stop processing further endpoints if the run was interrupted.
-		if ctx.Err() != nil {
-			break
+// runReport runs the report subcommand: it rebuilds the report of each
+// endpoint of a results log, in the order the endpoints first appear, prints
+// them in the configured output format and reports whether any failed its
+// thresholds.
+func runReport(args []string) bool {
+	rc, err := flags.ParseReportFlags(args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	f, err := os.Open(rc.File)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	analyzers := map[string]*generator.Analyzer{}
+	var names []string
+	err = results.Scan(f, rc.Filter, func(rec results.Record) {
+		a, ok := analyzers[rec.Endpoint]
+		if !ok {
+			a = generator.NewAnalyzer(rec.Endpoint, rc.Precision, rc.Interval)
+			analyzers[rec.Endpoint] = a
+			names = append(names, rec.Endpoint)
 		}
+		a.Add(rec)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "reading %s: %s\n", rc.File, err)
+		os.Exit(1)
+	}
+	if len(names) == 0 {
+		fmt.Fprintf(os.Stderr, "no records in %s match the filters\n", rc.File)
+		os.Exit(1)
 	}
 
-	if thresholdFailed {
+	cfg := &flags.Config{Output: rc.Output}
+	var reports []*reporter.Report
+	failed := false
+	for _, name := range names {
+		report := newReport(analyzers[name].Report())
+		failed = printReport(cfg, run{thresholds: rc.Thresholds}, report) || failed
+		reports = append(reports, report)
+	}
+	printDocument(cfg.Output, reporter.Run{Version: version, Hostname: hostname(), Args: os.Args[1:]}, reports, nil)
+	return failed
+}
+
+// printDocument writes the reports, or the capacity searches, to stdout as one
+// document, for the output formats that cover every run at once, or ends the
+// NDJSON stream of the reports with its summary line.
+func printDocument(output string, meta reporter.Run, reports []*reporter.Report, searches []*reporter.CapacityReport) {
+	var err error
+	switch output {
+	case "json":
+		err = reporter.WriteJSON(os.Stdout, meta, reports, searches)
+	case "ndjson":
+		err = reporter.WriteNDJSONSummary(os.Stdout, meta, reports)
+	case "html":
+		err = reporter.WriteHTML(os.Stdout, reports)
+	case "junit":
+		err = reporter.WriteJUnit(os.Stdout, reports)
+	case "markdown":
+		err = reporter.WriteMarkdown(os.Stdout, reports)
+	case "csv":
+		err = reporter.WriteCSV(os.Stdout, reports, true)
+	default:
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error writing %s report: %s\n", output, err)
 		os.Exit(1)
 	}
 }
 
+// runMetadata describes this invocation for the JSON document; the caller
+// sets its start and end. The configuration file is hashed so reports of runs
+// with different settings can be told apart.
+func runMetadata(cfg *flags.Config) reporter.Run {
+	meta := reporter.Run{Version: version, Hostname: hostname(), ConfigFile: cfg.ConfigPath, Args: os.Args[1:]}
+	if cfg.ConfigPath != "" {
+		if data, err := os.ReadFile(cfg.ConfigPath); err == nil {
+			meta.ConfigHash = fmt.Sprintf("%x", sha256.Sum256(data))
+		}
+	}
+	return meta
+}
+
+// hostname returns the name of this host, or empty if it is not known.
+func hostname() string {
+	name, _ := os.Hostname()
+	return name
+}
+
+// createDocument creates the file a document flag names, or returns nil if it
+// is not set.
+func createDocument(flag, path string) *os.File {
+	if path == "" {
+		return nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid %s: %s\n", flag, err)
+		os.Exit(1)
+	}
+	return f
+}
+
+// createCSV creates or, to append to, opens the -csv-file, and reports
+// whether it needs a header, that is, whether it is empty. It returns nil if
+// the flag is not set.
+func createCSV(path string, appendRows bool) (*os.File, bool) {
+	if !appendRows {
+		return createDocument("-csv-file", path), true
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -csv-file: %s\n", err)
+		os.Exit(1)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -csv-file: %s\n", err)
+		os.Exit(1)
+	}
+	return f, info.Size() == 0
+}
+
+// writeDocument writes the reports to f with write and closes it; f is nil
+// when its flag is not set.
+func writeDocument(f *os.File, flag string, write func(io.Writer, []*reporter.Report) error, reports []*reporter.Report) {
+	if f == nil {
+		return
+	}
+	err := write(f, reports)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "writing %s: %s\n", flag, err)
+		os.Exit(1)
+	}
+}
+
+// abortOn returns an abort check that fails a window of a run on any of
+// conds, cancelling ctx through cancel, or nil without conditions.
+func abortOn(conds []threshold.Condition, cancel context.CancelFunc) func(generator.GeneratorReport) string {
+	if len(conds) == 0 {
+		return nil
+	}
+	return func(window generator.GeneratorReport) string {
+		fails := threshold.Evaluate(conds, threshold.Values(newReport(window)))
+		if len(fails) == 0 {
+			return ""
+		}
+		cancel()
+		return strings.Join(fails, ", ")
+	}
+}
+
+// judge evaluates conds against report, recording the verdict in it, and
+// reports whether any condition failed.
+func judge(report *reporter.Report, conds []threshold.Condition) bool {
+	if len(conds) == 0 {
+		return false
+	}
+	for _, c := range conds {
+		report.Thresholds = append(report.Thresholds, c.Raw)
+	}
+	report.ThresholdFailures = threshold.Evaluate(conds, threshold.Values(report))
+	return len(report.ThresholdFailures) > 0
+}
+
+// compare records in report the change of each metric from base, and of every
+// other numeric field, evaluates regs against it, and reports whether any
+// regression held.
+func compare(report, base *reporter.Report, regs []threshold.Regression) bool {
+	baseline, current := threshold.Values(base), threshold.Values(report)
+	for _, m := range threshold.Metrics() {
+		d := reporter.Delta{Metric: m, Baseline: baseline[m], Current: current[m]}
+		switch threshold.KindOf(m) {
+		case threshold.KindDuration:
+			d.Unit = "s"
+		case threshold.KindPercent:
+			d.Unit = "%"
+		case threshold.KindFloat:
+			d.Unit = "req/s"
+		}
+		report.Baseline = append(report.Baseline, d)
+	}
+	report.Baseline = append(report.Baseline, fieldDeltas(report, base)...)
+	for _, r := range regs {
+		report.Regressions = append(report.Regressions, r.Raw)
+	}
+	report.RegressionFailures = threshold.EvaluateRegressions(regs, baseline, current)
+	return len(report.RegressionFailures) > 0
+}
+
+// fieldDeltas returns the change from base of every numeric field of report
+// that is not a -fail-if metric, named after its JSON field. Each status code,
+// error category and failed check is a field of its own; one seen in only one
+// of the two reports counts as zero in the other.
+func fieldDeltas(report, base *reporter.Report) []reporter.Delta {
+	var deltas []reporter.Delta
+	add := func(metric, unit string, value func(r *reporter.Report) float64) {
+		deltas = append(deltas, reporter.Delta{Metric: metric, Unit: unit, Baseline: value(base), Current: value(report)})
+	}
+	add("count", "", func(r *reporter.Report) float64 { return float64(r.Count) })
+	add("success_count", "", func(r *reporter.Report) float64 { return float64(r.SuccessCount) })
+	add("dropped_iterations", "", func(r *reporter.Report) float64 { return float64(r.DroppedIterations) })
+	add("late_iterations", "", func(r *reporter.Report) float64 { return float64(r.LateIterations) })
+	add("missed_ticks", "", func(r *reporter.Report) float64 { return float64(r.MissedTicks) })
+	add("total_duration", "s", func(r *reporter.Report) float64 { return r.TotalDuration.Seconds() })
+	add("total_bytes", "", func(r *reporter.Report) float64 { return float64(r.TotalBytes) })
+	add("bytes_per_sec", "B/s", func(r *reporter.Report) float64 { return r.BytesPerSec })
+	add("avg_dns", "s", func(r *reporter.Report) float64 { return r.AvgDNS })
+	add("avg_connect", "s", func(r *reporter.Report) float64 { return r.AvgConnect })
+	add("avg_tls", "s", func(r *reporter.Report) float64 { return r.AvgTLS })
+	add("conn_reuse_rate", "%", func(r *reporter.Report) float64 { return r.ConnReuseRate })
+	for class := 1; class <= 5; class++ {
+		add(fmt.Sprintf("status_%dxx", class), "", func(r *reporter.Report) float64 {
+			n := 0
+			for code, c := range r.StatusCodes {
+				if code/100 == class {
+					n += c
+				}
+			}
+			return float64(n)
+		})
+	}
+	for _, code := range unionKeys(base.StatusCodes, report.StatusCodes) {
+		add(fmt.Sprintf("status_%d", code), "", func(r *reporter.Report) float64 { return float64(r.StatusCodes[code]) })
+	}
+	for _, cat := range unionKeys(base.Errors, report.Errors) {
+		add("errors_"+strings.ReplaceAll(cat, " ", "_"), "", func(r *reporter.Report) float64 { return float64(r.Errors[cat]) })
+	}
+	for _, name := range unionKeys(base.CheckFailures, report.CheckFailures) {
+		add("check_failures_"+strings.ReplaceAll(name, " ", "_"), "", func(r *reporter.Report) float64 { return float64(r.CheckFailures[name]) })
+	}
+	return deltas
+}
+
+// unionKeys returns the keys of a and b, sorted.
+func unionKeys[K cmp.Ordered, V any](a, b map[K]V) []K {
+	keys := slices.Collect(maps.Keys(a))
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// loadBaseline reads the JSON reports of a previous run, by label.
+func loadBaseline(path string) (map[string]*reporter.Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	reports, err := reporter.ParseJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	byLabel := make(map[string]*reporter.Report, len(reports))
+	for i := range reports {
+		byLabel[reports[i].Label()] = &reports[i]
+	}
+	return byLabel, nil
+}
+
+// run is one run of the generator: an endpoint, a scenario or a traffic mix.
+type run struct {
+	endpoint   flags.Endpoint // Load settings and label of the run
+	rc         generator.RequestConfig
+	thresholds []threshold.Condition // Conditions the run's report must pass
+	mixed      []run                 // A traffic mix's endpoints, in the order of its breakdown
+	baseline   *reporter.Report      // Report of the same run in the -baseline, if any
+}
+
+// newMixRun builds the run of a traffic mix over endpoints, which only
+// contribute their requests: the mix's own settings set the load.
+func newMixRun(cfg *flags.Config, mix flags.Endpoint, endpoints []run) run {
+	rc := generator.RequestConfig{
+		Name:           mix.Name,
+		Count:          mix.Count,
+		Concurrency:    mix.Concurrency,
+		Duration:       time.Duration(mix.Duration),
+		Rate:           mix.Rate,
+		Executor:       mix.Executor,
+		MaxInFlight:    mix.MaxInFlight,
+		Stages:         toGeneratorStages(mix.Stages),
+		Precision:      cfg.Precision,
+		Interval:       cfg.Interval,
+		WarmupCount:    mix.Warmup.Count,
+		WarmupDuration: mix.Warmup.Duration,
+	}
+	for _, r := range endpoints {
+		rc.Mix = append(rc.Mix, r.rc)
+		// Verbose output of any endpoint turns off the progress line.
+		mix.Verbose = mix.Verbose || r.endpoint.Verbose
+	}
+	return run{endpoint: mix, rc: rc, thresholds: threshold.Merge(cfg.Thresholds, mix.Thresholds), mixed: endpoints}
+}
+
+// newRequestConfig builds the generator configuration for the index-th
+// endpoint or scenario, compiling its templates and extractors and loading its
+// feeder.
+func newRequestConfig(cfg *flags.Config, engine *templating.Engine, index int, e flags.Endpoint) (generator.RequestConfig, error) {
+	rc := generator.RequestConfig{
+		Name:           e.Name,
+		Weight:         e.Weight,
+		Method:         e.Method,
+		URL:            e.URL,
+		Count:          e.Count,
+		Verbose:        e.Verbose,
+		Concurrency:    e.Concurrency,
+		ParsedHeaders:  e.Headers,
+		Data:           e.Data,
+		Duration:       time.Duration(e.Duration),
+		Rate:           e.Rate,
+		Executor:       e.Executor,
+		MaxInFlight:    e.MaxInFlight,
+		Stages:         toGeneratorStages(e.Stages),
+		Precision:      cfg.Precision,
+		Interval:       cfg.Interval,
+		WarmupCount:    e.Warmup.Count,
+		WarmupDuration: e.Warmup.Duration,
+	}
+
+	tmpl, err := engine.Compile(e.URL, e.Headers, e.Data)
+	if err != nil {
+		return rc, fmt.Errorf("template: %w", err)
+	}
+	rc.Template = tmpl
+	if rc.Checks, err = newChecks(e.Checks); err != nil {
+		return rc, err
+	}
+
+	// Every variable a template reads must be a column of the feeder.
+	columns := make(map[string]bool)
+	if e.Feeder.File != "" {
+		// Each feeder gets a seed of its own, still derived from -seed, so
+		// feeders of the same size do not hand out rows in the same order.
+		seed := cfg.Seed
+		if seed != 0 {
+			seed += int64(index)
+		}
+		rc.Feeder, err = feeder.Open(e.Feeder.File, e.Feeder.Strategy, e.Feeder.OnExhausted, seed)
+		if err != nil {
+			return rc, fmt.Errorf("feeder: %w", err)
+		}
+		for _, col := range rc.Feeder.Columns() {
+			columns[col] = true
+		}
+	}
+	unknown := func(v string) error {
+		if rc.Feeder == nil {
+			return fmt.Errorf("template uses {{.%s}} but no feeder is set", v)
+		}
+		return fmt.Errorf("template uses {{.%s}} but %s has no %q column", v, e.Feeder.File, v)
+	}
+	for _, v := range tmpl.Vars() {
+		if !columns[v] {
+			return rc, unknown(v)
+		}
+	}
+
+	// A step can also use what the steps before it extracted.
+	for i, st := range e.Steps {
+		step := generator.Step{Name: st.Name, Method: st.Method, URL: st.URL, Headers: st.Headers, Data: st.Data}
+		if step.Template, err = engine.Compile(st.URL, st.Headers, st.Data); err != nil {
+			return rc, fmt.Errorf("step %d (%s): template: %w", i+1, st.Name, err)
+		}
+		for _, v := range step.Template.Vars() {
+			if !columns[v] {
+				return rc, fmt.Errorf("step %d (%s): %w, nor does an earlier step extract it", i+1, st.Name, unknown(v))
+			}
+		}
+		for _, name := range slices.Sorted(maps.Keys(st.Extract)) {
+			source, expr, err := st.Extract[name].Source()
+			if err != nil {
+				return rc, fmt.Errorf("step %d (%s): extract %s: %w", i+1, st.Name, name, err)
+			}
+			ex, err := extract.New(name, source, expr)
+			if err != nil {
+				return rc, fmt.Errorf("step %d (%s): extract %w", i+1, st.Name, err)
+			}
+			step.Extract = append(step.Extract, ex)
+			columns[name] = true
+		}
+		if step.Checks, err = newChecks(st.Checks); err != nil {
+			return rc, fmt.Errorf("step %d (%s): %w", i+1, st.Name, err)
+		}
+		rc.Steps = append(rc.Steps, step)
+	}
+	return rc, nil
+}
+
+// newChecks compiles the configured checks.
+func newChecks(in []flags.Check) ([]*check.Check, error) {
+	var out []*check.Check
+	for i, c := range in {
+		compiled, err := check.New(c.Spec())
+		if err != nil {
+			return nil, fmt.Errorf("check %d: %w", i+1, err)
+		}
+		out = append(out, compiled)
+	}
+	return out, nil
+}
+
+// newReport maps a generator report onto the reporter's report (the two
+// layers are decoupled and copied field by field).
+func newReport(gr generator.GeneratorReport) *reporter.Report {
+	return &reporter.Report{
+		Name:              gr.Name,
+		URL:               gr.URL,
+		Method:            gr.Method,
+		Count:             gr.Count,
+		Concurrency:       gr.Concurrency,
+		Executor:          gr.Executor,
+		Rate:              gr.Rate,
+		DroppedIterations: gr.DroppedIterations,
+		LateIterations:    gr.LateIterations,
+		MissedTicks:       gr.MissedTicks,
+		WarmupCount:       gr.WarmupCount,
+		FeederExhausted:   gr.FeederExhausted,
+		AbortReason:       gr.AbortReason,
+		TotalDuration:     gr.TotalDuration,
+		RequestsPerSec:    gr.RequestsPerSec,
+		TotalBytes:        gr.TotalBytes,
+		BytesPerSec:       gr.BytesPerSec,
+		ParsedHeaders:     gr.ParsedHeaders,
+		ParsedData:        gr.ParsedData,
+		AverageResponse:   gr.AverageResponse,
+		P50Response:       gr.P50Response,
+		P90Response:       gr.P90Response,
+		P95Response:       gr.P95Response,
+		P99Response:       gr.P99Response,
+		CorrectedP50:      gr.CorrectedP50,
+		CorrectedP90:      gr.CorrectedP90,
+		CorrectedP95:      gr.CorrectedP95,
+		CorrectedP99:      gr.CorrectedP99,
+		MinResponse:       gr.MinResponse,
+		MaxResponse:       gr.MaxResponse,
+		AvgDNS:            gr.AvgDNS,
+		AvgConnect:        gr.AvgConnect,
+		AvgTLS:            gr.AvgTLS,
+		AvgTTFB:           gr.AvgTTFB,
+		ConnReuseRate:     gr.ConnReuseRate,
+		SuccessCount:      gr.SuccessCount,
+		SuccessRate:       gr.SuccessRate,
+		StatusCodes:       gr.StatusCodes,
+		ErrorCount:        gr.ErrorCount,
+		Errors:            gr.Errors,
+		ErrorSamples:      gr.ErrorSamples,
+		CheckFailures:     gr.CheckFailures,
+		Histogram:         toReporterBuckets(gr.Histogram),
+		Stages:            toReporterStages(gr.Stages),
+		Timeseries:        toReporterTimeseries(gr.Timeseries),
+		Steps:             toReporterSteps(gr.Steps),
+		Weight:            gr.Weight,
+		Endpoints:         toReporterEndpoints(gr.Endpoints),
+	}
+}
+
+// toReporterEndpoints maps the per-endpoint breakdown of a traffic mix onto
+// reporter reports.
+func toReporterEndpoints(in []generator.GeneratorReport) []reporter.Report {
+	if in == nil {
+		return nil
+	}
+	out := make([]reporter.Report, len(in))
+	for i, gr := range in {
+		out[i] = *newReport(gr)
+	}
+	return out
+}
+
+// runSearch runs a capacity search against one endpoint and prints the
+// per-step table. Every probe holds a constant rate for the step duration.
+func runSearch(ctx context.Context, cfg *flags.Config, gen *generator.Generator, rc generator.RequestConfig, display *progress.Display) *reporter.CapacityReport {
+	s := cfg.Search
+	var steps []generator.GeneratorReport
+	probe := func(ctx context.Context, rate int) map[string]float64 {
+		stepCfg := rc
+		stepCfg.Rate = rate
+		stepCfg.Duration = s.StepDuration
+		stepCfg.Stages = nil
+		stepCfg.Abort = nil // the SLO decides when the search stops
+		gr := gen.GenerateRequests(ctx, stepCfg)
+		display.Clear()
+		steps = append(steps, gr)
+		return threshold.Values(newReport(gr))
+	}
+	res := capacity.Search(ctx, capacity.Config{Mode: s.Mode, Min: s.Min, Max: s.Max, Step: s.Step, SLO: s.SLO}, probe)
+
+	report := &reporter.CapacityReport{
+		URL:          rc.URL,
+		Method:       rc.Method,
+		Mode:         s.Mode,
+		MinRate:      s.Min,
+		MaxRate:      s.Max,
+		StepDuration: s.StepDuration.Seconds(),
+		MaxPassing:   res.MaxRate,
+		Interrupted:  res.Interrupted,
+	}
+	for _, c := range s.SLO {
+		report.SLO = append(report.SLO, c.Raw)
+	}
+	// Steps pair up with the probes that ran; an interrupted probe has no step.
+	for i, st := range res.Steps {
+		gr := steps[i]
+		report.Steps = append(report.Steps, reporter.CapacityStep{
+			Rate:           st.Rate,
+			RequestsPerSec: gr.RequestsPerSec,
+			P50Response:    gr.P50Response,
+			P99Response:    gr.P99Response,
+			SuccessRate:    gr.SuccessRate,
+			ErrorCount:     gr.ErrorCount,
+			Failures:       st.Failures,
+		})
+	}
+
+	// As JSON, the search is part of the run's document.
+	if cfg.Output != "json" {
+		report.Generate()
+	}
+	return report
+}
+
 // toReporterBuckets maps the generator's histogram buckets onto the reporter's
 // bucket type (the two layers are decoupled and copied field by field).
 func toReporterBuckets(in []generator.Bucket) []reporter.Bucket {
@@ -139,20 +876,118 @@ func toReporterBuckets(in []generator.Bucket) []reporter.Bucket {
 	return out
 }
 
-// reportMetrics exposes a report's metrics by the names used in -fail-if
-// conditions (durations in seconds).
-func reportMetrics(r *reporter.Report) map[string]float64 {
-	return map[string]float64{
-		"p50":     r.P50Response,
-		"p90":     r.P90Response,
-		"p95":     r.P95Response,
-		"p99":     r.P99Response,
-		"avg":     r.AverageResponse,
-		"min":     r.MinResponse,
-		"max":     r.MaxResponse,
-		"ttfb":    r.AvgTTFB,
-		"success": r.SuccessRate,
-		"rps":     r.RequestsPerSec,
-		"errors":  float64(r.ErrorCount),
+// toGeneratorStages maps the configured stages onto the generator's stage type.
+func toGeneratorStages(in []flags.Stage) []generator.Stage {
+	if in == nil {
+		return nil
+	}
+	out := make([]generator.Stage, len(in))
+	for i, st := range in {
+		out[i] = generator.Stage{Duration: time.Duration(st.Duration), Rate: st.Rate, Concurrency: st.Concurrency}
+	}
+	return out
+}
+
+// toReporterStages maps the generator's per-stage breakdown onto the
+// reporter's stage type.
+func toReporterStages(in []generator.StageReport) []reporter.Stage {
+	if in == nil {
+		return nil
+	}
+	out := make([]reporter.Stage, len(in))
+	for i, st := range in {
+		out[i] = reporter.Stage{
+			Start:             st.Start,
+			Duration:          st.Duration,
+			Rate:              st.Rate,
+			Concurrency:       st.Concurrency,
+			Count:             st.Count,
+			DroppedIterations: st.DroppedIterations,
+			LateIterations:    st.LateIterations,
+			MissedTicks:       st.MissedTicks,
+			RequestsPerSec:    st.RequestsPerSec,
+			SuccessRate:       st.SuccessRate,
+			ErrorCount:        st.ErrorCount,
+			AverageResponse:   st.AverageResponse,
+			P50Response:       st.P50Response,
+			P95Response:       st.P95Response,
+			P99Response:       st.P99Response,
+			MaxResponse:       st.MaxResponse,
+		}
+	}
+	return out
+}
+
+// toReporterTimeseries maps the generator's timeseries windows onto the
+// reporter's interval type.
+func toReporterTimeseries(in []generator.Interval) []reporter.Interval {
+	if in == nil {
+		return nil
+	}
+	out := make([]reporter.Interval, len(in))
+	for i, iv := range in {
+		out[i] = reporter.Interval{
+			Start:             iv.Start,
+			Duration:          iv.Duration,
+			Count:             iv.Count,
+			DroppedIterations: iv.DroppedIterations,
+			LateIterations:    iv.LateIterations,
+			MissedTicks:       iv.MissedTicks,
+			RequestsPerSec:    iv.RequestsPerSec,
+			SuccessRate:       iv.SuccessRate,
+			ErrorCount:        iv.ErrorCount,
+			P50Response:       iv.P50Response,
+			P95Response:       iv.P95Response,
+			P99Response:       iv.P99Response,
+			InFlight:          iv.InFlight,
+		}
+	}
+	return out
+}
+
+// toReporterSteps maps the generator's per-step breakdown of a scenario onto
+// the reporter's step type.
+func toReporterSteps(in []generator.StepReport) []reporter.Step {
+	if in == nil {
+		return nil
+	}
+	out := make([]reporter.Step, len(in))
+	for i, st := range in {
+		out[i] = reporter.Step{
+			Name:               st.Name,
+			Method:             st.Method,
+			URL:                st.URL,
+			Count:              st.Count,
+			RequestsPerSec:     st.RequestsPerSec,
+			SuccessRate:        st.SuccessRate,
+			ErrorCount:         st.ErrorCount,
+			ExtractionFailures: st.ExtractionFailures,
+			CheckFailures:      st.CheckFailures,
+			StatusCodes:        st.StatusCodes,
+			AverageResponse:    st.AverageResponse,
+			P50Response:        st.P50Response,
+			P95Response:        st.P95Response,
+			P99Response:        st.P99Response,
+			MaxResponse:        st.MaxResponse,
+		}
+	}
+	return out
+}
+
+// toProgressStatus maps the generator's live view of a run onto the progress
+// display's status type.
+func toProgressStatus(p generator.Progress) progress.Status {
+	return progress.Status{
+		Elapsed:           p.Elapsed,
+		Remaining:         p.Remaining,
+		Sent:              p.Sent,
+		Target:            p.Target,
+		DroppedIterations: p.DroppedIterations,
+		InFlight:          p.InFlight,
+		RequestsPerSec:    p.RequestsPerSec,
+		P99Response:       p.P99Response,
+		ErrorCount:        p.ErrorCount,
+		StatusCodes:       p.StatusCodes,
+		Warmup:            p.Warmup,
 	}
 }
@@ -2,12 +2,21 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/idesyatov/http-runner/internal/flags"
 	"github.com/idesyatov/http-runner/internal/generator"
+	"github.com/idesyatov/http-runner/internal/reporter"
+	"github.com/idesyatov/http-runner/internal/templating"
+	"github.com/idesyatov/http-runner/internal/threshold"
 	"github.com/idesyatov/http-runner/pkg/httpclient"
 )
 
@@ -60,3 +69,156 @@ func TestMain(t *testing.T) {
 		t.Errorf("Expected Method %s, got %s", cfg.Endpoints[0].Method, generatorReport.Method)
 	}
 }
+
+// TestSearchJSONOutput checks that a capacity search with -output json prints
+// one valid JSON document holding the search.
+func TestSearchJSONOutput(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	slo, err := threshold.Parse("p99>1s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &flags.Config{
+		Output:    "json",
+		Precision: 3,
+		Interval:  time.Second,
+		Search:    &flags.Search{Mode: "step", Min: 10, Max: 20, Step: 10, StepDuration: 200 * time.Millisecond, SLO: slo},
+	}
+	endpoint := flags.Endpoint{Method: "GET", URL: server.URL, Concurrency: 2, Timeout: flags.Duration(time.Second)}
+	rc, err := newRequestConfig(cfg, templating.New(0), 0, endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = pw
+	out := make(chan []byte)
+	go func() {
+		b, _ := io.ReadAll(pr)
+		out <- b
+	}()
+	reports, searches, _ := runSequential(context.Background(), cfg, []run{{endpoint: endpoint, rc: rc}}, nil)
+	printDocument(cfg.Output, reporter.Run{}, reports, searches)
+	pw.Close()
+	os.Stdout = stdout
+	b := <-out
+
+	var doc struct {
+		Endpoints []json.RawMessage `json:"endpoints"`
+		Capacity  []struct {
+			MaxPassingRate int               `json:"max_passing_rate"`
+			Steps          []json.RawMessage `json:"steps"`
+		} `json:"capacity"`
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatalf("stdout is not one JSON document: %v\n%s", err, b)
+	}
+	if len(doc.Endpoints) != 0 {
+		t.Errorf("Expected no endpoints, got %d", len(doc.Endpoints))
+	}
+	if len(doc.Capacity) != 1 {
+		t.Fatalf("Expected 1 capacity search, got %d", len(doc.Capacity))
+	}
+	if c := doc.Capacity[0]; len(c.Steps) != 2 || c.MaxPassingRate != 20 {
+		t.Errorf("Expected 2 steps passing up to 20 req/s, got %d steps, max %d", len(c.Steps), c.MaxPassingRate)
+	}
+}
+
+// TestFeederSeeds checks that with -seed, endpoints whose feeders have the same
+// number of rows still get rows in different orders, and the same orders again
+// on a rerun.
+func TestFeederSeeds(t *testing.T) {
+	dir := t.TempDir()
+	var lines []string
+	for i := 0; i < 20; i++ {
+		lines = append(lines, string(rune('a'+i)))
+	}
+	order := func(index int, name string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("id\n"+strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		cfg := &flags.Config{Seed: 42}
+		e := flags.Endpoint{Method: "GET", URL: "https://example.com/{{.id}}", Feeder: flags.Feeder{File: path, Strategy: "unique"}}
+		rc, err := newRequestConfig(cfg, templating.New(cfg.Seed), index, e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for range lines {
+			row, _ := rc.Feeder.Next()
+			ids = append(ids, row["id"])
+		}
+		return strings.Join(ids, "")
+	}
+	first, second := order(0, "a.csv"), order(1, "b.csv")
+	if first == second {
+		t.Errorf("expected different row orders for two endpoints, got %s twice", first)
+	}
+	if again := order(1, "b.csv"); again != second {
+		t.Errorf("expected the same seed to reproduce the order, got %s and %s", second, again)
+	}
+}
+
+// TestCompare checks that a report compared with a baseline gets the change of
+// every -fail-if metric and of every other numeric field, including status
+// codes and error categories seen in only one of the two reports.
+func TestCompare(t *testing.T) {
+	base := &reporter.Report{
+		Count:       100,
+		P99Response: 0.2,
+		TotalBytes:  1000,
+		StatusCodes: map[int]int{200: 95, 404: 5},
+		Errors:      map[string]int{"timeout": 2},
+	}
+	report := &reporter.Report{
+		Count:         120,
+		P99Response:   0.3,
+		TotalBytes:    1500,
+		StatusCodes:   map[int]int{200: 110, 500: 10},
+		CheckFailures: map[string]int{"status 200": 10},
+	}
+	regs, err := threshold.ParseRegressions("p99 +10%")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !compare(report, base, regs) {
+		t.Errorf("expected the p99 regression to hold")
+	}
+
+	deltas := make(map[string]reporter.Delta)
+	for _, d := range report.Baseline {
+		deltas[d.Metric] = d
+	}
+	for _, m := range threshold.Metrics() {
+		if _, ok := deltas[m]; !ok {
+			t.Errorf("expected a delta for metric %s", m)
+		}
+	}
+	for metric, want := range map[string][2]float64{
+		"count":                     {100, 120},
+		"total_bytes":               {1000, 1500},
+		"status_2xx":                {95, 110},
+		"status_4xx":                {5, 0},
+		"status_5xx":                {0, 10},
+		"status_404":                {5, 0},
+		"status_500":                {0, 10},
+		"errors_timeout":            {2, 0},
+		"check_failures_status_200": {0, 10},
+	} {
+		d, ok := deltas[metric]
+		if !ok {
+			t.Errorf("expected a delta for %s", metric)
+			continue
+		}
+		if d.Baseline != want[0] || d.Current != want[1] {
+			t.Errorf("%s: expected %v -> %v, got %v -> %v", metric, want[0], want[1], d.Baseline, d.Current)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+// Package histogram implements a fixed-memory, log-linear histogram of int64
+// values in the spirit of HdrHistogram.
+//
+// Values are grouped into buckets whose width doubles with each power of two,
+// and every bucket is split into the same number of linear sub-buckets. That
+// keeps the relative error of any recorded value within the configured number
+// of significant decimal digits across the whole range, while the memory used
+// depends only on the range and precision, never on how many values are
+// recorded. Histograms with the same layout can be merged exactly.
+package histogram
+
+import (
+	"math"
+	"math/bits"
+)
+
+// Histogram records int64 values between a lowest discernible value and a
+// highest trackable value. It is not safe for concurrent use.
+type Histogram struct {
+	lowest  int64 // Lowest discernible value
+	highest int64 // Highest trackable value; larger values are clamped to it
+	digits  int   // Significant decimal digits kept
+
+	unitMagnitude      uint  // log2 of the smallest bucket's unit
+	subBucketHalfMag   uint  // log2 of half the sub-bucket count
+	subBucketCount     int   // Linear sub-buckets per bucket
+	subBucketHalfCount int   // Half of subBucketCount
+	subBucketMask      int64 // Mask selecting values that fit the first bucket
+
+	counts []int64 // Count per (bucket, sub-bucket) slot
+	total  int64   // Number of recorded values
+	sum    int64   // Sum of recorded values, for the mean
+	min    int64   // Smallest recorded value (exact)
+	max    int64   // Largest recorded value (exact)
+}
+
+// Bar is one bar of an equal-width rendering of the histogram: values in
+// [Start, End] and how many were recorded there.
+type Bar struct {
+	Start int64 // Lower bound (inclusive)
+	End   int64 // Upper bound
+	Count int64 // Values recorded in the range
+}
+
+// New returns an empty histogram tracking values from lowest (at least 1) to
+// highest, keeping digits (clamped to 1-5) significant decimal digits.
+func New(lowest, highest int64, digits int) *Histogram {
+	lowest = max(lowest, 1)
+	highest = max(highest, 2*lowest)
+	digits = min(max(digits, 1), 5)
+
+	// Enough linear sub-buckets to tell apart 2*10^digits values, rounded up
+	// to a power of two.
+	largest := 2 * math.Pow10(digits)
+	subBucketCountMag := uint(math.Ceil(math.Log2(largest)))
+	h := &Histogram{
+		lowest:           lowest,
+		highest:          highest,
+		digits:           digits,
+		unitMagnitude:    uint(math.Floor(math.Log2(float64(lowest)))),
+		subBucketHalfMag: subBucketCountMag - 1,
+		subBucketCount:   1 << subBucketCountMag,
+	}
+	h.subBucketHalfCount = h.subBucketCount / 2
+	h.subBucketMask = int64(h.subBucketCount-1) << h.unitMagnitude
+
+	// Each further bucket doubles the covered range.
+	buckets := 1
+	for limit := int64(h.subBucketCount) << h.unitMagnitude; limit <= highest; limit <<= 1 {
+		buckets++
+		if limit > math.MaxInt64/2 {
+			break
+		}
+	}
+	h.counts = make([]int64, (buckets+1)*h.subBucketHalfCount)
+	h.Reset()
+	return h
+}
+
+// Reset clears all recorded values, keeping the layout.
+func (h *Histogram) Reset() {
+	clear(h.counts)
+	h.total, h.sum = 0, 0
+	h.min, h.max = math.MaxInt64, 0
+}
+
+// Record adds a value. Negative values count as 0 and values above the
+// highest trackable value land in the top bucket; Min and Max stay exact.
+func (h *Histogram) Record(v int64) {
+	v = max(v, 0)
+	h.counts[h.index(min(v, h.highest))]++
+	h.total++
+	h.sum += v
+	h.min = min(h.min, v)
+	h.max = max(h.max, v)
+}
+
+// Merge adds every value recorded in o. Histograms with the same layout merge
+// exactly; otherwise each of o's slots is re-recorded at its midpoint.
+func (h *Histogram) Merge(o *Histogram) {
+	if o.total == 0 {
+		return
+	}
+	if h.sameLayout(o) {
+		for i, c := range o.counts {
+			h.counts[i] += c
+		}
+	} else {
+		for i, c := range o.counts {
+			if c > 0 {
+				h.counts[h.index(min(o.midpoint(i), h.highest))] += c
+			}
+		}
+	}
+	h.total += o.total
+	h.sum += o.sum
+	h.min = min(h.min, o.min)
+	h.max = max(h.max, o.max)
+}
+
+// Count returns the number of recorded values.
+func (h *Histogram) Count() int64 { return h.total }
+
+// Min returns the smallest recorded value, or 0 if empty.
+func (h *Histogram) Min() int64 {
+	if h.total == 0 {
+		return 0
+	}
+	return h.min
+}
+
+// Max returns the largest recorded value, or 0 if empty.
+func (h *Histogram) Max() int64 { return h.max }
+
+// Mean returns the exact mean of the recorded values, or 0 if empty.
+func (h *Histogram) Mean() float64 {
+	if h.total == 0 {
+		return 0
+	}
+	return float64(h.sum) / float64(h.total)
+}
+
+// ValueAt returns the p-th percentile (0-100) using the nearest-rank method:
+// the highest value equivalent to the slot holding that rank, clamped to the
+// recorded min and max. It returns 0 if empty.
+func (h *Histogram) ValueAt(p float64) int64 {
+	if h.total == 0 {
+		return 0
+	}
+	rank := int64(math.Ceil(p / 100 * float64(h.total)))
+	rank = min(max(rank, 1), h.total)
+	var seen int64
+	for i, c := range h.counts {
+		seen += c
+		if seen >= rank {
+			return min(max(h.highestEquivalent(i), h.min), h.max)
+		}
+	}
+	return h.max
+}
+
+// Bars splits the range between the recorded min and max into n equal-width
+// bars. It returns nil if empty, and a single bar when every value is equal.
+// Values are placed by the midpoint of their slot, so a bar boundary is only
+// as precise as the histogram.
+func (h *Histogram) Bars(n int) []Bar {
+	if h.total == 0 || n <= 0 {
+		return nil
+	}
+	lo, hi := h.Min(), h.max
+	if hi <= lo {
+		return []Bar{{Start: lo, End: hi, Count: h.total}}
+	}
+	width := float64(hi-lo) / float64(n)
+	bars := make([]Bar, n)
+	for i := range bars {
+		bars[i].Start = lo + int64(width*float64(i))
+		bars[i].End = lo + int64(width*float64(i+1))
+	}
+	bars[n-1].End = hi
+	for i, c := range h.counts {
+		if c == 0 {
+			continue
+		}
+		v := min(max(h.midpoint(i), lo), hi)
+		idx := min(int(float64(v-lo)/width), n-1) // the max value lands in the last bar
+		bars[idx].Count += c
+	}
+	return bars
+}
+
+func (h *Histogram) sameLayout(o *Histogram) bool {
+	return h.lowest == o.lowest && h.highest == o.highest && h.digits == o.digits
+}
+
+// index returns the counts slot for v.
+func (h *Histogram) index(v int64) int {
+	bucket := h.bucketIndex(v)
+	sub := int(v >> (uint(bucket) + h.unitMagnitude))
+	// Bucket 0 uses all its sub-buckets; later buckets only their upper half,
+	// since the lower half is covered at finer resolution by the bucket below.
+	return (bucket+1)<<h.subBucketHalfMag + sub - h.subBucketHalfCount
+}
+
+func (h *Histogram) bucketIndex(v int64) int {
+	pow2Ceiling := 64 - bits.LeadingZeros64(uint64(v|h.subBucketMask))
+	return pow2Ceiling - int(h.unitMagnitude) - int(h.subBucketHalfMag+1)
+}
+
+// slot returns the bucket and sub-bucket of a counts index.
+func (h *Histogram) slot(i int) (bucket, sub int) {
+	bucket = (i >> h.subBucketHalfMag) - 1
+	sub = (i & (h.subBucketHalfCount - 1)) + h.subBucketHalfCount
+	if bucket < 0 {
+		sub -= h.subBucketHalfCount
+		bucket = 0
+	}
+	return bucket, sub
+}
+
+// lowestEquivalent returns the smallest value that falls in slot i.
+func (h *Histogram) lowestEquivalent(i int) int64 {
+	bucket, sub := h.slot(i)
+	return int64(sub) << (uint(bucket) + h.unitMagnitude)
+}
+
+// slotWidth returns how many distinct values share slot i.
+func (h *Histogram) slotWidth(i int) int64 {
+	bucket, _ := h.slot(i)
+	return 1 << (uint(bucket) + h.unitMagnitude)
+}
+
+func (h *Histogram) highestEquivalent(i int) int64 {
+	return h.lowestEquivalent(i) + h.slotWidth(i) - 1
+}
+
+func (h *Histogram) midpoint(i int) int64 {
+	return h.lowestEquivalent(i) + h.slotWidth(i)/2
+}
@@ -0,0 +1,173 @@
+package histogram
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+)
+
+// newLatency returns a histogram laid out like the generator's: nanoseconds
+// from 1µs to 1h.
+func newLatency(digits int) *Histogram {
+	return New(int64(time.Microsecond), int64(time.Hour), digits)
+}
+
+func TestEmpty(t *testing.T) {
+	h := newLatency(3)
+	if h.Count() != 0 || h.Min() != 0 || h.Max() != 0 || h.Mean() != 0 || h.ValueAt(99) != 0 {
+		t.Errorf("expected zero values for an empty histogram")
+	}
+	if h.Bars(10) != nil {
+		t.Errorf("expected no bars for an empty histogram")
+	}
+}
+
+// TestPercentileAccuracy checks that percentiles stay within the configured
+// number of significant digits of the exact nearest-rank value.
+func TestPercentileAccuracy(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var values []int64
+	for i := 0; i < 100000; i++ {
+		// Log-uniform between 100µs and 10s, the spread of real latencies.
+		v := int64(math.Exp(rng.Float64()*math.Log(1e5)) * float64(100*time.Microsecond))
+		values = append(values, v)
+	}
+	sorted := append([]int64(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	for _, digits := range []int{2, 3, 4} {
+		h := newLatency(digits)
+		for _, v := range values {
+			h.Record(v)
+		}
+		tolerance := math.Pow10(-digits)
+		for _, p := range []float64{50, 90, 95, 99, 99.9} {
+			rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+			exact := float64(sorted[rank-1])
+			got := float64(h.ValueAt(p))
+			if rel := math.Abs(got-exact) / exact; rel > tolerance {
+				t.Errorf("digits=%d p%v: got %v, exact %v (relative error %.5f > %.5f)", digits, p, got, exact, rel, tolerance)
+			}
+		}
+		if h.Min() != sorted[0] || h.Max() != sorted[len(sorted)-1] {
+			t.Errorf("digits=%d: expected exact min/max %d/%d, got %d/%d", digits, sorted[0], sorted[len(sorted)-1], h.Min(), h.Max())
+		}
+		if h.ValueAt(100) != h.Max() {
+			t.Errorf("digits=%d: expected p100 to equal max", digits)
+		}
+	}
+}
+
+// TestFixedMemory checks that recording more values never grows the histogram.
+func TestFixedMemory(t *testing.T) {
+	h := newLatency(3)
+	size := len(h.counts)
+	for i := 0; i < 1000000; i++ {
+		h.Record(int64(i) * int64(time.Microsecond))
+	}
+	if len(h.counts) != size {
+		t.Errorf("expected %d slots after recording, got %d", size, len(h.counts))
+	}
+	if h.Count() != 1000000 {
+		t.Errorf("expected 1000000 values, got %d", h.Count())
+	}
+}
+
+func TestMeanAndClamping(t *testing.T) {
+	h := New(1, 1000, 3)
+	h.Record(-5)   // counts as 0
+	h.Record(10)   // in range
+	h.Record(5000) // above highest: top bucket, but Max stays exact
+	if h.Count() != 3 {
+		t.Fatalf("expected 3 values, got %d", h.Count())
+	}
+	if h.Mean() != float64(0+10+5000)/3 {
+		t.Errorf("expected exact mean, got %f", h.Mean())
+	}
+	if h.Min() != 0 || h.Max() != 5000 {
+		t.Errorf("expected min 0 and max 5000, got %d and %d", h.Min(), h.Max())
+	}
+}
+
+// TestPowerOfTwoHighest checks that highest itself is trackable when it falls
+// on a bucket boundary.
+func TestPowerOfTwoHighest(t *testing.T) {
+	for _, highest := range []int64{4096, 8192, 1 << 20} {
+		h := New(1, highest, 3)
+		h.Record(highest)
+		h.Record(highest * 2) // clamped to highest
+		if got := h.ValueAt(100); got < highest {
+			t.Errorf("highest %d: expected p100 of at least %d, got %d", highest, highest, got)
+		}
+	}
+}
+
+// TestMerge checks that merging equals recording everything in one histogram,
+// and that differently laid out histograms still merge their counts.
+func TestMerge(t *testing.T) {
+	a, b, all := newLatency(3), newLatency(3), newLatency(3)
+	for i := int64(1); i <= 1000; i++ {
+		v := i * int64(time.Millisecond)
+		if i%2 == 0 {
+			a.Record(v)
+		} else {
+			b.Record(v)
+		}
+		all.Record(v)
+	}
+	a.Merge(b)
+	if a.Count() != all.Count() || a.Min() != all.Min() || a.Max() != all.Max() || a.Mean() != all.Mean() {
+		t.Errorf("merged summary differs from recording everything at once")
+	}
+	for _, p := range []float64{50, 90, 99} {
+		if a.ValueAt(p) != all.ValueAt(p) {
+			t.Errorf("p%v: merged %d, direct %d", p, a.ValueAt(p), all.ValueAt(p))
+		}
+	}
+
+	coarse := newLatency(2)
+	coarse.Merge(all)
+	if coarse.Count() != all.Count() {
+		t.Errorf("expected %d values after merging across layouts, got %d", all.Count(), coarse.Count())
+	}
+	if got, want := float64(coarse.ValueAt(50)), float64(all.ValueAt(50)); math.Abs(got-want)/want > 0.01 {
+		t.Errorf("expected p50 within 1%% across layouts, got %v vs %v", got, want)
+	}
+}
+
+func TestBars(t *testing.T) {
+	h := newLatency(3)
+	// All samples equal → a single bar holding everything.
+	for i := 0; i < 3; i++ {
+		h.Record(int64(5 * time.Millisecond))
+	}
+	if bars := h.Bars(10); len(bars) != 1 || bars[0].Count != 3 {
+		t.Fatalf("expected one bar with count 3, got %v", bars)
+	}
+
+	// A 0..9ms spread into 10 bars: counts sum to the input size and the
+	// min/max land in the first/last bars.
+	h.Reset()
+	for i := 0; i < 10; i++ {
+		h.Record(int64(i) * int64(time.Millisecond))
+	}
+	bars := h.Bars(10)
+	if len(bars) != 10 {
+		t.Fatalf("expected 10 bars, got %d", len(bars))
+	}
+	var total int64
+	for _, b := range bars {
+		total += b.Count
+	}
+	if total != 10 {
+		t.Errorf("expected bar counts to sum to 10, got %d", total)
+	}
+	if bars[0].Count == 0 || bars[9].Count == 0 {
+		t.Errorf("expected the min and max in the first and last bars, got %v", bars)
+	}
+	if bars[0].Start != 0 || bars[9].End != int64(9*time.Millisecond) {
+		t.Errorf("expected bars to span [0, 9ms], got [%d, %d]", bars[0].Start, bars[9].End)
+	}
+}
@@ -0,0 +1,148 @@
+// Package jsonpath selects a single value from decoded JSON with a small
+// subset of JSONPath:
+//
+//	$                 the root
+//	$.user.id         object members by name
+//	$['user-name']    object members by quoted name (any characters)
+//	$.items[0]        array elements by index
+//	$.items[-1]       array elements counted from the end
+//
+// Filters, wildcards and recursive descent are not supported: a path always
+// selects at most one value.
+package jsonpath
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Path is a parsed JSONPath expression.
+type Path struct {
+	expr  string
+	steps []step
+}
+
+// step selects an object member (key) or an array element (index).
+type step struct {
+	key   string
+	index int
+	isKey bool
+}
+
+// Parse parses a JSONPath expression. The leading "$" may be omitted, so
+// "user.id" is the same as "$.user.id".
+func Parse(expr string) (Path, error) {
+	p := Path{expr: expr}
+	rest := strings.TrimSpace(expr)
+	rest = strings.TrimPrefix(rest, "$")
+	if rest != "" && rest[0] != '.' && rest[0] != '[' {
+		rest = "." + rest
+	}
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			name := rest[:end]
+			if name == "" || strings.ContainsAny(name, " \t]'\"") {
+				return Path{}, fmt.Errorf("%q: invalid member name %q (quote it as ['name'])", expr, name)
+			}
+			if name == "*" {
+				return Path{}, fmt.Errorf("%q: wildcards are not supported", expr)
+			}
+			p.steps = append(p.steps, step{key: name, isKey: true})
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return Path{}, fmt.Errorf("%q: unclosed [", expr)
+			}
+			inner := strings.TrimSpace(rest[1:end])
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				p.steps = append(p.steps, step{key: inner[1 : len(inner)-1], isKey: true})
+			} else {
+				i, err := strconv.Atoi(inner)
+				if err != nil {
+					return Path{}, fmt.Errorf("%q: [%s] is not an index or a quoted name", expr, inner)
+				}
+				p.steps = append(p.steps, step{index: i})
+			}
+			rest = rest[end+1:]
+		default:
+			return Path{}, fmt.Errorf("%q: unexpected %q", expr, rest[0])
+		}
+	}
+	return p, nil
+}
+
+// String returns the expression the path was parsed from.
+func (p Path) String() string { return p.expr }
+
+// Select returns the value at the path in v, a value decoded from JSON
+// (maps, slices, strings, numbers, booleans and nil), and whether it exists.
+func (p Path) Select(v interface{}) (interface{}, bool) {
+	for _, st := range p.steps {
+		if st.isKey {
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if v, ok = m[st.key]; !ok {
+				return nil, false
+			}
+			continue
+		}
+		a, ok := v.([]interface{})
+		if !ok {
+			return nil, false
+		}
+		i := st.index
+		if i < 0 {
+			i += len(a)
+		}
+		if i < 0 || i >= len(a) {
+			return nil, false
+		}
+		v = a[i]
+	}
+	return v, true
+}
+
+// Lookup decodes a JSON document and returns the value at the path as a
+// string: strings as they are, and any other value in its JSON form (e.g.
+// 42, true, {"a":1}). Numbers keep their exact digits.
+func (p Path) Lookup(doc []byte) (string, bool) {
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return "", false
+	}
+	v, ok := p.Select(v)
+	if !ok {
+		return "", false
+	}
+	return String(v), true
+}
+
+// String formats a value decoded from JSON: strings as they are, and any
+// other value in its JSON form.
+func String(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
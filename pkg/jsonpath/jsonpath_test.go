@@ -0,0 +1,63 @@
+package jsonpath
+
+import "testing"
+
+const doc = `{
+  "token": "abc",
+  "user": {"id": 12345678901234567890, "active": true, "roles": ["admin", "ops"]},
+  "items": [{"sku": "a1"}, {"sku": "b2"}],
+  "odd-key": {"x y": null}
+}`
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"$.token", "abc"},
+		{"token", "abc"},
+		{"$.user.id", "12345678901234567890"},
+		{"$.user.active", "true"},
+		{"$.user.roles", `["admin","ops"]`},
+		{"$.user.roles[1]", "ops"},
+		{"$.items[-1].sku", "b2"},
+		{"$.items[0]", `{"sku":"a1"}`},
+		{"$['odd-key'][\"x y\"]", "null"},
+	}
+	for _, tt := range tests {
+		p, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.expr, err)
+			continue
+		}
+		got, ok := p.Lookup([]byte(doc))
+		if !ok || got != tt.want {
+			t.Errorf("%s = %q (found %v), want %q", tt.expr, got, ok, tt.want)
+		}
+	}
+}
+
+func TestLookup_Missing(t *testing.T) {
+	for _, expr := range []string{"$.nope", "$.token.x", "$.items[2]", "$.items[-3]", "$.user[0]"} {
+		p, err := Parse(expr)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", expr, err)
+			continue
+		}
+		if got, ok := p.Lookup([]byte(doc)); ok {
+			t.Errorf("%s: expected no value, got %q", expr, got)
+		}
+	}
+	p, _ := Parse("$.token")
+	if _, ok := p.Lookup([]byte("not json")); ok {
+		t.Errorf("expected no value from a body that is not JSON")
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, expr := range []string{"$.", "$..a", "$.items[", "$.items[x]", "$.items.*", "$a b"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q): expected an error", expr)
+		}
+	}
+}